@@ -4,65 +4,139 @@ import (
 	"fmt"
 	"image"
 	"math"
+	"slices"
 	"time"
 
+	"github.com/dbriemann/vigor/config"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/colorm"
-	"github.com/tanema/gween"
 	"github.com/tanema/gween/ease"
 )
 
-// TODO: add callbacks?
-// after update, draw, loop?
-// see what is needed first.
-
 var (
-	ErrColumnMismatch     = fmt.Errorf("columns do not match with frame width and padding")
-	ErrRowMismatch        = fmt.Errorf("rows do not match with frame height and padding")
-	ErrFrameCountZero     = fmt.Errorf("the calculated frame count is zero")
-	ErrFrameExceedsBounds = fmt.Errorf("frame index exceeds section bounds")
-	ErrUnknownEaseFunc    = fmt.Errorf("ease function name unknown")
+	ErrColumnMismatch     = config.ErrColumnMismatch
+	ErrRowMismatch        = config.ErrRowMismatch
+	ErrSectionOutOfBounds = config.ErrSectionOutOfBounds
+	ErrFrameCountZero     = config.ErrFrameCountZero
+	ErrFrameExceedsBounds = config.ErrFrameExceedsBounds
+	ErrUnknownEaseFunc    = config.ErrUnknownEaseFunc
 	ErrNoAnimations       = fmt.Errorf("no animations defined")
 	ErrNoSections         = fmt.Errorf("no sections defined")
 	ErrUnknownAnimation   = fmt.Errorf("unknown animation")
-	ErrFileNotFound       = fmt.Errorf("file not found")
-	ErrImageNotLoaded     = fmt.Errorf("image not loaded")
+	ErrFileNotFound       = config.ErrFileNotFound
+	ErrImageNotLoaded     = config.ErrImageNotLoaded
 	ErrTemplateNotFound   = fmt.Errorf("template not found")
+	ErrFrameDurationCount = config.ErrFrameDurationCount
+	ErrUnknownPlayMode    = config.ErrUnknownPlayMode
+	ErrFrameBoxCount      = config.ErrFrameBoxCount
+	ErrFrameBoxConflict   = config.ErrFrameBoxConflict
 )
 
+// Section is the area of a sprite sheet that is sliced into a grid of frames.
 type Section struct {
-	left    int
-	top     int
-	width   int
-	height  int
-	padding int
+	left   int
+	top    int
+	width  int
+	height int
+	// margin is the space between the section border and the grid, spacing the space between cells.
+	margin  int
+	spacing int
 }
 
+// NewSection creates a section with the same padding around the grid and between its cells.
+// A width or height of zero extends the section to the right or bottom edge of the sheet.
 func NewSection(left, top, width, height, padding int) Section {
+	return NewSpacedSection(left, top, width, height, padding, padding)
+}
+
+// NewSpacedSection creates a section with a margin around the grid that differs from the spacing between cells.
+func NewSpacedSection(left, top, width, height, margin, spacing int) Section {
 	s := Section{
 		left:    left,
 		top:     top,
 		width:   width,
 		height:  height,
-		padding: padding,
+		margin:  margin,
+		spacing: spacing,
 	}
 	return s
 }
 
+// newConfigSection creates the section defined in a config.
+func newConfigSection(sc SectionConfig) Section {
+	area, margin, spacing := sc.Grid()
+	return NewSpacedSection(area.Min.X, area.Min.Y, area.Dx(), area.Dy(), margin, spacing)
+}
+
 func (s *Section) Bounds() image.Rectangle {
 	return image.Rect(s.left, s.top, s.left+s.width, s.top+s.height)
 }
 
+// PlayMode is the order in which the frames of an animation are played.
+type PlayMode int
+
+const (
+	PlayForward PlayMode = iota
+	PlayReverse
+	// PlayPingPong plays the frames forward and then backward, which together is one loop.
+	PlayPingPong
+)
+
+// parseEaseFunc returns the ease function with the given name, see config.CheckEaseFunc. An empty name is linear.
+func parseEaseFunc(name string) (ease.TweenFunc, error) {
+	if name == "" {
+		return ease.Linear, nil
+	}
+	f, ok := easeFuncMappings[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEaseFunc, name)
+	}
+	return f, nil
+}
+
+// parsePlayMode returns the play mode with the given name, see config.CheckPlayMode. An empty name is forward.
+func parsePlayMode(name string) (PlayMode, error) {
+	if name == "" {
+		return PlayForward, nil
+	}
+	mode, ok := playModeMappings[name]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownPlayMode, name)
+	}
+	return mode, nil
+}
+
+// AnimationTemplate is the shared definition of animations. Animations copy its playback settings,
+// so they can be changed for every animation on its own.
 type AnimationTemplate struct {
-	Sheet       *ebiten.Image
-	EaseFunc    ease.TweenFunc
-	Images      []*ebiten.Image
-	Frames      []int
-	Section     Section
-	FrameWidth  int
-	FrameHeight int
-	Duration    time.Duration
-	Looped      bool
+	Sheet    *ebiten.Image
+	EaseFunc ease.TweenFunc
+	Images   []*ebiten.Image
+	Frames   []int
+	// FrameDurations holds the duration of every entry in Frames, EaseFunc warps the time over their sum.
+	// If it is empty, Duration is spread evenly over all frames with EaseFunc.
+	FrameDurations []time.Duration
+	// Events are the names of the events that are emitted when a step of Frames is entered, by step.
+	Events map[int][]string
+	// Hitboxes are named rectangles relative to the frame, indexed like Images.
+	// An empty rectangle means the hitbox does not exist in that frame.
+	Hitboxes map[string][]image.Rectangle
+	// Pivots are the origin points of the frames, relative to the frame and indexed like Images. Sprites use them
+	// to attach things to the frame. If it is empty, the pivot is the upper left corner.
+	Pivots []image.Point
+	// frameTransforms restore trimmed and rotated frames, indexed like Images. It may be empty.
+	frameTransforms []frameTransform
+	Section         Section
+	FrameWidth      int
+	FrameHeight     int
+	Duration        time.Duration
+	Mode            PlayMode
+	// Loops is the number of times the animation is played. If it is zero, looped animations are played forever
+	// and all others once.
+	Loops int
+	// Speed multiplies the time of the animation. Zero means normal speed.
+	Speed  float64
+	Looped bool
 }
 
 func NewAnimationTemplate(sheet *ebiten.Image, section Section, w, h int, frames []int, duration time.Duration, looped bool, easeFunc ease.TweenFunc) (*AnimationTemplate, error) {
@@ -78,54 +152,81 @@ func NewAnimationTemplate(sheet *ebiten.Image, section Section, w, h int, frames
 		Looped:      looped,
 	}
 
-	// Calculate frame positions relative to the sprite sheet.
-	// NOTE: A padding larger than the frame will break this function.
-
-	// Check if section and frame size are a fit.
-	if sheet.Bounds().Max.X%(t.FrameWidth+t.Section.padding) != t.Section.padding {
-		return nil, ErrColumnMismatch
+	images, err := sliceSection(sheet, section, w, h)
+	if err != nil {
+		return nil, err
 	}
-	if sheet.Bounds().Max.Y%(t.FrameHeight+t.Section.padding) != t.Section.padding {
-		return nil, ErrRowMismatch
+	for _, f := range t.Frames {
+		if f < 0 || f >= len(images) {
+			return nil, fmt.Errorf("%w: frame %d of %d", ErrFrameExceedsBounds, f, len(images))
+		}
 	}
-	columns := sheet.Bounds().Max.X / (t.FrameWidth + t.Section.padding)
-	rows := sheet.Bounds().Max.Y / (t.FrameHeight + t.Section.padding)
+	t.Images = images
 
-	numFrames := columns * rows
-	if numFrames == 0 {
-		return nil, ErrFrameCountZero
+	return &t, nil
+}
+
+// SetFrameDurations sets the duration of every frame and the total duration to their sum.
+func (t *AnimationTemplate) SetFrameDurations(durations []time.Duration) error {
+	if len(durations) != len(t.Frames) {
+		return fmt.Errorf("%w: %d durations, %d frames", ErrFrameDurationCount, len(durations), len(t.Frames))
 	}
-	for _, f := range t.Frames {
-		if f >= numFrames {
-			return nil, ErrFrameExceedsBounds
-		}
+	t.FrameDurations = durations
+	t.Duration = 0
+	for _, d := range durations {
+		t.Duration += d
 	}
+	return nil
+}
 
-	for y := 0; y < rows; y++ {
-		for x := 0; x < columns; x++ {
-			upperLeft := image.Point{
-				X: t.Section.left + (x+1)*t.Section.padding + x*t.FrameWidth,
-				Y: t.Section.top + (y+1)*t.Section.padding + y*t.FrameHeight,
-			}
-			subImg := sheet.SubImage(image.Rect(
-				upperLeft.X,
-				upperLeft.Y,
-				upperLeft.X+t.FrameWidth,
-				upperLeft.Y+t.FrameHeight,
-			)).(*ebiten.Image)
-			t.Images = append(t.Images, subImg)
-		}
+// sliceSection cuts the sheet into a grid of cells with the given size, row by row.
+func sliceSection(sheet *ebiten.Image, section Section, w, h int) ([]*ebiten.Image, error) {
+	cells, err := sectionCells(sheet.Bounds(), section, w, h)
+	if err != nil {
+		return nil, err
 	}
 
-	return &t, nil
+	images := []*ebiten.Image{}
+	for _, cell := range cells {
+		images = append(images, sheet.SubImage(cell).(*ebiten.Image))
+	}
+
+	return images, nil
+}
+
+// sectionCells calculates the grid cells of a section for a sheet with the given bounds.
+// The cells are absolute, so sheets that are sub-images (e.g. of an atlas) are supported.
+func sectionCells(bounds image.Rectangle, section Section, w, h int) ([]image.Rectangle, error) {
+	return config.GridCells(bounds, section.Bounds(), w, h, section.margin, section.spacing)
 }
 
 type Animation struct {
 	*AnimationTemplate
-	Tween    *gween.Tween
+	// Frame is the index of the current frame in Images.
 	Frame    int
 	Paused   bool
 	Finished bool
+
+	// The playback settings are copied from the template.
+	frames         []int
+	frameDurations []time.Duration
+	duration       time.Duration
+	easeFunc       ease.TweenFunc
+	mode           PlayMode
+	events         map[int][]string
+	// loops is the number of times the animation is played, zero means forever.
+	loops  int
+	speed  float64
+	played int
+	// elapsed is the time since the start of the current loop.
+	elapsed time.Duration
+	// pos is the index of the current frame in the playback order, see step. It is -1 until the first frame is entered.
+	pos int
+
+	frameCallbacks  map[int][]func()
+	loopCallbacks   []func()
+	finishCallbacks []func()
+	eventCallbacks  []func(name string)
 }
 
 func NewAnimation(template *AnimationTemplate) (*Animation, error) {
@@ -137,12 +238,23 @@ func NewAnimation(template *AnimationTemplate) (*Animation, error) {
 	}
 	a := &Animation{
 		AnimationTemplate: template,
-		Frame:             template.Frames[0],
 		Paused:            true,
-		Finished:          len(template.Frames) == 1,
+		frames:            template.Frames,
+		frameDurations:    template.FrameDurations,
+		duration:          template.Duration,
+		easeFunc:          template.EaseFunc,
+		mode:              template.Mode,
+		events:            template.Events,
+		loops:             template.Loops,
+		speed:             template.Speed,
 	}
-
-	a.InitTween()
+	if a.loops == 0 && !template.Looped {
+		a.loops = 1
+	}
+	if a.speed == 0 {
+		a.speed = 1
+	}
+	a.Reset()
 
 	return a, nil
 }
@@ -152,11 +264,13 @@ func (a *Animation) Run() {
 	a.Paused = false
 }
 
-// Reset set the animation to frame zero, keeps paused state as is.
+// Reset sets the animation to its first frame, keeps paused state as is.
 func (a *Animation) Reset() {
-	a.Frame = a.Frames[0]
+	a.pos = -1
+	a.played = 0
+	a.elapsed = 0
+	a.Frame = a.frames[a.step(0)]
 	a.Finished = false
-	a.Tween.Reset()
 }
 
 // Stop pauses an animation at the current frame.
@@ -164,43 +278,331 @@ func (a *Animation) Stop() {
 	a.Paused = true
 }
 
-// Update selects the current frame to draw considering the easing function.
+// PlayFrom restarts the animation at a step of its frames and runs it. With easing, the step starts
+// at the unwarped time.
+func (a *Animation) PlayFrom(step int) {
+	a.Reset()
+	last := len(a.frames) - 1
+	step = max(0, min(step, last))
+	start, end := a.stepTime(step)
+	pos := step
+	if a.mode == PlayReverse {
+		// Reversed time runs from the end of the step to its start.
+		start = a.duration - end
+		pos = last - step
+	}
+	a.elapsed = start
+	a.enter(pos)
+	a.Finished = false
+	a.Run()
+}
+
+// Update advances the time of the animation and selects the current frame.
 func (a *Animation) Update(dt float32) {
 	if a.Paused || a.Finished {
 		return
 	}
 
-	interpolation, finished := a.Tween.Update(dt)
-	frameIndex := int(math.Round(float64(interpolation)))
-	a.Frame = a.Frames[frameIndex]
+	cycle := a.duration
+	if a.mode == PlayPingPong {
+		cycle *= 2
+	}
+	a.elapsed += time.Duration(float64(dt) * a.speed * float64(time.Second))
 
-	if finished && a.Looped {
-		a.Reset()
-		return
+	looped, finished := false, false
+	if a.elapsed >= cycle {
+		a.played++
+		if cycle > 0 && (a.loops == 0 || a.played < a.loops) {
+			a.elapsed %= cycle
+			looped = true
+		} else {
+			a.elapsed = cycle
+			finished = true
+		}
+	}
+
+	a.advance(a.position(a.elapsed), looped)
+	a.finish(finished)
+}
+
+// position returns the index in the playback order at a time of the current loop.
+// The easing function warps the time of every pass over the frames.
+func (a *Animation) position(t time.Duration) int {
+	last := len(a.frames) - 1
+	backward := a.mode == PlayReverse
+	if a.mode == PlayPingPong && t > a.duration {
+		t -= a.duration
+		backward = true
+	}
+	if a.easeFunc != nil && a.duration > 0 {
+		total := float32(a.duration.Seconds())
+		t = time.Duration(float64(a.easeFunc(float32(min(t, a.duration).Seconds()), 0, total, total)) * float64(time.Second))
+	}
+	if backward {
+		t = a.duration - t
+	}
+
+	step := last
+	if len(a.frameDurations) > 0 {
+		for i, d := range a.frameDurations {
+			if t < d {
+				step = i
+				break
+			}
+			t -= d
+		}
+	} else if a.duration > 0 {
+		step = max(0, min(last, int(math.Round(float64(t)/float64(a.duration)*float64(last)))))
+	}
+
+	switch {
+	case a.mode == PlayReverse:
+		return last - step
+	case a.mode == PlayPingPong && backward:
+		return 2*last - step
+	}
+	return step
+}
+
+// step returns the index in frames of a position in the playback order.
+func (a *Animation) step(pos int) int {
+	last := len(a.frames) - 1
+	switch a.mode {
+	case PlayReverse:
+		return last - pos
+	case PlayPingPong:
+		if pos > last {
+			return 2*last - pos
+		}
+	}
+	return pos
+}
+
+// positions returns the number of positions in the playback order.
+func (a *Animation) positions() int {
+	if a.mode == PlayPingPong {
+		return 2*len(a.frames) - 1
+	}
+	return len(a.frames)
+}
+
+// stepTime returns the time span of a step when played forward without easing.
+func (a *Animation) stepTime(step int) (time.Duration, time.Duration) {
+	if len(a.frameDurations) > 0 {
+		start := time.Duration(0)
+		for _, d := range a.frameDurations[:step] {
+			start += d
+		}
+		return start, start + a.frameDurations[step]
+	}
+	last := len(a.frames) - 1
+	if last == 0 {
+		return 0, a.duration
+	}
+	// Frames are rounded, so the first and last step are half as long as the others.
+	at := func(s float64) time.Duration {
+		return time.Duration(math.Ceil(float64(a.duration) * max(0, min(s, float64(last))) / float64(last)))
 	}
-	a.Finished = finished
+	return at(float64(step) - 0.5), at(float64(step) + 0.5)
+}
+
+// advance moves to a position in the playback order and enters all steps on the way. If looped is true,
+// the animation wrapped around to the first position before.
+func (a *Animation) advance(pos int, looped bool) {
+	if looped {
+		for p := a.pos + 1; p < a.positions(); p++ {
+			a.enter(p)
+		}
+		notify(a.loopCallbacks)
+		a.pos = -1
+	}
+	if pos < a.pos {
+		// Easing functions may move backwards.
+		a.pos = pos - 1
+	}
+	for p := a.pos + 1; p <= pos; p++ {
+		a.enter(p)
+	}
+}
+
+// enter sets the current position and calls the frame callbacks and events of its step.
+func (a *Animation) enter(pos int) {
+	a.pos = pos
+	step := a.step(pos)
+	a.Frame = a.frames[step]
+	notify(a.frameCallbacks[step])
+	for _, name := range a.events[step] {
+		for _, f := range a.eventCallbacks {
+			f(name)
+		}
+	}
+}
+
+func (a *Animation) finish(finished bool) {
+	if finished && !a.Finished {
+		a.Finished = true
+		notify(a.finishCallbacks)
+	}
+}
+
+func notify(callbacks []func()) {
+	for _, f := range callbacks {
+		f()
+	}
+}
+
+// OnFrame registers a callback that is called whenever the step of Frames with the given index is entered.
+func (a *Animation) OnFrame(step int, f func()) {
+	if a.frameCallbacks == nil {
+		a.frameCallbacks = map[int][]func(){}
+	}
+	a.frameCallbacks[step] = append(a.frameCallbacks[step], f)
+}
+
+// OnLoop registers a callback that is called whenever a looped animation starts over.
+func (a *Animation) OnLoop(f func()) {
+	a.loopCallbacks = append(a.loopCallbacks, f)
+}
+
+// OnFinish registers a callback that is called when an animation that is not looped reaches its end.
+func (a *Animation) OnFinish(f func()) {
+	a.finishCallbacks = append(a.finishCallbacks, f)
+}
+
+// OnEvent registers a callback that is called with the name of every event of the template that is emitted.
+func (a *Animation) OnEvent(f func(name string)) {
+	a.eventCallbacks = append(a.eventCallbacks, f)
+}
+
+// Hitbox returns the named hitbox of the current frame relative to the frame.
+func (a *Animation) Hitbox(name string) (image.Rectangle, bool) {
+	boxes, ok := a.Hitboxes[name]
+	if !ok || a.Frame >= len(boxes) || boxes[a.Frame].Empty() {
+		return image.Rectangle{}, false
+	}
+	return boxes[a.Frame], true
+}
+
+// Pivot returns the pivot of the current frame relative to the frame.
+func (a *Animation) Pivot() image.Point {
+	if a.Frame >= len(a.Pivots) {
+		return image.Point{}
+	}
+	return a.Pivots[a.Frame]
+}
+
+// stepsToImages maps values given per step of frames to the images the steps show, see config.CheckSteps.
+func stepsToImages[T comparable](frames []int, images int, values []T) ([]T, error) {
+	if err := config.CheckSteps(frames, images, values); err != nil {
+		return nil, err
+	}
+	out := make([]T, images)
+	for step, f := range frames {
+		out[f] = values[step]
+	}
+	return out, nil
+}
+
+// SetStepBoxes sets a named hitbox of every image from boxes given per step of Frames.
+func (t *AnimationTemplate) SetStepBoxes(name string, boxes []image.Rectangle) error {
+	imageBoxes, err := stepsToImages(t.Frames, len(t.Images), boxes)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if t.Hitboxes == nil {
+		t.Hitboxes = map[string][]image.Rectangle{}
+	}
+	t.Hitboxes[name] = imageBoxes
+	return nil
+}
+
+// SetStepPivots sets the pivot of every image from pivots given per step of Frames.
+func (t *AnimationTemplate) SetStepPivots(pivots []image.Point) error {
+	imagePivots, err := stepsToImages(t.Frames, len(t.Images), pivots)
+	if err != nil {
+		return err
+	}
+	t.Pivots = imagePivots
+	return nil
 }
 
 func (a *Animation) Draw(target *ebiten.Image, op colorm.DrawImageOptions) {
 	cm := colorm.ColorM{}
+	if a.Frame < len(a.frameTransforms) {
+		a.frameTransforms[a.Frame].apply(&op.GeoM, a.Images[a.Frame])
+	}
 	colorm.DrawImage(target, a.Images[a.Frame], cm, &op)
 }
 
+// SetFrames sets the frames of this animation. Frame durations that do not match anymore are dropped,
+// so the duration is spread evenly. Events and frame callbacks move to the steps that show the same frame,
+// those of frames that are not shown anymore are dropped.
 func (a *Animation) SetFrames(frames []int) {
-	a.Frames = frames
-	if len(a.Frames) == 1 {
-		a.Finished = true
+	a.events = remapSteps(a.frames, frames, a.events)
+	a.frameCallbacks = remapSteps(a.frames, frames, a.frameCallbacks)
+	a.frames = frames
+	if len(a.frameDurations) != len(frames) {
+		a.frameDurations = nil
+	}
+	a.Reset()
+}
+
+// remapSteps moves the values of the steps of the old frames to every step of the new frames that shows the same frame.
+// If a frame is shown at several old steps, the values of the first one that has any are used.
+func remapSteps[T any](old, frames []int, byStep map[int][]T) map[int][]T {
+	if len(byStep) == 0 {
+		return byStep
+	}
+	stepOf := map[int]int{}
+	for step, f := range old {
+		if _, ok := stepOf[f]; !ok && len(byStep[step]) > 0 {
+			stepOf[f] = step
+		}
+	}
+	remapped := map[int][]T{}
+	for step, f := range frames {
+		if oldStep, ok := stepOf[f]; ok {
+			// Steps showing the same frame share the values, so appending to one of them must copy.
+			remapped[step] = slices.Clip(byStep[oldStep])
+		}
 	}
+	return remapped
 }
 
+// SetDuration sets the duration of one pass over the frames. Individual frame durations are scaled.
 func (a *Animation) SetDuration(duration time.Duration) {
-	a.Duration = duration
+	if len(a.frameDurations) > 0 && a.duration > 0 {
+		scaled := make([]time.Duration, len(a.frameDurations))
+		for i, d := range a.frameDurations {
+			scaled[i] = time.Duration(float64(d) * float64(duration) / float64(a.duration))
+		}
+		a.frameDurations = scaled
+	}
+	a.elapsed = time.Duration(float64(a.elapsed) * float64(duration) / float64(max(a.duration, 1)))
+	a.duration = duration
 }
 
 func (a *Animation) SetTweenFunc(f ease.TweenFunc) {
-	a.EaseFunc = f
+	a.easeFunc = f
+}
+
+func (a *Animation) SetPlayMode(mode PlayMode) {
+	a.mode = mode
+	a.Reset()
+}
+
+// SetLoops sets the number of times the animation is played, zero plays it forever.
+func (a *Animation) SetLoops(loops int) {
+	a.loops = loops
+}
+
+// SetSpeed sets the factor the time of this animation is multiplied with.
+func (a *Animation) SetSpeed(speed float64) {
+	a.speed = max(0, speed)
 }
 
-func (a *Animation) InitTween() {
-	a.Tween = gween.New(0, float32(len(a.Frames)-1), float32(a.Duration.Seconds()), a.EaseFunc)
+// PlayDuration returns the duration of one pass over the frames of this animation, which differs from
+// the Duration of its template after SetDuration. It was called Duration before, which hid the template field.
+func (a *Animation) PlayDuration() time.Duration {
+	return a.duration
 }
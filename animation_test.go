@@ -0,0 +1,329 @@
+package vigor
+
+import (
+	"fmt"
+	"image"
+	"testing"
+	"time"
+
+	"github.com/dbriemann/vigor/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/tanema/gween/ease"
+)
+
+func TestSectionCells(t *testing.T) {
+	testcases := []struct {
+		name     string
+		bounds   image.Rectangle
+		section  Section
+		w, h     int
+		expected []image.Rectangle
+		err      error
+		message  string
+	}{
+		{
+			name:     "section inside sheet",
+			bounds:   image.Rect(0, 0, 64, 64),
+			section:  NewSection(32, 16, 20, 10, 0),
+			w:        10,
+			h:        10,
+			expected: []image.Rectangle{image.Rect(32, 16, 42, 26), image.Rect(42, 16, 52, 26)},
+		},
+		{
+			name:    "margin and spacing",
+			bounds:  image.Rect(0, 0, 64, 64),
+			section: NewSpacedSection(0, 0, 23, 14, 2, 1),
+			w:       9,
+			h:       10,
+			expected: []image.Rectangle{
+				image.Rect(2, 2, 11, 12), image.Rect(12, 2, 21, 12),
+			},
+		},
+		{
+			name:     "zero size extends to sheet edge",
+			bounds:   image.Rect(100, 50, 130, 60),
+			section:  NewSection(10, 0, 0, 0, 0),
+			w:        10,
+			h:        10,
+			expected: []image.Rectangle{image.Rect(110, 50, 120, 60), image.Rect(120, 50, 130, 60)},
+		},
+		{
+			name:     "padding",
+			bounds:   image.Rect(0, 0, 25, 13),
+			section:  NewSection(0, 0, 0, 0, 1),
+			w:        11,
+			h:        11,
+			expected: []image.Rectangle{image.Rect(1, 1, 12, 12), image.Rect(13, 1, 24, 12)},
+		},
+		{
+			name:    "columns mismatch",
+			bounds:  image.Rect(0, 0, 64, 64),
+			section: NewSpacedSection(0, 0, 25, 10, 0, 2),
+			w:       10,
+			h:       10,
+			err:     ErrColumnMismatch,
+			message: "section width 25 fits 2 cells of 10 with margin 0 and spacing 2, expected width 22 or 34",
+		},
+		{
+			name:    "rows mismatch",
+			bounds:  image.Rect(0, 0, 64, 64),
+			section: NewSpacedSection(0, 0, 12, 6, 1, 0),
+			w:       10,
+			h:       8,
+			err:     ErrRowMismatch,
+			message: "section height 6 fits 0 cells of 8 with margin 1 and spacing 0, expected height 10",
+		},
+		{
+			name:    "section exceeds sheet",
+			bounds:  image.Rect(0, 0, 64, 64),
+			section: NewSection(40, 0, 30, 10, 0),
+			w:       10,
+			h:       10,
+			err:     ErrSectionOutOfBounds,
+		},
+	}
+
+	for _, tc := range testcases {
+		cells, err := sectionCells(tc.bounds, tc.section, tc.w, tc.h)
+		if tc.err != nil {
+			assert.ErrorIs(t, err, tc.err, tc.name)
+			if tc.message != "" {
+				assert.ErrorContains(t, err, tc.message, tc.name)
+			}
+			continue
+		}
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expected, cells, tc.name)
+	}
+}
+
+func TestSectionConfigSpacing(t *testing.T) {
+	margin := 3
+	sec := newConfigSection(SectionConfig{Left: 1, Padding: 2, Margin: &margin})
+	assert.Equal(t, NewSpacedSection(1, 0, 0, 0, 3, 2), sec)
+	assert.Equal(t, NewSpacedSection(0, 0, 0, 0, 2, 2), newConfigSection(SectionConfig{Padding: 2}))
+}
+
+func TestParsePlayMode(t *testing.T) {
+	for name, expected := range playModeMappings {
+		assert.NoError(t, config.CheckPlayMode(name), name)
+		mode, err := parsePlayMode(name)
+		assert.NoError(t, err, name)
+		assert.Equal(t, expected, mode, name)
+	}
+	mode, err := parsePlayMode("")
+	assert.NoError(t, err)
+	assert.Equal(t, PlayForward, mode)
+	_, err = parsePlayMode("sideways")
+	assert.ErrorIs(t, err, ErrUnknownPlayMode)
+	assert.ErrorIs(t, config.CheckPlayMode("sideways"), ErrUnknownPlayMode)
+}
+
+func TestParseEaseFunc(t *testing.T) {
+	for name := range easeFuncMappings {
+		assert.NoError(t, config.CheckEaseFunc(name), name)
+		_, err := parseEaseFunc(name)
+		assert.NoError(t, err, name)
+	}
+	_, err := parseEaseFunc("Wobbly")
+	assert.ErrorIs(t, err, ErrUnknownEaseFunc)
+	assert.ErrorIs(t, config.CheckEaseFunc("Wobbly"), ErrUnknownEaseFunc)
+}
+
+func TestStepsToImages(t *testing.T) {
+	boxes, err := stepsToImages([]int{2, 0, 2}, 3, []image.Rectangle{image.Rect(1, 1, 2, 2), {}, image.Rect(1, 1, 2, 2)})
+	assert.NoError(t, err)
+	assert.Equal(t, []image.Rectangle{{}, {}, image.Rect(1, 1, 2, 2)}, boxes)
+
+	_, err = stepsToImages([]int{2, 0, 2}, 3, []image.Point{{1, 1}, {}, {2, 2}})
+	assert.ErrorIs(t, err, ErrFrameBoxConflict)
+}
+
+func TestAnimationEasedFrameDurations(t *testing.T) {
+	template := &AnimationTemplate{Frames: []int{0, 1, 2}, EaseFunc: ease.InQuad}
+	assert.ErrorIs(t, template.SetFrameDurations([]time.Duration{time.Second}), ErrFrameDurationCount)
+	assert.NoError(t, template.SetFrameDurations([]time.Duration{100 * time.Millisecond, 100 * time.Millisecond, 200 * time.Millisecond}))
+	assert.Equal(t, 400*time.Millisecond, template.Duration)
+
+	a, err := NewAnimation(template)
+	assert.NoError(t, err)
+	a.Run()
+
+	steps := []struct {
+		dt    float32
+		frame int
+	}{
+		// 0.15s are eased to 0.056s, which is still in the first frame.
+		{dt: 0.15, frame: 0},
+		{dt: 0.1, frame: 1},
+		{dt: 0.05, frame: 2},
+		{dt: 0.1, frame: 2},
+	}
+	for i, s := range steps {
+		a.Update(s.dt)
+		assert.Equal(t, s.frame, a.Frame, "step %d", i)
+	}
+	assert.True(t, a.Finished)
+}
+
+func TestAnimationCallbacks(t *testing.T) {
+	testcases := []struct {
+		name     string
+		template *AnimationTemplate
+		steps    []float32
+		expected []string
+	}{
+		{
+			name:     "tween looped",
+			template: &AnimationTemplate{Frames: []int{5, 6, 7}, Duration: 300 * time.Millisecond, EaseFunc: ease.Linear, Looped: true, Events: map[int][]string{1: {"hit"}}},
+			steps:    []float32{0.01, 0.25, 0.1, 0.01},
+			expected: []string{"frame 0", "frame 1", "event hit", "frame 2", "loop", "frame 0"},
+		},
+		{
+			name:     "timed once",
+			template: &AnimationTemplate{Frames: []int{0, 1, 2}, FrameDurations: []time.Duration{100 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond}, Duration: 300 * time.Millisecond, Events: map[int][]string{2: {"hit", "sound"}}},
+			steps:    []float32{0.05, 0.3, 0.1},
+			expected: []string{"frame 0", "frame 1", "frame 2", "event hit", "event sound", "finish"},
+		},
+		{
+			name:     "timed looped skipping frames",
+			template: &AnimationTemplate{Frames: []int{0, 1, 2}, FrameDurations: []time.Duration{100 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond}, Duration: 300 * time.Millisecond, Looped: true},
+			steps:    []float32{0.05, 0.3},
+			expected: []string{"frame 0", "frame 1", "frame 2", "loop", "frame 0"},
+		},
+		{
+			name:     "single frame once",
+			template: &AnimationTemplate{Frames: []int{0}, Duration: 300 * time.Millisecond, EaseFunc: ease.Linear},
+			steps:    []float32{0.1, 0.1, 0.15},
+			expected: []string{"frame 0", "finish"},
+		},
+		{
+			name:     "single frame looped",
+			template: &AnimationTemplate{Frames: []int{0}, Duration: 300 * time.Millisecond, EaseFunc: ease.Linear, Looped: true},
+			steps:    []float32{0.1, 0.1, 0.15, 0.3},
+			expected: []string{"frame 0", "loop", "frame 0", "loop", "frame 0"},
+		},
+		{
+			name:     "reverse two loops",
+			template: &AnimationTemplate{Frames: []int{0, 1, 2}, Duration: 300 * time.Millisecond, EaseFunc: ease.Linear, Mode: PlayReverse, Loops: 2},
+			steps:    []float32{0.01, 0.15, 0.2, 0.3},
+			expected: []string{"frame 2", "frame 1", "frame 0", "loop", "frame 2", "frame 1", "frame 0", "finish"},
+		},
+		{
+			name:     "pingpong once",
+			template: &AnimationTemplate{Frames: []int{0, 1, 2}, FrameDurations: []time.Duration{100 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond}, Duration: 300 * time.Millisecond, Mode: PlayPingPong},
+			steps:    []float32{0.05, 0.2, 0.1, 0.1, 0.2},
+			expected: []string{"frame 0", "frame 1", "frame 2", "frame 1", "frame 0", "finish"},
+		},
+		{
+			name:     "double speed",
+			template: &AnimationTemplate{Frames: []int{0, 1, 2}, FrameDurations: []time.Duration{100 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond}, Duration: 300 * time.Millisecond, Speed: 2},
+			steps:    []float32{0.05, 0.06, 0.05},
+			expected: []string{"frame 0", "frame 1", "frame 2", "finish"},
+		},
+	}
+
+	for _, tc := range testcases {
+		a, err := NewAnimation(tc.template)
+		assert.NoError(t, err, tc.name)
+		log := []string{}
+		for step := range tc.template.Frames {
+			a.OnFrame(step, func() { log = append(log, fmt.Sprintf("frame %d", step)) })
+		}
+		a.OnLoop(func() { log = append(log, "loop") })
+		a.OnFinish(func() { log = append(log, "finish") })
+		a.OnEvent(func(name string) { log = append(log, "event "+name) })
+
+		a.Run()
+		for _, dt := range tc.steps {
+			a.Update(dt)
+		}
+		assert.Equal(t, tc.expected, log, tc.name)
+	}
+}
+
+func TestAnimationSetFrames(t *testing.T) {
+	template := &AnimationTemplate{Frames: []int{5, 6, 7}, Duration: 300 * time.Millisecond, EaseFunc: ease.Linear, Events: map[int][]string{1: {"hit"}}}
+	a, err := NewAnimation(template)
+	assert.NoError(t, err)
+	log := []string{}
+	for step := range template.Frames {
+		a.OnFrame(step, func() { log = append(log, fmt.Sprintf("frame %d", step)) })
+	}
+	a.OnEvent(func(name string) { log = append(log, "event "+name) })
+
+	// The events and callbacks follow their frames, those of frame 5 are dropped.
+	a.SetFrames([]int{7, 6, 6})
+	a.OnFrame(1, func() { log = append(log, "added") })
+	a.Run()
+	for _, dt := range []float32{0.01, 0.1, 0.2} {
+		a.Update(dt)
+	}
+	assert.Equal(t, []string{"frame 2", "frame 1", "added", "event hit", "frame 1", "event hit"}, log)
+	assert.Equal(t, map[int][]string{1: {"hit"}}, template.Events)
+}
+
+func TestAnimationPlayFrom(t *testing.T) {
+	testcases := []struct {
+		name     string
+		mode     PlayMode
+		durs     []time.Duration
+		from     int
+		expected []int
+	}{
+		{name: "forward even", mode: PlayForward, from: 2, expected: []int{12, 12, 13, 13}},
+		{name: "forward timed", mode: PlayForward, durs: []time.Duration{time.Second, time.Second, time.Second, time.Second}, from: 1, expected: []int{11, 11, 12, 12}},
+		{name: "reverse even", mode: PlayReverse, from: 2, expected: []int{12, 12, 12, 11}},
+		{name: "pingpong timed", mode: PlayPingPong, durs: []time.Duration{time.Second, time.Second, time.Second, time.Second}, from: 2, expected: []int{12, 12, 13, 13}},
+	}
+
+	for _, tc := range testcases {
+		template := &AnimationTemplate{Frames: []int{10, 11, 12, 13}, Duration: 3 * time.Second, EaseFunc: ease.Linear, Mode: tc.mode}
+		if tc.durs != nil {
+			assert.NoError(t, template.SetFrameDurations(tc.durs), tc.name)
+		}
+		a, err := NewAnimation(template)
+		assert.NoError(t, err, tc.name)
+
+		entered := []int{}
+		for step := range template.Frames {
+			a.OnFrame(step, func() { entered = append(entered, step) })
+		}
+		a.PlayFrom(tc.from)
+		frames := []int{a.Frame}
+		for i := 0; i < 3; i++ {
+			a.Update(0.5)
+			frames = append(frames, a.Frame)
+		}
+		assert.Equal(t, tc.expected, frames, tc.name)
+		assert.Equal(t, tc.from, entered[0], tc.name)
+	}
+}
+
+func TestAnimationSettingsPerInstance(t *testing.T) {
+	template := &AnimationTemplate{Frames: []int{0, 1, 2}, Duration: time.Second, EaseFunc: ease.Linear}
+	assert.NoError(t, template.SetFrameDurations([]time.Duration{time.Second, time.Second, 2 * time.Second}))
+	a, err := NewAnimation(template)
+	assert.NoError(t, err)
+	b, err := NewAnimation(template)
+	assert.NoError(t, err)
+
+	a.SetDuration(2 * time.Second)
+	a.SetSpeed(2)
+	a.SetPlayMode(PlayReverse)
+	assert.Equal(t, 2*time.Second, a.PlayDuration())
+	assert.Equal(t, 4*time.Second, a.Duration)
+	assert.Equal(t, []time.Duration{500 * time.Millisecond, 500 * time.Millisecond, time.Second}, a.frameDurations)
+	assert.Equal(t, 4*time.Second, template.Duration)
+	assert.Equal(t, 4*time.Second, b.PlayDuration())
+	assert.Equal(t, []time.Duration{time.Second, time.Second, 2 * time.Second}, template.FrameDurations)
+	assert.Equal(t, PlayForward, template.Mode)
+	assert.Equal(t, 2, a.Frame)
+
+	a.Run()
+	a.Update(0.6)
+	assert.Equal(t, 1, a.Frame)
+	a.Update(0.5)
+	assert.True(t, a.Finished)
+	assert.Equal(t, 0, a.Frame)
+}
@@ -0,0 +1,228 @@
+package vigor
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/tanema/gween/ease"
+)
+
+var (
+	ErrUnknownDirection = fmt.Errorf("unknown animation direction")
+	ErrInvalidTag       = fmt.Errorf("tag frames out of range")
+)
+
+type asepriteTag struct {
+	Name      string `json:"name"`
+	Direction string `json:"direction"`
+	Repeat    string `json:"repeat"`
+	From      int    `json:"from"`
+	To        int    `json:"to"`
+}
+
+type asepriteSliceKey struct {
+	// Pivot is relative to the bounds.
+	Pivot *struct {
+		X int `json:"x"`
+		Y int `json:"y"`
+	} `json:"pivot"`
+	Bounds sheetRect `json:"bounds"`
+	Frame  int       `json:"frame"`
+}
+
+type asepriteSlice struct {
+	Name string             `json:"name"`
+	Keys []asepriteSliceKey `json:"keys"`
+}
+
+// asepriteData is the sprite sheet JSON that Aseprite exports.
+type asepriteData struct {
+	Frames sheetFrames `json:"frames"`
+	Meta   struct {
+		Image     string          `json:"image"`
+		FrameTags []asepriteTag   `json:"frameTags"`
+		Slices    []asepriteSlice `json:"slices"`
+	} `json:"meta"`
+}
+
+// expandTag returns the frames of a tag in playing order. Ping-pong tags play the
+// end frames only once, so looping them does not show a frame twice.
+func expandTag(tag asepriteTag, frameCount int) ([]int, error) {
+	if tag.From < 0 || tag.To >= frameCount || tag.From > tag.To {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTag, tag.Name)
+	}
+
+	forward := []int{}
+	for i := tag.From; i <= tag.To; i++ {
+		forward = append(forward, i)
+	}
+	reverse := []int{}
+	for i := tag.To; i >= tag.From; i-- {
+		reverse = append(reverse, i)
+	}
+
+	switch tag.Direction {
+	case "", "forward":
+		return forward, nil
+	case "reverse":
+		return reverse, nil
+	case "pingpong":
+		return append(forward, reverse[1:max(1, len(reverse)-1)]...), nil
+	case "pingpong_reverse":
+		return append(reverse, forward[1:max(1, len(forward)-1)]...), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownDirection, tag.Direction)
+}
+
+// sliceKeyFrames calls f with the slice key of every frame. A slice key is valid
+// from its frame until the next key.
+func sliceKeyFrames(s asepriteSlice, frameCount int, f func(frame int, k asepriteSliceKey)) {
+	keys := append([]asepriteSliceKey{}, s.Keys...)
+	sort.Slice(keys, func(a, b int) bool { return keys[a].Frame < keys[b].Frame })
+	for i, k := range keys {
+		end := frameCount
+		if i+1 < len(keys) {
+			end = min(end, keys[i+1].Frame)
+		}
+		for frame := max(0, k.Frame); frame < end; frame++ {
+			f(frame, k)
+		}
+	}
+}
+
+// sliceHitboxes maps every slice to a hitbox per frame.
+func sliceHitboxes(slices []asepriteSlice, frameCount int) map[string][]image.Rectangle {
+	hitboxes := map[string][]image.Rectangle{}
+	for _, s := range slices {
+		boxes := make([]image.Rectangle, frameCount)
+		sliceKeyFrames(s, frameCount, func(frame int, k asepriteSliceKey) {
+			boxes[frame] = k.Bounds.rect()
+		})
+		hitboxes[s.Name] = boxes
+	}
+	return hitboxes
+}
+
+// slicePivots returns the pivot of every frame from the slice keys that have one, or nil if no key has a pivot.
+// If several slices have a pivot in a frame, the last one is used.
+func slicePivots(slices []asepriteSlice, frameCount int) []image.Point {
+	var pivots []image.Point
+	for _, s := range slices {
+		sliceKeyFrames(s, frameCount, func(frame int, k asepriteSliceKey) {
+			if k.Pivot == nil {
+				return
+			}
+			if pivots == nil {
+				pivots = make([]image.Point, frameCount)
+			}
+			pivots[frame] = k.Bounds.rect().Min.Add(image.Pt(k.Pivot.X, k.Pivot.Y))
+		})
+	}
+	return pivots
+}
+
+// asepriteTemplates creates a template named "<name>_<tag>" for every tag. Without tags a single
+// template with all frames is created. The images belong to the frames in the same order.
+// A tag with a repeat count other than zero is not looped.
+func asepriteTemplates(name string, data *asepriteData, images []*ebiten.Image) (map[string]*AnimationTemplate, error) {
+	if len(data.Frames) == 0 {
+		return nil, ErrFrameCountZero
+	}
+
+	tags := data.Meta.FrameTags
+	if len(tags) == 0 {
+		tags = []asepriteTag{{To: len(data.Frames) - 1}}
+	}
+	hitboxes := sliceHitboxes(data.Meta.Slices, len(data.Frames))
+	pivots := slicePivots(data.Meta.Slices, len(data.Frames))
+
+	templates := map[string]*AnimationTemplate{}
+	for _, tag := range tags {
+		frames, err := expandTag(tag, len(data.Frames))
+		if err != nil {
+			return nil, err
+		}
+
+		t := &AnimationTemplate{
+			EaseFunc:       ease.Linear,
+			Images:         images,
+			Frames:         frames,
+			FrameDurations: []time.Duration{},
+			Hitboxes:       hitboxes,
+			Pivots:         pivots,
+			FrameWidth:     data.Frames[0].SourceSize.W,
+			FrameHeight:    data.Frames[0].SourceSize.H,
+			Looped:         tag.Repeat == "" || tag.Repeat == "0",
+		}
+		for _, f := range frames {
+			d := time.Duration(data.Frames[f].Duration) * time.Millisecond
+			t.FrameDurations = append(t.FrameDurations, d)
+			t.Duration += d
+		}
+
+		tname := name
+		if tag.Name != "" {
+			tname = name + "_" + tag.Name
+		}
+		templates[tname] = t
+	}
+
+	return templates, nil
+}
+
+// loadAseprite loads an Aseprite JSON export and the sprite sheet it references.
+func (r *AssetManager) loadAseprite(name, fpath string) (*ebiten.Image, map[string]*AnimationTemplate, error) {
+	raw, err := os.ReadFile(fpath)
+	if err != nil {
+		return nil, nil, err
+	}
+	data := &asepriteData{}
+	if err := json.Unmarshal(raw, data); err != nil {
+		return nil, nil, err
+	}
+
+	sheet, err := r.loadImage(path.Join(path.Dir(fpath), data.Meta.Image))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	images, transforms := sliceFrames(sheet, data.Frames)
+	templates, err := asepriteTemplates(name, data, images)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, t := range templates {
+		t.Sheet = sheet
+		t.frameTransforms = transforms
+	}
+
+	return sheet, templates, nil
+}
+
+// loadAsepriteAssets loads the sprite sheets of Aseprite exports. The templates of their tags are added to the
+// animation templates directly.
+func loadAsepriteAssets(ctx *AssetContext, raw json.RawMessage) (map[string]any, error) {
+	cfg := map[string]AsepriteConfig{}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, err
+	}
+	r := ctx.Assets
+	sheets := map[string]*ebiten.Image{}
+	for name, ase := range cfg {
+		sheet, templates, err := r.loadAseprite(name, path.Join(ctx.Root, ase.Path))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		sheets[name] = sheet
+		for tname, t := range templates {
+			r.AnimationTemplates[tname] = t
+		}
+	}
+	return toAssets(sheets), nil
+}
@@ -0,0 +1,184 @@
+package vigor
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dbriemann/vigor/config"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+const asepriteHash = `{
+ "frames": {
+   "knight 0.aseprite": {
+    "frame": { "x": 0, "y": 0, "w": 16, "h": 24 },
+    "rotated": false, "trimmed": false,
+    "spriteSourceSize": { "x": 0, "y": 0, "w": 16, "h": 24 },
+    "sourceSize": { "w": 16, "h": 24 },
+    "duration": 100
+   },
+   "knight 10.aseprite": {
+    "frame": { "x": 16, "y": 0, "w": 16, "h": 24 },
+    "rotated": false, "trimmed": false,
+    "spriteSourceSize": { "x": 0, "y": 0, "w": 16, "h": 24 },
+    "sourceSize": { "w": 16, "h": 24 },
+    "duration": 200
+   },
+   "knight 2.aseprite": {
+    "frame": { "x": 32, "y": 0, "w": 16, "h": 24 },
+    "rotated": false, "trimmed": false,
+    "spriteSourceSize": { "x": 0, "y": 0, "w": 16, "h": 24 },
+    "sourceSize": { "w": 16, "h": 24 },
+    "duration": 300
+   }
+ },
+ "meta": {
+  "image": "knight.png",
+  "frameTags": [
+   { "name": "idle", "from": 0, "to": 0, "direction": "forward" },
+   { "name": "walk", "from": 0, "to": 2, "direction": "pingpong" },
+   { "name": "attack", "from": 1, "to": 2, "direction": "reverse", "repeat": "1" }
+  ],
+  "slices": [
+   { "name": "sword", "color": "#0000ffff", "keys": [
+     { "frame": 1, "bounds": {"x": 10, "y": 4, "w": 6, "h": 2 } },
+     { "frame": 2, "bounds": {"x": 12, "y": 6, "w": 4, "h": 2 } }
+   ]},
+   { "name": "origin", "keys": [
+     { "frame": 0, "bounds": {"x": 4, "y": 20, "w": 8, "h": 4 }, "pivot": {"x": 4, "y": 4 } }
+   ]}
+  ]
+ }
+}`
+
+func TestSheetFramesOrder(t *testing.T) {
+	data := asepriteData{}
+	assert.NoError(t, json.Unmarshal([]byte(asepriteHash), &data))
+
+	names := []string{}
+	for _, f := range data.Frames {
+		names = append(names, f.Filename)
+	}
+	// The hash order is kept, even though it is not sorted.
+	assert.Equal(t, []string{"knight 0.aseprite", "knight 10.aseprite", "knight 2.aseprite"}, names)
+
+	arr := sheetFrames{}
+	assert.NoError(t, json.Unmarshal([]byte(`[{"filename": "a", "duration": 5}, {"filename": "b"}]`), &arr))
+	assert.Equal(t, "a", arr[0].Filename)
+	assert.Equal(t, 5, arr[0].Duration)
+	assert.Equal(t, "b", arr[1].Filename)
+}
+
+func TestExpandTag(t *testing.T) {
+	testcases := []struct {
+		name      string
+		direction string
+		from, to  int
+		frames    []int
+		err       error
+	}{
+		{name: "forward", direction: "forward", from: 1, to: 3, frames: []int{1, 2, 3}},
+		{name: "default", direction: "", from: 0, to: 1, frames: []int{0, 1}},
+		{name: "reverse", direction: "reverse", from: 1, to: 3, frames: []int{3, 2, 1}},
+		{name: "pingpong", direction: "pingpong", from: 0, to: 3, frames: []int{0, 1, 2, 3, 2, 1}},
+		{name: "pingpong reverse", direction: "pingpong_reverse", from: 0, to: 3, frames: []int{3, 2, 1, 0, 1, 2}},
+		{name: "pingpong single", direction: "pingpong", from: 2, to: 2, frames: []int{2}},
+		{name: "unknown", direction: "sideways", from: 0, to: 1, err: ErrUnknownDirection},
+		{name: "out of range", direction: "forward", from: 0, to: 4, err: ErrInvalidTag},
+	}
+
+	for _, tc := range testcases {
+		frames, err := expandTag(asepriteTag{Name: tc.name, Direction: tc.direction, From: tc.from, To: tc.to}, 4)
+		if tc.err != nil {
+			assert.ErrorIs(t, err, tc.err, tc.name)
+			continue
+		}
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, tc.frames, frames, tc.name)
+	}
+}
+
+func TestAsepriteTemplates(t *testing.T) {
+	data := &asepriteData{}
+	assert.NoError(t, json.Unmarshal([]byte(asepriteHash), data))
+
+	templates, err := asepriteTemplates("knight", data, make([]*ebiten.Image, 3))
+	assert.NoError(t, err)
+	assert.Len(t, templates, 3)
+
+	walk := templates["knight_walk"]
+	assert.Equal(t, []int{0, 1, 2, 1}, walk.Frames)
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 200 * time.Millisecond}, walk.FrameDurations)
+	assert.Equal(t, 800*time.Millisecond, walk.Duration)
+	assert.Equal(t, 16, walk.FrameWidth)
+	assert.Equal(t, 24, walk.FrameHeight)
+	assert.True(t, walk.Looped)
+	assert.False(t, templates["knight_attack"].Looped)
+
+	assert.Equal(t, []image.Rectangle{{}, image.Rect(10, 4, 16, 6), image.Rect(12, 6, 16, 8)}, walk.Hitboxes["sword"])
+	assert.Equal(t, []image.Point{{8, 24}, {8, 24}, {8, 24}}, walk.Pivots)
+}
+
+func TestAnimationFrameDurations(t *testing.T) {
+	template := &AnimationTemplate{
+		Frames:         []int{0, 1, 2},
+		FrameDurations: []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 100 * time.Millisecond},
+		Duration:       400 * time.Millisecond,
+		Hitboxes:       map[string][]image.Rectangle{"hit": {{}, image.Rect(1, 1, 2, 2), {}}},
+		Looped:         true,
+	}
+	a, err := NewAnimation(template)
+	assert.NoError(t, err)
+	a.Run()
+
+	steps := []struct {
+		dt    float32
+		frame int
+	}{
+		{dt: 0.05, frame: 0},
+		{dt: 0.1, frame: 1},
+		{dt: 0.14, frame: 1},
+		{dt: 0.02, frame: 2},
+		// Looping keeps the remaining time.
+		{dt: 0.15, frame: 0},
+	}
+	for i, s := range steps {
+		a.Update(s.dt)
+		assert.Equal(t, s.frame, a.Frame, "step %d", i)
+	}
+	assert.False(t, a.Finished)
+
+	a.Update(0.1)
+	box, ok := a.Hitbox("hit")
+	assert.True(t, ok)
+	assert.Equal(t, image.Rect(1, 1, 2, 2), box)
+	_, ok = a.Hitbox("missing")
+	assert.False(t, ok)
+}
+
+func TestLoadConfigAsepriteSheet(t *testing.T) {
+	dir := t.TempDir()
+	f, err := os.Create(filepath.Join(dir, "knight.png"))
+	assert.NoError(t, err)
+	assert.NoError(t, png.Encode(f, image.NewNRGBA(image.Rect(0, 0, 48, 24))))
+	assert.NoError(t, f.Close())
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "knight.json"), []byte(asepriteHash), 0o644))
+	// Animations can use the sheet of an Aseprite export like any other image.
+	cfg := fmt.Sprintf(`{"resourceRoot": "%s", "aseprite": {"knight": {"path": "knight.json"}},
+		"animations": {"knight_strip": {"imageName": "knight", "width": 16, "height": 24, "frames": [0, 1, 2]}}}`, filepath.ToSlash(dir))
+	fname := filepath.Join(dir, "config.json")
+	assert.NoError(t, os.WriteFile(fname, []byte(cfg), 0o644))
+
+	assert.NoError(t, config.ValidateFile(fname))
+	r := NewAssetManager()
+	assert.NoError(t, r.LoadConfig(fname))
+	assert.Contains(t, r.AnimationTemplates, "knight_walk")
+	assert.Len(t, r.AnimationTemplates["knight_strip"].Images, 3)
+}
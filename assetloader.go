@@ -0,0 +1,245 @@
+package vigor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+var (
+	ErrLoaderRegistered = fmt.Errorf("asset loader is already registered")
+	ErrAssetNotLoaded   = fmt.Errorf("asset not loaded")
+)
+
+// AssetContext is passed to asset loaders.
+type AssetContext struct {
+	// FS is the file system of the resource root.
+	FS fs.FS
+	// Root is the resource root path, for functions that need file paths.
+	Root string
+	// Assets holds the assets of the bundle that are loaded so far. Custom loaders run after all engine loaders,
+	// so they can use images, sounds, fonts and other engine assets with FindAsset.
+	Assets *AssetManager
+
+	cfg ResourceConfig
+	// atlasFrames are the animation frames packed into the atlas by animation name.
+	atlasFrames map[string][]*ebiten.Image
+}
+
+// AssetLoaderFunc loads the assets of a config section from its raw JSON and returns them by name.
+type AssetLoaderFunc func(ctx *AssetContext, raw json.RawMessage) (map[string]any, error)
+
+type assetLoader struct {
+	section string
+	// kind is the kind the loaded assets are stored as.
+	kind string
+	load AssetLoaderFunc
+}
+
+// engineLoaders load engine sections with the same mechanism as registered loaders, before all other sections.
+// Sprite sheets are images, which animations and bitmap fonts can use, so they are loaded first.
+var engineLoaders = []assetLoader{
+	{section: "aseprite", kind: assetImage, load: loadAsepriteAssets},
+	{section: "texturePacker", kind: assetImage, load: loadTexturePackerAssets},
+	{section: "images", kind: assetImage, load: loadImageAssets},
+	{section: "sections", kind: assetSection, load: loadSectionAssets},
+	{section: "sounds", kind: assetSound, load: loadSoundAssets},
+	{section: "synths", kind: assetSound, load: loadSynthAssets},
+	{section: "music", kind: assetMusic, load: loadMusicAssets},
+	{section: "fonts", kind: assetFont, load: loadFontAssets},
+	{section: "bitmapFonts", kind: assetFont, load: loadBitmapFontAssets},
+	{section: "animations", kind: assetAnimation, load: loadAnimationAssets},
+	{section: "tilemaps", kind: assetTilemap, load: loadTilemapAssets},
+	{section: "locales", kind: assetLocale, load: loadLocaleAssets},
+	{section: "stateMachines", kind: assetStateMachine, load: loadStateMachineAssets},
+}
+
+// assetLoaders are the loaders registered by the game.
+var assetLoaders = []assetLoader{}
+
+// RegisterAssetLoader registers a loader for a config section, which is called with the raw JSON of the section
+// whenever a config or bundle containing it is loaded. Its assets are unloaded with their bundle and can be
+// retrieved with GetAsset. Loaders run in the order they are registered, so a loader can use the assets of
+// loaders registered before it. Sections of the engine config cannot be overridden.
+func RegisterAssetLoader(section string, load AssetLoaderFunc) error {
+	cfgType := reflect.TypeFor[ResourceConfig]()
+	for i := 0; i < cfgType.NumField(); i++ {
+		if tag, _, _ := strings.Cut(cfgType.Field(i).Tag.Get("json"), ","); tag == section {
+			return fmt.Errorf("%w: %s", ErrLoaderRegistered, section)
+		}
+	}
+	for _, l := range assetLoaders {
+		if l.section == section {
+			return fmt.Errorf("%w: %s", ErrLoaderRegistered, section)
+		}
+	}
+	assetLoaders = append(assetLoaders, assetLoader{section: section, kind: section, load: load})
+	return nil
+}
+
+// runLoaders calls the loaders for all sections of the config and stores their assets.
+func (r *AssetManager) runLoaders(ctx *AssetContext, loaders []assetLoader) error {
+	for _, l := range loaders {
+		raw, ok := ctx.cfg.Raw(l.section)
+		if !ok {
+			continue
+		}
+		assets, err := l.load(ctx, raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", l.section, err)
+		}
+		for name, a := range assets {
+			r.setAsset(assetKey{l.kind, name}, a)
+		}
+	}
+	return nil
+}
+
+// GetAsset returns the asset with the given name and type from the assets of the game.
+func GetAsset[T any](name string) (T, error) {
+	return FindAsset[T](&G.assets, name)
+}
+
+// FindAsset returns the asset with the given name and type. Assets of different kinds may share a name,
+// the type decides which one is returned.
+func FindAsset[T any](r *AssetManager, name string) (T, error) {
+	kinds := []string{}
+	for _, l := range append(engineLoaders[:len(engineLoaders):len(engineLoaders)], assetLoaders...) {
+		if !slices.Contains(kinds, l.kind) {
+			kinds = append(kinds, l.kind)
+		}
+	}
+	for _, kind := range kinds {
+		if a, ok := r.asset(assetKey{kind, name}).(T); ok {
+			return a, nil
+		}
+	}
+
+	var zero T
+	return zero, fmt.Errorf("%w: %s (%v)", ErrAssetNotLoaded, name, reflect.TypeFor[T]())
+}
+
+func loadImageAssets(ctx *AssetContext, raw json.RawMessage) (map[string]any, error) {
+	r := ctx.Assets
+	if ctx.cfg.Atlas.Enabled {
+		images, frames, err := r.loadAtlas(ctx.cfg)
+		if err != nil {
+			return nil, err
+		}
+		ctx.atlasFrames = frames
+		return toAssets(images), nil
+	}
+
+	cfg := map[string]string{}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, err
+	}
+	images := map[string]*ebiten.Image{}
+	for relPath, name := range cfg {
+		ebImg, err := r.loadImage(path.Join(ctx.Root, relPath))
+		if err != nil {
+			return nil, err
+		}
+		images[name] = ebImg
+	}
+	return toAssets(images), nil
+}
+
+func loadSectionAssets(_ *AssetContext, raw json.RawMessage) (map[string]any, error) {
+	cfg := map[string]SectionConfig{}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, err
+	}
+	sections := map[string]Section{}
+	for name, sec := range cfg {
+		sections[name] = newConfigSection(sec)
+	}
+	return toAssets(sections), nil
+}
+
+func loadAnimationAssets(ctx *AssetContext, raw json.RawMessage) (map[string]any, error) {
+	cfg := map[string]AnimationConfig{}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, err
+	}
+	r := ctx.Assets
+	templates := map[string]*AnimationTemplate{}
+	for animName, template := range cfg {
+		imgName := template.ImageName
+		img, ok := r.Images[imgName]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrImageNotLoaded, imgName)
+		}
+
+		f, err := parseEaseFunc(template.EaseFunc)
+		if err != nil {
+			return nil, err
+		}
+		mode, err := parsePlayMode(template.Mode)
+		if err != nil {
+			return nil, err
+		}
+		a, err := NewAnimationTemplate(
+			img,
+			r.Sections[template.SectionName],
+			template.Width,
+			template.Height,
+			template.Frames,
+			time.Duration(template.Duration*float64(time.Second)),
+			template.Looped,
+			f,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", animName, err)
+		}
+		if len(template.FrameDurations) > 0 {
+			durations := make([]time.Duration, len(template.FrameDurations))
+			for i, d := range template.FrameDurations {
+				durations[i] = time.Duration(d * float64(time.Second))
+			}
+			if err := a.SetFrameDurations(durations); err != nil {
+				return nil, fmt.Errorf("%s: %w", animName, err)
+			}
+		}
+		a.Mode = mode
+		a.Loops = template.Loops
+		a.Speed = template.Speed
+		if len(template.Events) > 0 {
+			a.Events = map[int][]string{}
+			for _, event := range sortedKeys(template.Events) {
+				step := template.Events[event]
+				a.Events[step] = append(a.Events[step], event)
+			}
+		}
+		if frames, ok := ctx.atlasFrames[animName]; ok {
+			a.Images = frames
+		}
+		for _, boxName := range sortedKeys(template.Hitboxes) {
+			if err := a.SetStepBoxes(boxName, template.StepBoxes(boxName)); err != nil {
+				return nil, fmt.Errorf("%s: %w", animName, err)
+			}
+		}
+		if len(template.Pivots) > 0 {
+			if err := a.SetStepPivots(template.StepPivots()); err != nil {
+				return nil, fmt.Errorf("%s: %w", animName, err)
+			}
+		}
+		templates[animName] = a
+	}
+	return toAssets(templates), nil
+}
+
+func toAssets[T any](m map[string]T) map[string]any {
+	assets := make(map[string]any, len(m))
+	for name, a := range m {
+		assets[name] = a
+	}
+	return assets
+}
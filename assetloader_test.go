@@ -0,0 +1,107 @@
+package vigor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type testDialogue struct {
+	Lines    []string `json:"lines"`
+	Portrait *ebiten.Image
+}
+
+func TestAssetLoaders(t *testing.T) {
+	defer func(loaders []assetLoader) {
+		assetLoaders = loaders
+	}(assetLoaders)
+
+	assert.NoError(t, RegisterAssetLoader("dialogues", func(ctx *AssetContext, raw json.RawMessage) (map[string]any, error) {
+		cfg := map[string]struct {
+			Portrait string `json:"portrait"`
+			File     string `json:"file"`
+		}{}
+		if err := json.Unmarshal(raw, &cfg); err != nil {
+			return nil, err
+		}
+		dialogues := map[string]any{}
+		for name, d := range cfg {
+			data, err := os.ReadFile(filepath.Join(ctx.Root, d.File))
+			if err != nil {
+				return nil, err
+			}
+			dlg := &testDialogue{}
+			if err := json.Unmarshal(data, &dlg.Lines); err != nil {
+				return nil, err
+			}
+			if dlg.Portrait, err = FindAsset[*ebiten.Image](ctx.Assets, d.Portrait); err != nil {
+				return nil, err
+			}
+			dialogues[name] = dlg
+		}
+		return dialogues, nil
+	}))
+	assert.ErrorIs(t, RegisterAssetLoader("dialogues", nil), ErrLoaderRegistered)
+	assert.ErrorIs(t, RegisterAssetLoader("images", nil), ErrLoaderRegistered)
+
+	dir := writeBundleTestFiles(t)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "intro.json"), []byte(`["Hello", "Bye"]`), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "dialogues.yaml"), []byte(`
+resourceRoot: `+filepath.ToSlash(dir)+`
+images:
+  a.png: hero
+dialogues:
+  hero:
+    portrait: hero
+    file: intro.json
+`), 0o644))
+
+	r := NewAssetManager()
+	assert.NoError(t, r.LoadConfig(filepath.Join(dir, "base.json")))
+	assert.NoError(t, r.LoadBundle("dialogues", filepath.Join(dir, "dialogues.yaml")))
+
+	dlg, err := FindAsset[*testDialogue](&r, "hero")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Hello", "Bye"}, dlg.Lines)
+	// Assets of different kinds with the same name are told apart by their type.
+	img, err := FindAsset[*ebiten.Image](&r, "hero")
+	assert.NoError(t, err)
+	assert.Same(t, img, dlg.Portrait)
+	_, err = FindAsset[*ebiten.Image](&r, "ui")
+	assert.NoError(t, err)
+	_, err = FindAsset[*AnimationTemplate](&r, "hero")
+	assert.ErrorIs(t, err, ErrAssetNotLoaded)
+	assert.Equal(t, 1, r.Resident().Assets["dialogues"])
+
+	assert.NoError(t, r.UnloadBundle("dialogues"))
+	_, err = FindAsset[*testDialogue](&r, "hero")
+	assert.ErrorIs(t, err, ErrAssetNotLoaded)
+}
+
+func TestEngineLoaders(t *testing.T) {
+	// Every asset section of the config is loaded by an engine loader.
+	cfgType := reflect.TypeFor[ResourceConfig]()
+	for i := 0; i < cfgType.NumField(); i++ {
+		field := cfgType.Field(i)
+		if !field.IsExported() || field.Type.Kind() != reflect.Map {
+			continue
+		}
+		section, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		assert.True(t, slices.ContainsFunc(engineLoaders, func(l assetLoader) bool {
+			return l.section == section
+		}), section)
+	}
+
+	dir := writeBundleTestFiles(t)
+	r := NewAssetManager()
+	assert.NoError(t, r.LoadConfig(filepath.Join(dir, "fonts.json")))
+	_, err := FindAsset[Font](&r, "small")
+	assert.NoError(t, err)
+}
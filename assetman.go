@@ -5,96 +5,204 @@ import (
 	"image"
 	"os"
 	"path"
-	"time"
+	"sort"
 
 	_ "image/jpeg"
 	_ "image/png"
 
+	"github.com/dbriemann/vigor/config"
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
 // TODO: make private after old vigorflap is removed.
 type AssetManager struct {
-	// TODO: audio
 	// TODO: others
 	Images             map[string]*ebiten.Image
+	Sounds             map[string]*Sound
+	Music              map[string]*MusicTrack
+	Fonts              map[string]Font
+	Tilemaps           map[string]*TiledMap
 	Sections           map[string]Section
 	AnimationTemplates map[string]*AnimationTemplate
 	RootPath           string
+
+	// imageFrames holds the sprite sheet frames of images, which may be trimmed or rotated.
+	imageFrames map[string]sheetFrame
+	// custom holds the assets of registered asset loaders.
+	custom  map[assetKey]any
+	images  *imageCache
+	bundles []*bundle
+	loaders []*BundleLoader
+	// decoded holds the files decoded in the background for the bundle that is currently created.
+	decoded *decodedFiles
 }
 
 // TODO: warn if asset manager is used before init function of game.
 func NewAssetManager() AssetManager {
 	r := AssetManager{
+		loaders:            []*BundleLoader{},
 		Images:             map[string]*ebiten.Image{},
+		Sounds:             map[string]*Sound{},
+		Music:              map[string]*MusicTrack{},
+		Fonts:              map[string]Font{},
+		Tilemaps:           map[string]*TiledMap{},
 		Sections:           map[string]Section{},
 		AnimationTemplates: map[string]*AnimationTemplate{},
+		imageFrames:        map[string]sheetFrame{},
+		custom:             map[assetKey]any{},
+		images:             newImageCache(),
+		bundles:            []*bundle{},
 	}
 	return r
 }
 
+// LoadConfig loads the assets of a config file, which stay loaded for the whole game.
+// The config is validated first, so all problems found are returned as ConfigErrors.
 func (r *AssetManager) LoadConfig(fname string) error {
-	cfg, err := loadConfigData[ResourceConfig](fname)
+	cfg, err := config.Load[ResourceConfig](fname)
 	if err != nil {
 		return err
 	}
+	if errs := config.Validate(cfg); len(errs) > 0 {
+		return errs
+	}
+	return r.loadBundle(baseBundle, cfg, nil)
+}
 
+func (r *AssetManager) loadConfig(cfg ResourceConfig) error {
 	r.RootPath = cfg.ResourceRoot
+	root := r.RootPath
+	if root == "" {
+		root = "."
+	}
+	ctx := &AssetContext{FS: os.DirFS(root), Root: r.RootPath, Assets: r, cfg: cfg}
 
-	for relPath, name := range cfg.Images {
-		f, err := os.Open(path.Join(r.RootPath, relPath))
-		if err != nil {
-			return err
-		}
-		defer f.Close()
+	if err := r.runLoaders(ctx, engineLoaders); err != nil {
+		return err
+	}
 
-		img, _, err := image.Decode(f)
-		if err != nil {
-			return err
-		}
-		ebImg := ebiten.NewImageFromImage(img)
+	return r.runLoaders(ctx, assetLoaders)
+}
 
-		r.Images[name] = ebImg
+// loadAtlas decodes all images and packs the small ones together with the animation frames
+// of the large ones into atlas pages. It returns the images by name and the packed frames by animation name.
+func (r *AssetManager) loadAtlas(cfg ResourceConfig) (map[string]*ebiten.Image, map[string][]*ebiten.Image, error) {
+	atlas := cfg.Atlas
+	if atlas.PageSize == 0 {
+		atlas.PageSize = defaultAtlasPageSize
+	}
+	if atlas.MaxImageSize == 0 {
+		atlas.MaxImageSize = defaultAtlasMaxImageSize
+	}
+	fits := func(size image.Point) bool {
+		return size.X <= atlas.MaxImageSize && size.Y <= atlas.MaxImageSize
 	}
 
-	// TODO: audio
-	// TODO: others
+	// Iterate in a fixed order, so the atlas layout is the same every time.
+	relPaths := make([]string, 0, len(cfg.Images))
+	for relPath := range cfg.Images {
+		relPaths = append(relPaths, relPath)
+	}
+	sort.Strings(relPaths)
+
+	images := map[string]*ebiten.Image{}
+	items := []atlasItem{}
+	packed := []string{}
+	decoded := map[string]image.Image{}
+	for _, relPath := range relPaths {
+		name := cfg.Images[relPath]
+		img, err := r.decoded.image(path.Join(r.RootPath, relPath))
+		if err != nil {
+			return nil, nil, err
+		}
+		decoded[name] = img
+		if fits(img.Bounds().Size()) {
+			items = append(items, atlasItem{src: img, rect: img.Bounds()})
+			packed = append(packed, name)
+		} else {
+			ebImg, err := r.images.load(path.Join(r.RootPath, relPath), func() (image.Image, error) {
+				return img, nil
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			images[name] = ebImg
+		}
+	}
 
-	for name, sec := range cfg.Sections {
-		r.Sections[name] = NewSection(sec.Left, sec.Top, sec.Width, sec.Height, sec.Padding)
+	animNames := make([]string, 0, len(cfg.Animations))
+	for animName := range cfg.Animations {
+		animNames = append(animNames, animName)
 	}
+	sort.Strings(animNames)
 
-	for animName, template := range cfg.Animations {
-		imgName := template.ImageName
-		img, ok := r.Images[imgName]
-		if !ok {
-			return fmt.Errorf("%w: %s", ErrImageNotLoaded, imgName)
+	type frameRange struct {
+		animName   string
+		start, end int
+	}
+	ranges := []frameRange{}
+	for _, animName := range animNames {
+		anim := cfg.Animations[animName]
+		img, ok := decoded[anim.ImageName]
+		// Frames of images that are packed as a whole are already part of the atlas.
+		if !ok || fits(img.Bounds().Size()) || !fits(image.Pt(anim.Width, anim.Height)) {
+			continue
 		}
-
-		if template.EaseFunc == "" {
-			template.EaseFunc = "Linear"
+		sec := cfg.Sections[anim.SectionName]
+		cells, err := sectionCells(img.Bounds(), newConfigSection(sec), anim.Width, anim.Height)
+		if err != nil {
+			// The error is reported when the animation template is created.
+			continue
 		}
-		f, ok := easeFuncMappings[template.EaseFunc]
-		if !ok {
-			return fmt.Errorf("%w: %s", ErrUnknownEaseFunc, template.EaseFunc)
+		ranges = append(ranges, frameRange{animName: animName, start: len(items), end: len(items) + len(cells)})
+		for _, cell := range cells {
+			items = append(items, atlasItem{src: img, rect: cell})
 		}
-		a, err := NewAnimationTemplate(
-			img,
-			r.Sections[template.SectionName],
-			template.Width,
-			template.Height,
-			template.Frames,
-			time.Duration(template.Duration*float64(time.Second)),
-			template.Looped,
-			f,
-		)
-		if err != nil {
-			return err
+	}
+
+	packedImages, ebPages, pages, err := buildAtlas(items, atlas.PageSize, atlas.Padding)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, page := range ebPages {
+		r.images.own(page)
+	}
+	for i, name := range packed {
+		images[name] = packedImages[i]
+	}
+	frames := map[string][]*ebiten.Image{}
+	for _, fr := range ranges {
+		frames[fr.animName] = packedImages[fr.start:fr.end]
+	}
+
+	if atlas.DumpDir != "" {
+		if err := dumpAtlas(atlas.DumpDir, pages); err != nil {
+			return nil, nil, err
 		}
-		r.AnimationTemplates[animName] = a
 	}
 
-	return nil
+	return images, frames, nil
+}
+
+func decodeImage(fpath string) (image.Image, error) {
+	f, err := os.Open(fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// loadImage returns the image of a file, which is shared with other bundles loading the same file.
+func (r *AssetManager) loadImage(fpath string) (*ebiten.Image, error) {
+	return r.images.load(path.Clean(fpath), func() (image.Image, error) {
+		return r.decoded.image(fpath)
+	})
 }
 
 func (r *AssetManager) GetImageOrPanic(name string) *ebiten.Image {
@@ -112,3 +220,19 @@ func (r *AssetManager) GetAnimTemplateOrPanic(name string) *AnimationTemplate {
 	}
 	return templ
 }
+
+func (r *AssetManager) GetTilemapOrPanic(name string) *TiledMap {
+	m, ok := r.Tilemaps[name]
+	if !ok {
+		panic(fmt.Sprintf("could not load tilemap %s from asset manager: does not exist", name))
+	}
+	return m
+}
+
+func (r *AssetManager) GetFontOrPanic(name string) Font {
+	f, ok := r.Fonts[name]
+	if !ok {
+		panic(fmt.Sprintf("could not load font %s from asset manager: does not exist", name))
+	}
+	return f
+}
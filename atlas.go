@@ -0,0 +1,148 @@
+package vigor
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+var ErrAtlasItemTooLarge = fmt.Errorf("image does not fit into an atlas page")
+
+// atlasItem is a region of a decoded image that is copied into an atlas page.
+type atlasItem struct {
+	src  image.Image
+	rect image.Rectangle
+}
+
+type atlasPlacement struct {
+	page int
+	pos  image.Point
+}
+
+type atlasShelf struct {
+	y      int
+	height int
+	x      int
+}
+
+type atlasPage struct {
+	shelves []atlasShelf
+	bottom  int
+}
+
+// packShelves places rectangles of the given sizes on square pages with a shelf algorithm.
+// Rectangles are packed from the tallest to the lowest and keep padding pixels to each other and
+// to the page borders. It returns the placements in the order of sizes and the number of pages.
+func packShelves(sizes []image.Point, pageSize, padding int) ([]atlasPlacement, int, error) {
+	order := make([]int, len(sizes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		sa, sb := sizes[order[a]], sizes[order[b]]
+		if sa.Y != sb.Y {
+			return sa.Y > sb.Y
+		}
+		return sa.X > sb.X
+	})
+
+	placements := make([]atlasPlacement, len(sizes))
+	pages := []atlasPage{}
+
+outer:
+	for _, i := range order {
+		size := sizes[i]
+		if size.X+2*padding > pageSize || size.Y+2*padding > pageSize {
+			return nil, 0, fmt.Errorf("%w: %dx%d", ErrAtlasItemTooLarge, size.X, size.Y)
+		}
+
+		// Use the first shelf with enough space.
+		for p := range pages {
+			for s := range pages[p].shelves {
+				shelf := &pages[p].shelves[s]
+				if size.Y <= shelf.height && shelf.x+size.X+padding <= pageSize {
+					placements[i] = atlasPlacement{page: p, pos: image.Pt(shelf.x, shelf.y)}
+					shelf.x += size.X + padding
+					continue outer
+				}
+			}
+		}
+
+		// Open a new shelf on the first page with enough height left, or on a new page.
+		p := 0
+		for ; p < len(pages); p++ {
+			if pages[p].bottom+size.Y+padding <= pageSize {
+				break
+			}
+		}
+		if p == len(pages) {
+			pages = append(pages, atlasPage{bottom: padding})
+		}
+		shelf := atlasShelf{y: pages[p].bottom, height: size.Y, x: padding}
+		placements[i] = atlasPlacement{page: p, pos: image.Pt(shelf.x, shelf.y)}
+		shelf.x += size.X + padding
+		pages[p].shelves = append(pages[p].shelves, shelf)
+		pages[p].bottom += size.Y + padding
+	}
+
+	return placements, len(pages), nil
+}
+
+// buildAtlas copies the items into atlas pages. It returns an image for each item,
+// which is a sub-image of an atlas page, the atlas pages and the decoded pages for debugging.
+func buildAtlas(items []atlasItem, pageSize, padding int) ([]*ebiten.Image, []*ebiten.Image, []*image.NRGBA, error) {
+	sizes := make([]image.Point, len(items))
+	for i, item := range items {
+		sizes[i] = item.rect.Size()
+	}
+	placements, pageCount, err := packShelves(sizes, pageSize, padding)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	pages := make([]*image.NRGBA, pageCount)
+	for p := range pages {
+		pages[p] = image.NewNRGBA(image.Rect(0, 0, pageSize, pageSize))
+	}
+	for i, item := range items {
+		pl := placements[i]
+		dst := image.Rectangle{Min: pl.pos, Max: pl.pos.Add(sizes[i])}
+		draw.Draw(pages[pl.page], dst, item.src, item.rect.Min, draw.Src)
+	}
+
+	ebPages := make([]*ebiten.Image, pageCount)
+	for p, page := range pages {
+		ebPages[p] = ebiten.NewImageFromImage(page)
+	}
+	images := make([]*ebiten.Image, len(items))
+	for i, pl := range placements {
+		images[i] = ebPages[pl.page].SubImage(image.Rectangle{Min: pl.pos, Max: pl.pos.Add(sizes[i])}).(*ebiten.Image)
+	}
+
+	return images, ebPages, pages, nil
+}
+
+// dumpAtlas writes every atlas page as PNG file into dir.
+func dumpAtlas(dir string, pages []*image.NRGBA) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for i, page := range pages {
+		f, err := os.Create(path.Join(dir, fmt.Sprintf("atlas_%d.png", i)))
+		if err != nil {
+			return err
+		}
+		err = png.Encode(f, page)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
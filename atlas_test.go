@@ -0,0 +1,81 @@
+package vigor
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackShelves(t *testing.T) {
+	testcases := []struct {
+		name     string
+		sizes    []image.Point
+		pageSize int
+		padding  int
+		pages    int
+		err      error
+	}{
+		{
+			name:     "single page",
+			sizes:    []image.Point{{10, 10}, {20, 5}, {5, 20}, {30, 30}},
+			pageSize: 64,
+			padding:  1,
+			pages:    1,
+		},
+		{
+			name:     "overflow to second page",
+			sizes:    []image.Point{{30, 30}, {30, 30}, {30, 30}, {30, 30}, {30, 30}},
+			pageSize: 64,
+			padding:  1,
+			pages:    2,
+		},
+		{
+			name:     "exact fit without padding",
+			sizes:    []image.Point{{32, 32}, {32, 32}, {32, 32}, {32, 32}},
+			pageSize: 64,
+			padding:  0,
+			pages:    1,
+		},
+		{
+			name:     "too large",
+			sizes:    []image.Point{{64, 10}},
+			pageSize: 64,
+			padding:  1,
+			err:      ErrAtlasItemTooLarge,
+		},
+	}
+
+	for _, tc := range testcases {
+		placements, pages, err := packShelves(tc.sizes, tc.pageSize, tc.padding)
+		if tc.err != nil {
+			assert.ErrorIs(t, err, tc.err, tc.name)
+			continue
+		}
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, tc.pages, pages, tc.name)
+
+		page := image.Rect(tc.padding, tc.padding, tc.pageSize-tc.padding, tc.pageSize-tc.padding)
+		for i, pl := range placements {
+			r := image.Rectangle{Min: pl.pos, Max: pl.pos.Add(tc.sizes[i])}
+			assert.True(t, r.In(page), "%s: %v is outside of the page", tc.name, r)
+
+			// Padded rectangles must not overlap.
+			for j := i + 1; j < len(placements); j++ {
+				if placements[j].page != pl.page {
+					continue
+				}
+				other := image.Rectangle{Min: placements[j].pos, Max: placements[j].pos.Add(tc.sizes[j])}
+				padded := image.Rectangle{Min: r.Min.Sub(image.Pt(tc.padding, tc.padding)), Max: r.Max.Add(image.Pt(tc.padding, tc.padding))}
+				assert.False(t, padded.Overlaps(other), "%s: %v overlaps %v", tc.name, r, other)
+			}
+		}
+	}
+}
+
+func TestSectionCellsHonorOrigin(t *testing.T) {
+	section := NewSection(0, 0, 20, 10, 0)
+	cells, err := sectionCells(image.Rect(100, 50, 120, 60), section, 10, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []image.Rectangle{image.Rect(100, 50, 110, 60), image.Rect(110, 50, 120, 60)}, cells)
+}
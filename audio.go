@@ -0,0 +1,321 @@
+package vigor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/mp3"
+	"github.com/hajimehoshi/ebiten/v2/audio/vorbis"
+	"github.com/hajimehoshi/ebiten/v2/audio/wav"
+)
+
+var (
+	ErrUnknownSound     = fmt.Errorf("unknown sound")
+	ErrUnsupportedAudio = fmt.Errorf("unsupported audio format")
+)
+
+// bytesPerFrame is the size of one stereo frame of 16 bit samples, which is the format ebiten plays.
+const bytesPerFrame = 4
+
+// Sound holds the fully decoded PCM data of a sound effect.
+type Sound struct {
+	data         []byte
+	bus          string
+	volume       float64
+	maxInstances int
+}
+
+// SoundOptions alter a single playback of a sound.
+type SoundOptions struct {
+	Volume         float64 // Volume is multiplied with the configured sound volume. Zero means 1.
+	Pan            float64 // Pan ranges from -1 (left) to 1 (right).
+	Pitch          float64 // Pitch is the playback speed factor. Zero means 1.
+	PitchVariation float64 // PitchVariation randomizes the pitch within [Pitch-v, Pitch+v].
+	Bus            string  // Bus overrides the configured mixer bus of the sound.
+}
+
+// SoundInstance is a handle to a playing sound.
+type SoundInstance struct {
+	player   *audio.Player
+	name     string
+	bus      string
+	volume   float64
+	base     float64
+	fadeTime float32
+	fadeDur  float32
+	fading   bool
+	stopped  bool
+}
+
+// Stop stops the sound immediately.
+func (s *SoundInstance) Stop() {
+	if s.stopped {
+		return
+	}
+	s.stopped = true
+	s.player.Pause()
+	s.player.Close()
+}
+
+// FadeOut lowers the volume of the sound to zero over duration seconds and stops it afterwards.
+func (s *SoundInstance) FadeOut(duration float32) {
+	if s.stopped {
+		return
+	}
+	if duration <= 0 {
+		s.Stop()
+		return
+	}
+	s.fading = true
+	s.fadeTime = 0
+	s.fadeDur = duration
+}
+
+func (s *SoundInstance) IsPlaying() bool {
+	return !s.stopped && s.player.IsPlaying()
+}
+
+func (s *SoundInstance) SetVolume(v float64) {
+	s.volume = v
+	s.applyVolume()
+}
+
+func (s *SoundInstance) applyVolume() {
+	v := s.volume * s.base * G.mixer.effective(s.bus)
+	if s.fading {
+		v *= float64(1 - s.fadeTime/s.fadeDur)
+	}
+	s.player.SetVolume(v)
+}
+
+func (s *SoundInstance) update() {
+	if s.stopped {
+		return
+	}
+	if s.fading {
+		s.fadeTime += G.Dt()
+		if s.fadeTime >= s.fadeDur {
+			s.Stop()
+			return
+		}
+	}
+	// The mixer buses may have changed.
+	s.applyVolume()
+}
+
+type audioSystem struct {
+	context   *audio.Context
+	instances map[string][]*SoundInstance
+}
+
+func (a *audioSystem) init() {
+	a.context = audio.CurrentContext()
+	if a.context == nil {
+		a.context = audio.NewContext(audioSampleRate)
+	}
+	a.instances = map[string][]*SoundInstance{}
+}
+
+func (a *audioSystem) play(name string, opts SoundOptions) (*SoundInstance, error) {
+	snd, ok := G.assets.Sounds[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSound, name)
+	}
+
+	// Steal the oldest instance if the sound is already played too often.
+	playing := a.instances[name]
+	if len(playing) >= snd.maxInstances {
+		playing[0].Stop()
+		playing = playing[1:]
+	}
+
+	bus := snd.bus
+	if opts.Bus != "" {
+		if !G.mixer.hasBus(opts.Bus) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownBus, opts.Bus)
+		}
+		bus = opts.Bus
+	}
+
+	volume := opts.Volume
+	if volume == 0 {
+		volume = 1
+	}
+	pitch := opts.Pitch
+	if pitch == 0 {
+		pitch = 1
+	}
+	if opts.PitchVariation > 0 {
+		pitch += (rand.Float64()*2 - 1) * opts.PitchVariation
+	}
+
+	inst := &SoundInstance{
+		player: a.context.NewPlayerFromBytes(processPCM(snd.data, pitch, opts.Pan)),
+		name:   name,
+		bus:    bus,
+		volume: volume,
+		base:   snd.volume,
+	}
+	inst.applyVolume()
+	inst.player.Play()
+	a.instances[name] = append(playing, inst)
+
+	return inst, nil
+}
+
+// update advances fades and forgets about instances that finished playing.
+// It returns true if any sound on the voice bus is still playing.
+func (a *audioSystem) update() (voicePlaying bool) {
+	for name, list := range a.instances {
+		alive := list[:0]
+		for _, inst := range list {
+			inst.update()
+			if inst.IsPlaying() {
+				alive = append(alive, inst)
+				voicePlaying = voicePlaying || inst.bus == BusVoice
+			} else {
+				inst.Stop()
+			}
+		}
+		a.instances[name] = alive
+	}
+	return voicePlaying
+}
+
+// PlaySound plays the sound with the given name from the asset manager.
+// If the maximum amount of concurrent instances is reached, the oldest instance is stopped.
+func PlaySound(name string, opts SoundOptions) (*SoundInstance, error) {
+	return G.audio.play(name, opts)
+}
+
+type audioStream interface {
+	io.ReadSeeker
+	Length() int64
+}
+
+// decodeAudio picks a decoder by file extension and resamples to the sample rate of the game.
+func decodeAudio(fpath string, src io.Reader) (audioStream, error) {
+	switch strings.ToLower(path.Ext(fpath)) {
+	case ".wav":
+		s, err := wav.DecodeWithSampleRate(audioSampleRate, src)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case ".ogg":
+		s, err := vorbis.DecodeWithSampleRate(audioSampleRate, src)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case ".mp3":
+		s, err := mp3.DecodeWithSampleRate(audioSampleRate, src)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedAudio, fpath)
+}
+
+// decodeSoundFile decodes a whole sound file into PCM data.
+func decodeSoundFile(fpath string) ([]byte, error) {
+	raw, err := os.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := decodeAudio(fpath, bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(stream)
+}
+
+func newSound(data []byte, bus string, volume float64, maxInstances int) (*Sound, error) {
+	s := &Sound{
+		data:         data,
+		bus:          bus,
+		volume:       volume,
+		maxInstances: maxInstances,
+	}
+	if s.volume == 0 {
+		s.volume = 1
+	}
+	if s.maxInstances <= 0 {
+		s.maxInstances = defaultMaxSoundInstances
+	}
+	if s.bus == "" {
+		s.bus = BusSfx
+	}
+	if !G.mixer.hasBus(s.bus) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownBus, s.bus)
+	}
+	return s, nil
+}
+
+func loadSoundAssets(ctx *AssetContext, raw json.RawMessage) (map[string]any, error) {
+	cfg := map[string]SoundConfig{}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, err
+	}
+	sounds := map[string]*Sound{}
+	for name, snd := range cfg {
+		data, err := ctx.Assets.decoded.sound(path.Join(ctx.Root, snd.Path))
+		if err != nil {
+			return nil, err
+		}
+		s, err := newSound(data, snd.Bus, snd.Volume, snd.MaxInstances)
+		if err != nil {
+			return nil, err
+		}
+		sounds[name] = s
+	}
+	return toAssets(sounds), nil
+}
+
+// processPCM resamples the data by the pitch factor and applies the stereo panning.
+// The original data is returned if nothing has to be changed.
+func processPCM(data []byte, pitch, pan float64) []byte {
+	if pitch == 1 && pan == 0 {
+		return data
+	}
+	if pitch <= 0 {
+		pitch = 1
+	}
+	pan = math.Max(-1, math.Min(1, pan))
+	left := math.Min(1, 1-pan)
+	right := math.Min(1, 1+pan)
+
+	frames := len(data) / bytesPerFrame
+	outFrames := int(float64(frames) / pitch)
+	out := make([]byte, outFrames*bytesPerFrame)
+
+	sample := func(frame, channel int) float64 {
+		if frame >= frames {
+			frame = frames - 1
+		}
+		return float64(int16(binary.LittleEndian.Uint16(data[frame*bytesPerFrame+channel*2:])))
+	}
+
+	for i := 0; i < outFrames; i++ {
+		// Linear interpolation between the two nearest source frames.
+		pos := float64(i) * pitch
+		f := int(pos)
+		t := pos - float64(f)
+		for ch, gain := range []float64{left, right} {
+			v := (sample(f, ch)*(1-t) + sample(f+1, ch)*t) * gain
+			binary.LittleEndian.PutUint16(out[i*bytesPerFrame+ch*2:], uint16(int16(v)))
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,83 @@
+package vigor
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestPCM(frames int, left, right int16) []byte {
+	data := make([]byte, frames*bytesPerFrame)
+	for i := 0; i < frames; i++ {
+		binary.LittleEndian.PutUint16(data[i*bytesPerFrame:], uint16(left))
+		binary.LittleEndian.PutUint16(data[i*bytesPerFrame+2:], uint16(right))
+	}
+	return data
+}
+
+func pcmFrame(data []byte, frame int) (left, right int16) {
+	left = int16(binary.LittleEndian.Uint16(data[frame*bytesPerFrame:]))
+	right = int16(binary.LittleEndian.Uint16(data[frame*bytesPerFrame+2:]))
+	return
+}
+
+func TestProcessPCM(t *testing.T) {
+	testcases := []struct {
+		name   string
+		pitch  float64
+		pan    float64
+		frames int
+		left   int16
+		right  int16
+	}{
+		{
+			name:   "no change",
+			pitch:  1,
+			pan:    0,
+			frames: 100,
+			left:   1000,
+			right:  1000,
+		},
+		{
+			name:   "double pitch halves the length",
+			pitch:  2,
+			pan:    0,
+			frames: 50,
+			left:   1000,
+			right:  1000,
+		},
+		{
+			name:   "half pitch doubles the length",
+			pitch:  0.5,
+			pan:    0,
+			frames: 200,
+			left:   1000,
+			right:  1000,
+		},
+		{
+			name:   "pan right mutes left channel",
+			pitch:  1,
+			pan:    1,
+			frames: 100,
+			left:   0,
+			right:  1000,
+		},
+		{
+			name:   "pan half left",
+			pitch:  1,
+			pan:    -0.5,
+			frames: 100,
+			left:   1000,
+			right:  500,
+		},
+	}
+
+	for _, tc := range testcases {
+		out := processPCM(newTestPCM(100, 1000, 1000), tc.pitch, tc.pan)
+		assert.Equal(t, tc.frames*bytesPerFrame, len(out), tc.name)
+		l, r := pcmFrame(out, tc.frames/2)
+		assert.Equal(t, tc.left, l, tc.name)
+		assert.Equal(t, tc.right, r, tc.name)
+	}
+}
@@ -0,0 +1,318 @@
+package vigor
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dbriemann/vigor/config"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+var (
+	ErrCharCountMismatch = config.ErrCharCountMismatch
+	ErrInvalidKerning    = config.ErrInvalidKerning
+	ErrInvalidBMFont     = fmt.Errorf("invalid BMFont file")
+)
+
+var _ Font = (*bitmapFont)(nil)
+
+type bitmapGlyph struct {
+	image   *ebiten.Image
+	offset  image.Point
+	advance int
+}
+
+// bitmapFont draws glyphs from images, usually cells of a sprite sheet.
+type bitmapFont struct {
+	glyphs     map[rune]bitmapGlyph
+	kerning    map[[2]rune]int
+	lineHeight int
+}
+
+// glyph returns the glyph for r. Unknown runes are replaced with '?' if the font has one.
+func (f *bitmapFont) glyph(r rune) (bitmapGlyph, bool) {
+	g, ok := f.glyphs[r]
+	if !ok {
+		g, ok = f.glyphs['?']
+	}
+	return g, ok
+}
+
+func (f *bitmapFont) LineHeight() int {
+	return f.lineHeight
+}
+
+func (f *bitmapFont) Advance(r rune) int {
+	g, _ := f.glyph(r)
+	return g.advance
+}
+
+func (f *bitmapFont) Kern(prev, r rune) int {
+	return f.kerning[[2]rune{prev, r}]
+}
+
+func (f *bitmapFont) drawGlyph(target *ebiten.Image, r rune, x, y int, clr color.Color) {
+	g, ok := f.glyph(r)
+	if !ok || g.image == nil {
+		return
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(x+g.offset.X), float64(y+g.offset.Y))
+	op.ColorScale.ScaleWithColor(clr)
+	target.DrawImage(g.image, op)
+}
+
+// newGridFont maps the characters to the cells of a section, row by row.
+func newGridFont(sheet *ebiten.Image, section Section, cfg BitmapFontConfig) (*bitmapFont, error) {
+	cells, err := sliceSection(sheet, section, cfg.Width, cfg.Height)
+	if err != nil {
+		return nil, err
+	}
+	if utf8.RuneCountInString(cfg.Chars) > len(cells) {
+		return nil, ErrCharCountMismatch
+	}
+
+	f := &bitmapFont{
+		glyphs:     map[rune]bitmapGlyph{},
+		kerning:    map[[2]rune]int{},
+		lineHeight: cfg.LineHeight,
+	}
+	if f.lineHeight == 0 {
+		f.lineHeight = cfg.Height
+	}
+
+	i := 0
+	for _, r := range cfg.Chars {
+		f.glyphs[r] = bitmapGlyph{
+			image:   cells[i],
+			advance: cfg.Width,
+		}
+		i++
+	}
+	for char, w := range cfg.Widths {
+		r, _ := utf8.DecodeRuneInString(char)
+		if g, ok := f.glyphs[r]; ok {
+			g.advance = w
+			f.glyphs[r] = g
+		}
+	}
+	for pair, amount := range cfg.Kerning {
+		runes := []rune(pair)
+		if len(runes) != 2 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidKerning, pair)
+		}
+		f.kerning[[2]rune{runes[0], runes[1]}] = amount
+	}
+
+	return f, nil
+}
+
+type bmFontChar struct {
+	ID       int `xml:"id,attr"`
+	X        int `xml:"x,attr"`
+	Y        int `xml:"y,attr"`
+	Width    int `xml:"width,attr"`
+	Height   int `xml:"height,attr"`
+	XOffset  int `xml:"xoffset,attr"`
+	YOffset  int `xml:"yoffset,attr"`
+	XAdvance int `xml:"xadvance,attr"`
+	Page     int `xml:"page,attr"`
+}
+
+type bmFontKerning struct {
+	First  int `xml:"first,attr"`
+	Second int `xml:"second,attr"`
+	Amount int `xml:"amount,attr"`
+}
+
+type bmFontPage struct {
+	ID   int    `xml:"id,attr"`
+	File string `xml:"file,attr"`
+}
+
+// bmFontData is the content of an AngelCode BMFont descriptor, which is either in text or XML format.
+type bmFontData struct {
+	Common struct {
+		LineHeight int `xml:"lineHeight,attr"`
+	} `xml:"common"`
+	Pages    []bmFontPage    `xml:"pages>page"`
+	Chars    []bmFontChar    `xml:"chars>char"`
+	Kernings []bmFontKerning `xml:"kernings>kerning"`
+}
+
+func parseBMFont(r io.Reader) (*bmFontData, error) {
+	br := bufio.NewReader(r)
+	start, err := br.Peek(1)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBMFont, err)
+	}
+
+	data := &bmFontData{}
+	if start[0] == '<' {
+		if err := xml.NewDecoder(br).Decode(data); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidBMFont, err)
+		}
+		return data, nil
+	}
+
+	scanner := bufio.NewScanner(br)
+	for lineNr := 1; scanner.Scan(); lineNr++ {
+		tag, attrs, err := parseBMFontLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidBMFont, lineNr, err)
+		}
+		switch tag {
+		case "common":
+			data.Common.LineHeight = attrs.ints["lineHeight"]
+		case "page":
+			data.Pages = append(data.Pages, bmFontPage{ID: attrs.ints["id"], File: attrs.strs["file"]})
+		case "char":
+			data.Chars = append(data.Chars, bmFontChar{
+				ID:       attrs.ints["id"],
+				X:        attrs.ints["x"],
+				Y:        attrs.ints["y"],
+				Width:    attrs.ints["width"],
+				Height:   attrs.ints["height"],
+				XOffset:  attrs.ints["xoffset"],
+				YOffset:  attrs.ints["yoffset"],
+				XAdvance: attrs.ints["xadvance"],
+				Page:     attrs.ints["page"],
+			})
+		case "kerning":
+			data.Kernings = append(data.Kernings, bmFontKerning{
+				First:  attrs.ints["first"],
+				Second: attrs.ints["second"],
+				Amount: attrs.ints["amount"],
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+type bmFontAttrs struct {
+	ints map[string]int
+	strs map[string]string
+}
+
+// parseBMFontLine splits a line like `char id=65 x=0 y=0` into its tag and attributes.
+// Quoted values are only kept for the file attribute of pages.
+func parseBMFontLine(line string) (string, bmFontAttrs, error) {
+	tag, rest, _ := strings.Cut(strings.TrimSpace(line), " ")
+	attrs := bmFontAttrs{
+		ints: map[string]int{},
+		strs: map[string]string{},
+	}
+
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			return "", attrs, fmt.Errorf("missing value in %q", rest)
+		}
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				return "", attrs, fmt.Errorf("unterminated string in %q", rest)
+			}
+			attrs.strs[key] = value[1 : end+1]
+			rest = value[end+2:]
+			continue
+		}
+		value, rest, _ = strings.Cut(value, " ")
+		// Lists like padding=1,1,1,1 are not needed.
+		if n, err := strconv.Atoi(value); err == nil {
+			attrs.ints[key] = n
+		}
+	}
+
+	return tag, attrs, nil
+}
+
+// loadBMFont loads a BMFont descriptor and its page images, which are relative to the descriptor.
+func (r *AssetManager) loadBMFont(fpath string) (*bitmapFont, error) {
+	fh, err := os.Open(fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	data, err := parseBMFont(fh)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := map[int]*ebiten.Image{}
+	for _, p := range data.Pages {
+		img, err := r.loadImage(path.Join(path.Dir(fpath), p.File))
+		if err != nil {
+			return nil, err
+		}
+		pages[p.ID] = img
+	}
+
+	f := &bitmapFont{
+		glyphs:     map[rune]bitmapGlyph{},
+		kerning:    map[[2]rune]int{},
+		lineHeight: data.Common.LineHeight,
+	}
+	for _, c := range data.Chars {
+		page, ok := pages[c.Page]
+		if !ok {
+			return nil, fmt.Errorf("%w: char %d references unknown page %d", ErrInvalidBMFont, c.ID, c.Page)
+		}
+		g := bitmapGlyph{
+			offset:  image.Pt(c.XOffset, c.YOffset),
+			advance: c.XAdvance,
+		}
+		if c.Width > 0 && c.Height > 0 {
+			g.image = page.SubImage(image.Rect(c.X, c.Y, c.X+c.Width, c.Y+c.Height)).(*ebiten.Image)
+		}
+		f.glyphs[rune(c.ID)] = g
+	}
+	for _, k := range data.Kernings {
+		f.kerning[[2]rune{rune(k.First), rune(k.Second)}] = k.Amount
+	}
+
+	return f, nil
+}
+
+// loadBitmapFontAssets loads BMFont descriptors and grid fonts, which are cut from loaded images.
+func loadBitmapFontAssets(ctx *AssetContext, raw json.RawMessage) (map[string]any, error) {
+	cfg := map[string]BitmapFontConfig{}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, err
+	}
+	r := ctx.Assets
+	fonts := map[string]*bitmapFont{}
+	for name, bf := range cfg {
+		var f *bitmapFont
+		var err error
+		if bf.BMFont != "" {
+			f, err = r.loadBMFont(path.Join(ctx.Root, bf.BMFont))
+		} else {
+			img, ok := r.Images[bf.ImageName]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrImageNotLoaded, bf.ImageName)
+			}
+			f, err = newGridFont(img, r.Sections[bf.SectionName], bf)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		fonts[name] = f
+	}
+	return toAssets(fonts), nil
+}
@@ -0,0 +1,79 @@
+package vigor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const bmFontText = `info face="Test" size=16 bold=0 padding=0,0,0,0 spacing=1,1
+common lineHeight=18 base=14 scaleW=64 scaleH=64 pages=1
+page id=0 file="test font.png"
+chars count=2
+char id=65 x=0 y=0 width=8 height=10 xoffset=1 yoffset=4 xadvance=9 page=0 chnl=15
+char id=86 x=8 y=0 width=8 height=10 xoffset=0 yoffset=4 xadvance=8 page=0 chnl=15
+kernings count=1
+kerning first=65 second=86 amount=-2
+`
+
+const bmFontXML = `<?xml version="1.0"?>
+<font>
+  <info face="Test" size="16"/>
+  <common lineHeight="18" base="14" scaleW="64" scaleH="64" pages="1"/>
+  <pages>
+    <page id="0" file="test font.png"/>
+  </pages>
+  <chars count="2">
+    <char id="65" x="0" y="0" width="8" height="10" xoffset="1" yoffset="4" xadvance="9" page="0" chnl="15"/>
+    <char id="86" x="8" y="0" width="8" height="10" xoffset="0" yoffset="4" xadvance="8" page="0" chnl="15"/>
+  </chars>
+  <kernings count="1">
+    <kerning first="65" second="86" amount="-2"/>
+  </kernings>
+</font>
+`
+
+func TestParseBMFont(t *testing.T) {
+	testcases := []struct {
+		name string
+		src  string
+	}{
+		{name: "text", src: bmFontText},
+		{name: "xml", src: bmFontXML},
+	}
+
+	for _, tc := range testcases {
+		data, err := parseBMFont(strings.NewReader(tc.src))
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, 18, data.Common.LineHeight, tc.name)
+		assert.Equal(t, []bmFontPage{{ID: 0, File: "test font.png"}}, data.Pages, tc.name)
+		assert.Equal(t, []bmFontChar{
+			{ID: 65, X: 0, Y: 0, Width: 8, Height: 10, XOffset: 1, YOffset: 4, XAdvance: 9},
+			{ID: 86, X: 8, Y: 0, Width: 8, Height: 10, XOffset: 0, YOffset: 4, XAdvance: 8},
+		}, data.Chars, tc.name)
+		assert.Equal(t, []bmFontKerning{{First: 65, Second: 86, Amount: -2}}, data.Kernings, tc.name)
+	}
+
+	_, err := parseBMFont(strings.NewReader("page id=0 file=\"broken\n"))
+	assert.ErrorIs(t, err, ErrInvalidBMFont)
+}
+
+func TestBitmapFontMetrics(t *testing.T) {
+	f := &bitmapFont{
+		glyphs: map[rune]bitmapGlyph{
+			'A': {advance: 9},
+			'V': {advance: 8},
+			'?': {advance: 5},
+		},
+		kerning:    map[[2]rune]int{{'A', 'V'}: -2},
+		lineHeight: 18,
+	}
+
+	assert.Equal(t, 18, f.LineHeight())
+	assert.Equal(t, 9, f.Advance('A'))
+	assert.Equal(t, 5, f.Advance('x'), "unknown runes fall back to '?'")
+	assert.Equal(t, -2, f.Kern('A', 'V'))
+	assert.Equal(t, 0, f.Kern('V', 'A'))
+	assert.Equal(t, 9+8-2, measureRunes(f, []rune("AV")))
+}
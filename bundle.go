@@ -0,0 +1,399 @@
+package vigor
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"strings"
+
+	"github.com/dbriemann/vigor/config"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+var (
+	ErrBundleLoaded    = fmt.Errorf("bundle is already loaded")
+	ErrBundleNotLoaded = fmt.Errorf("bundle is not loaded")
+	ErrBundleName      = fmt.Errorf("bundle name must not be empty")
+)
+
+const (
+	assetImage        = "image"
+	assetFrame        = "frame"
+	assetSound        = "sound"
+	assetMusic        = "music"
+	assetFont         = "font"
+	assetTilemap      = "tilemap"
+	assetSection      = "section"
+	assetAnimation    = "animation"
+	assetLocale       = "locale"
+	assetStateMachine = "stateMachine"
+)
+
+// baseBundle holds the assets of the config loaded with LoadConfig, which are never unloaded.
+const baseBundle = ""
+
+type assetKey struct {
+	kind string
+	name string
+}
+
+// cachedImage is an image that is shared by all bundles that load it.
+type cachedImage struct {
+	image *ebiten.Image
+	// key is the file path the image was loaded from. It is empty for images that are not shared, like atlas pages.
+	key  string
+	refs int
+}
+
+// imageCache counts the bundles that use an image, so it can be disposed when the last one is unloaded.
+type imageCache struct {
+	files map[string]*cachedImage
+	// acquired collects the images used by the bundle that is currently loaded.
+	acquired []*cachedImage
+}
+
+func newImageCache() *imageCache {
+	return &imageCache{
+		files:    map[string]*cachedImage{},
+		acquired: []*cachedImage{},
+	}
+}
+
+// load returns the image of a file, which is only decoded if no loaded bundle uses it yet.
+func (c *imageCache) load(key string, decode func() (image.Image, error)) (*ebiten.Image, error) {
+	ci, ok := c.files[key]
+	if !ok {
+		img, err := decode()
+		if err != nil {
+			return nil, err
+		}
+		ci = &cachedImage{image: ebiten.NewImageFromImage(img), key: key}
+		c.files[key] = ci
+	}
+	for _, a := range c.acquired {
+		if a == ci {
+			return ci.image, nil
+		}
+	}
+	ci.refs++
+	c.acquired = append(c.acquired, ci)
+	return ci.image, nil
+}
+
+// own adds an image that belongs only to the bundle that is currently loaded.
+func (c *imageCache) own(img *ebiten.Image) {
+	c.acquired = append(c.acquired, &cachedImage{image: img, refs: 1})
+}
+
+// take returns the images acquired since the last call.
+func (c *imageCache) take() []*cachedImage {
+	acquired := c.acquired
+	c.acquired = []*cachedImage{}
+	return acquired
+}
+
+// release disposes all images that are not used by any loaded bundle anymore.
+func (c *imageCache) release(images []*cachedImage) {
+	for _, ci := range images {
+		ci.refs--
+		if ci.refs > 0 {
+			continue
+		}
+		if ci.key != "" {
+			delete(c.files, ci.key)
+		}
+		ci.image.Dispose()
+	}
+}
+
+// bundle is a set of assets loaded from one config file.
+type bundle struct {
+	name   string
+	images []*cachedImage
+	assets map[assetKey]any
+}
+
+// LoadBundle loads the assets of a config file as a named bundle, which can be unloaded again with UnloadBundle.
+// Bundles cannot reference assets of other bundles. Assets that several bundles need are listed in each of them,
+// images loaded from the same file are shared and stay resident until the last bundle using them is unloaded.
+// If bundles define an asset with the same name, the one of the last loaded bundle is used.
+func (r *AssetManager) LoadBundle(name, fname string) error {
+	if name == baseBundle {
+		return ErrBundleName
+	}
+	if r.bundle(name) != nil || r.loader(name) != nil {
+		return fmt.Errorf("%w: %s", ErrBundleLoaded, name)
+	}
+	cfg, err := config.Load[ResourceConfig](fname)
+	if err != nil {
+		return err
+	}
+	if errs := config.Validate(cfg); len(errs) > 0 {
+		return errs
+	}
+	return r.loadBundle(name, cfg, nil)
+}
+
+// loadBundle creates the assets of a config. Files that are not decoded yet are decoded on the fly.
+func (r *AssetManager) loadBundle(name string, cfg ResourceConfig, decoded *decodedFiles) error {
+	staging := NewAssetManager()
+	staging.images = r.images
+	staging.decoded = decoded
+	r.images.take()
+	err := staging.loadConfig(cfg)
+	images := r.images.take()
+	if err != nil {
+		r.images.release(images)
+		return err
+	}
+
+	b := r.bundle(name)
+	if b == nil {
+		b = &bundle{name: name, images: []*cachedImage{}, assets: map[assetKey]any{}}
+		r.bundles = append(r.bundles, b)
+	}
+	b.images = append(b.images, images...)
+	for key, v := range staging.assets() {
+		b.assets[key] = v
+		r.setAsset(key, v)
+	}
+	if name == baseBundle {
+		r.RootPath = staging.RootPath
+	}
+	return nil
+}
+
+// UnloadBundle removes the assets of a bundle. Images are disposed if no other bundle uses them,
+// so stageables created from them must not be drawn anymore.
+func (r *AssetManager) UnloadBundle(name string) error {
+	b := r.bundle(name)
+	if name == baseBundle || b == nil {
+		return fmt.Errorf("%w: %s", ErrBundleNotLoaded, name)
+	}
+	for i := range r.bundles {
+		if r.bundles[i] == b {
+			r.bundles = append(r.bundles[:i], r.bundles[i+1:]...)
+			break
+		}
+	}
+
+	for key := range b.assets {
+		// Another bundle defining the asset takes over.
+		var v any
+		for i := len(r.bundles) - 1; i >= 0; i-- {
+			if other, ok := r.bundles[i].assets[key]; ok {
+				v = other
+				break
+			}
+		}
+		r.setAsset(key, v)
+	}
+	r.images.release(b.images)
+	return nil
+}
+
+// Bundles returns the names of all loaded bundles in load order.
+func (r *AssetManager) Bundles() []string {
+	names := []string{}
+	for _, b := range r.bundles {
+		if b.name != baseBundle {
+			names = append(names, b.name)
+		}
+	}
+	return names
+}
+
+func (r *AssetManager) bundle(name string) *bundle {
+	for _, b := range r.bundles {
+		if b.name == name {
+			return b
+		}
+	}
+	return nil
+}
+
+// assets returns all assets by kind and name.
+func (r *AssetManager) assets() map[assetKey]any {
+	all := map[assetKey]any{}
+	for name, v := range r.Images {
+		all[assetKey{assetImage, name}] = v
+	}
+	for name, v := range r.imageFrames {
+		all[assetKey{assetFrame, name}] = v
+	}
+	for name, v := range r.Sounds {
+		all[assetKey{assetSound, name}] = v
+	}
+	for name, v := range r.Music {
+		all[assetKey{assetMusic, name}] = v
+	}
+	for name, v := range r.Fonts {
+		all[assetKey{assetFont, name}] = v
+	}
+	for name, v := range r.Tilemaps {
+		all[assetKey{assetTilemap, name}] = v
+	}
+	for name, v := range r.Sections {
+		all[assetKey{assetSection, name}] = v
+	}
+	for name, v := range r.AnimationTemplates {
+		all[assetKey{assetAnimation, name}] = v
+	}
+	for key, v := range r.custom {
+		all[key] = v
+	}
+	return all
+}
+
+// setAsset sets or, if v is nil, removes an asset.
+func (r *AssetManager) setAsset(key assetKey, v any) {
+	switch key.kind {
+	case assetImage:
+		setOrDelete(r.Images, key.name, v)
+	case assetFrame:
+		setOrDelete(r.imageFrames, key.name, v)
+	case assetSound:
+		setOrDelete(r.Sounds, key.name, v)
+	case assetMusic:
+		setOrDelete(r.Music, key.name, v)
+	case assetFont:
+		setOrDelete(r.Fonts, key.name, v)
+	case assetTilemap:
+		setOrDelete(r.Tilemaps, key.name, v)
+	case assetSection:
+		setOrDelete(r.Sections, key.name, v)
+	case assetAnimation:
+		setOrDelete(r.AnimationTemplates, key.name, v)
+	default:
+		if v == nil {
+			delete(r.custom, key)
+		} else {
+			r.custom[key] = v
+		}
+	}
+}
+
+// asset returns an asset or nil if it is not loaded.
+func (r *AssetManager) asset(key assetKey) any {
+	var v any
+	var ok bool
+	switch key.kind {
+	case assetImage:
+		v, ok = r.Images[key.name]
+	case assetFrame:
+		v, ok = r.imageFrames[key.name]
+	case assetSound:
+		v, ok = r.Sounds[key.name]
+	case assetMusic:
+		v, ok = r.Music[key.name]
+	case assetFont:
+		v, ok = r.Fonts[key.name]
+	case assetTilemap:
+		v, ok = r.Tilemaps[key.name]
+	case assetSection:
+		v, ok = r.Sections[key.name]
+	case assetAnimation:
+		v, ok = r.AnimationTemplates[key.name]
+	default:
+		v, ok = r.custom[key]
+	}
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+func setOrDelete[T any](m map[string]T, name string, v any) {
+	if v == nil {
+		delete(m, name)
+		return
+	}
+	m[name] = v.(T)
+}
+
+type ResidentImage struct {
+	// Path is the file the image was loaded from. It is empty for atlas pages.
+	Path   string
+	Width  int
+	Height int
+	// Refs is the number of loaded bundles using the image.
+	Refs int
+}
+
+// ResidentReport lists what the asset manager currently holds in memory.
+type ResidentReport struct {
+	Bundles []string
+	Images  []ResidentImage
+	// Assets is the number of assets by kind.
+	Assets map[string]int
+}
+
+// Resident reports the loaded bundles, the images in memory and the number of assets.
+func (r *AssetManager) Resident() ResidentReport {
+	report := ResidentReport{
+		Bundles: r.Bundles(),
+		Images:  []ResidentImage{},
+		Assets:  map[string]int{},
+	}
+
+	seen := map[*cachedImage]bool{}
+	for _, b := range r.bundles {
+		for _, ci := range b.images {
+			if seen[ci] {
+				continue
+			}
+			seen[ci] = true
+			size := ci.image.Bounds().Size()
+			report.Images = append(report.Images, ResidentImage{Path: ci.key, Width: size.X, Height: size.Y, Refs: ci.refs})
+		}
+	}
+	sort.SliceStable(report.Images, func(i, j int) bool {
+		return report.Images[i].Path < report.Images[j].Path
+	})
+
+	for key := range r.assets() {
+		report.Assets[key.kind]++
+	}
+	return report
+}
+
+// Bytes returns the estimated memory of all images, assuming 4 bytes per pixel.
+func (rep ResidentReport) Bytes() int {
+	n := 0
+	for _, img := range rep.Images {
+		n += 4 * img.Width * img.Height
+	}
+	return n
+}
+
+func (rep ResidentReport) String() string {
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "assets: %d images, %d KiB\n", len(rep.Images), rep.Bytes()/1024)
+	if len(rep.Bundles) > 0 {
+		fmt.Fprintf(&sb, "  bundles %s\n", strings.Join(rep.Bundles, ", "))
+	}
+	for _, img := range rep.Images {
+		p := img.Path
+		if p == "" {
+			p = "<atlas page>"
+		}
+		fmt.Fprintf(&sb, "  %-32s %4dx%-4d refs %d\n", p, img.Width, img.Height, img.Refs)
+	}
+
+	kinds := make([]string, 0, len(rep.Assets))
+	for kind := range rep.Assets {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	counts := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		counts = append(counts, fmt.Sprintf("%s %d", kind, rep.Assets[kind]))
+	}
+	sb.WriteString("  " + strings.Join(counts, ", "))
+	return sb.String()
+}
+
+func (r *AssetManager) debugInfo() string {
+	rep := r.Resident()
+	return fmt.Sprintf("assets: %d images, %d KiB, bundles %v", len(rep.Images), rep.Bytes()/1024, rep.Bundles)
+}
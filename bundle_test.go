@@ -0,0 +1,81 @@
+package vigor
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func writeBundleTestFiles(t *testing.T) string {
+	dir := t.TempDir()
+	for name, size := range map[string]int{"a.png": 4, "b.png": 8} {
+		f, err := os.Create(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		assert.NoError(t, png.Encode(f, image.NewNRGBA(image.Rect(0, 0, size, size))))
+		assert.NoError(t, f.Close())
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "font.ttf"), goregular.TTF, 0o644))
+
+	configs := map[string]string{
+		"base.json":   `{"resourceRoot": "%s", "images": {"a.png": "ui"}}`,
+		"level1.json": `{"resourceRoot": "%s", "images": {"a.png": "shared", "b.png": "hero"}, "sections": {"s": {"left": 1}}}`,
+		"level2.json": `{"resourceRoot": "%s", "images": {"b.png": "hero"}, "sections": {"s": {"left": 2}}}`,
+		"broken.json": `{"resourceRoot": "%s", "images": {"a.png": "shared", "missing.png": "missing"}}`,
+		"fonts.json":  `{"resourceRoot": "%s", "fonts": {"small": {"path": "font.ttf", "size": 8}, "large": {"path": "font.ttf", "size": 16}}}`,
+	}
+	for name, cfg := range configs {
+		raw := []byte(fmt.Sprintf(cfg, filepath.ToSlash(dir)))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), raw, 0o644))
+	}
+	return dir
+}
+
+func TestAssetBundles(t *testing.T) {
+	dir := writeBundleTestFiles(t)
+	r := NewAssetManager()
+	imgA := filepath.ToSlash(filepath.Join(dir, "a.png"))
+	imgB := filepath.ToSlash(filepath.Join(dir, "b.png"))
+
+	assert.NoError(t, r.LoadConfig(filepath.Join(dir, "base.json")))
+	assert.NoError(t, r.LoadBundle("level1", filepath.Join(dir, "level1.json")))
+	assert.NoError(t, r.LoadBundle("level2", filepath.Join(dir, "level2.json")))
+	assert.ErrorIs(t, r.LoadBundle("level1", filepath.Join(dir, "level1.json")), ErrBundleLoaded)
+	assert.ErrorIs(t, r.LoadBundle("", filepath.Join(dir, "level1.json")), ErrBundleName)
+	assert.Error(t, r.LoadBundle("broken", filepath.Join(dir, "broken.json")))
+
+	// Images loaded from the same file are shared.
+	assert.Same(t, r.Images["ui"], r.Images["shared"])
+	assert.Equal(t, []string{"level1", "level2"}, r.Bundles())
+	assert.Equal(t, 2, r.images.files[imgA].refs)
+	assert.Equal(t, 2, r.images.files[imgB].refs)
+	assert.Equal(t, 2, r.Sections["s"].left)
+
+	report := r.Resident()
+	assert.Equal(t, []ResidentImage{{Path: imgA, Width: 4, Height: 4, Refs: 2}, {Path: imgB, Width: 8, Height: 8, Refs: 2}}, report.Images)
+	assert.Equal(t, map[string]int{assetImage: 3, assetSection: 1}, report.Assets)
+	assert.Equal(t, 4*(16+64), report.Bytes())
+
+	// The assets of level1 take over again.
+	hero := r.Images["hero"]
+	assert.NoError(t, r.UnloadBundle("level2"))
+	assert.Equal(t, 1, r.Sections["s"].left)
+	assert.Same(t, hero, r.Images["hero"])
+	assert.Equal(t, 1, r.images.files[imgB].refs)
+
+	assert.NoError(t, r.UnloadBundle("level1"))
+	assert.NotContains(t, r.Images, "hero")
+	assert.NotContains(t, r.Images, "shared")
+	assert.NotContains(t, r.Sections, "s")
+	assert.NotContains(t, r.images.files, imgB)
+	assert.Equal(t, 1, r.images.files[imgA].refs)
+	assert.Contains(t, r.Images, "ui")
+
+	assert.ErrorIs(t, r.UnloadBundle("level1"), ErrBundleNotLoaded)
+	assert.ErrorIs(t, r.UnloadBundle(""), ErrBundleNotLoaded)
+}
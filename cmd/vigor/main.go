@@ -0,0 +1,79 @@
+// Command vigor contains tools for games made with vigor.
+//
+// Usage:
+//
+//	vigor lint [config.json]
+//	vigor locales [config.json] [source dirs...]
+//
+// lint validates a resource config (JSON, YAML or TOML, with its includes) and prints all problems with the
+// JSON path of the offending field.
+// locales reports keys that are missing in the string tables of a locale and its fallbacks. If source directories
+// are given, keys that never appear as string literal in their Go files are reported as unused.
+// Both exit with status 1 if problems are found, so they can be run in CI.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/dbriemann/vigor/config"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: vigor lint [config.json]")
+	fmt.Fprintln(os.Stderr, "       vigor locales [config.json] [source dirs...]")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	fname := "config.json"
+	if len(os.Args) >= 3 {
+		fname = os.Args[2]
+	}
+
+	switch os.Args[1] {
+	case "lint":
+		if len(os.Args) > 3 {
+			usage()
+		}
+		lint(fname)
+	case "locales":
+		locales(fname, os.Args[min(3, len(os.Args)):])
+	default:
+		usage()
+	}
+}
+
+func lint(fname string) {
+	err := config.ValidateFile(fname)
+	if err == nil {
+		return
+	}
+	var errs config.Errors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", fname, e)
+		}
+		fmt.Fprintf(os.Stderr, "%d problems found\n", len(errs))
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", fname, err)
+	}
+	os.Exit(1)
+}
+
+func locales(fname string, srcDirs []string) {
+	report, err := config.CheckLocales(fname, srcDirs...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", fname, err)
+		os.Exit(1)
+	}
+	if report.Empty() {
+		return
+	}
+	fmt.Fprintln(os.Stderr, report)
+	os.Exit(1)
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"image"
+)
+
+var (
+	ErrColumnMismatch     = fmt.Errorf("columns do not match with frame width, margin and spacing")
+	ErrRowMismatch        = fmt.Errorf("rows do not match with frame height, margin and spacing")
+	ErrSectionOutOfBounds = fmt.Errorf("section exceeds the sheet")
+	ErrFrameCountZero     = fmt.Errorf("the calculated frame count is zero")
+	ErrFrameExceedsBounds = fmt.Errorf("frame index exceeds section bounds")
+	ErrUnknownEaseFunc    = fmt.Errorf("ease function name unknown")
+	ErrFileNotFound       = fmt.Errorf("file not found")
+	ErrImageNotLoaded     = fmt.Errorf("image not loaded")
+	ErrFrameDurationCount = fmt.Errorf("frame durations do not match frames")
+	ErrUnknownPlayMode    = fmt.Errorf("play mode unknown")
+	ErrFrameBoxCount      = fmt.Errorf("boxes do not match frames")
+	ErrFrameBoxConflict   = fmt.Errorf("steps showing the same frame have different boxes")
+)
+
+// CheckPlayMode returns an error if name is not a play mode, "forward", "reverse" or "pingpong".
+// An empty name is forward.
+func CheckPlayMode(name string) error {
+	if name != "" && !playModes[name] {
+		return fmt.Errorf("%w: %s", ErrUnknownPlayMode, name)
+	}
+	return nil
+}
+
+// CheckEaseFunc returns an error if name is not an ease function, e.g. "InOutQuad". An empty name is linear.
+func CheckEaseFunc(name string) error {
+	if name != "" && !easeFuncs[name] {
+		return fmt.Errorf("%w: %s", ErrUnknownEaseFunc, name)
+	}
+	return nil
+}
+
+// gridSize returns the size of n cells with margin and spacing.
+func gridSize(n, cell, margin, spacing int) int {
+	return 2*margin + n*cell + (n-1)*spacing
+}
+
+// gridCells returns how many cells fit into size. An error describes the sizes that would fit.
+func gridCells(size, cell, margin, spacing int, dim string) (int, error) {
+	n := (size - 2*margin + spacing) / (cell + spacing)
+	if n >= 1 && gridSize(n, cell, margin, spacing) == size {
+		return n, nil
+	}
+
+	n = max(n, 0)
+	expected := fmt.Sprint(gridSize(n+1, cell, margin, spacing))
+	if n > 0 {
+		expected = fmt.Sprintf("%d or %s", gridSize(n, cell, margin, spacing), expected)
+	}
+	return 0, fmt.Errorf("section %s %d fits %d cells of %d with margin %d and spacing %d, expected %s %s",
+		dim, size, n, cell, margin, spacing, dim, expected)
+}
+
+// GridCells calculates the cells of a grid in an area of a sheet with the given bounds, row by row.
+// The area is relative to the sheet, a width or height of zero extends it to the right or bottom edge.
+// The cells are absolute, so sheets that are sub-images (e.g. of an atlas) are supported.
+func GridCells(bounds, area image.Rectangle, w, h, margin, spacing int) ([]image.Rectangle, error) {
+	if w <= 0 || h <= 0 {
+		return nil, fmt.Errorf("%w: frame size %dx%d", ErrFrameCountZero, w, h)
+	}
+
+	extendX, extendY := area.Dx() == 0, area.Dy() == 0
+	area = area.Add(bounds.Min)
+	if extendX {
+		area.Max.X = bounds.Max.X
+	}
+	if extendY {
+		area.Max.Y = bounds.Max.Y
+	}
+	if area.Empty() || !area.In(bounds) {
+		return nil, fmt.Errorf("%w: section %v, sheet %v", ErrSectionOutOfBounds, area.Sub(bounds.Min), bounds.Sub(bounds.Min))
+	}
+
+	columns, err := gridCells(area.Dx(), w, margin, spacing, "width")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrColumnMismatch, err)
+	}
+	rows, err := gridCells(area.Dy(), h, margin, spacing, "height")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRowMismatch, err)
+	}
+
+	cells := []image.Rectangle{}
+	for y := 0; y < rows; y++ {
+		for x := 0; x < columns; x++ {
+			upperLeft := image.Point{
+				X: area.Min.X + margin + x*(w+spacing),
+				Y: area.Min.Y + margin + y*(h+spacing),
+			}
+			cells = append(cells, image.Rect(
+				upperLeft.X,
+				upperLeft.Y,
+				upperLeft.X+w,
+				upperLeft.Y+h,
+			))
+		}
+	}
+
+	return cells, nil
+}
+
+// CheckSteps checks values given per step of frames: there must be one per step, every frame must be one of the
+// images and steps showing the same image must have the same value.
+func CheckSteps[T comparable](frames []int, images int, values []T) error {
+	if len(values) != len(frames) {
+		return fmt.Errorf("%w: %d values, %d frames", ErrFrameBoxCount, len(values), len(frames))
+	}
+	seen := map[int]T{}
+	for step, f := range frames {
+		if f < 0 || f >= images {
+			return fmt.Errorf("%w: frame %d of %d", ErrFrameExceedsBounds, f, images)
+		}
+		if v, ok := seen[f]; ok && v != values[step] {
+			return fmt.Errorf("%w: frame %d at step %d", ErrFrameBoxConflict, f, step)
+		}
+		seen[f] = values[step]
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSectionConfigGrid(t *testing.T) {
+	margin := 3
+	area, m, sp := SectionConfig{Left: 1, Top: 2, Width: 20, Padding: 2, Margin: &margin}.Grid()
+	assert.Equal(t, image.Rect(1, 2, 21, 2), area)
+	assert.Equal(t, 3, m)
+	assert.Equal(t, 2, sp)
+
+	// A zero width extends the grid to the edge of the sheet.
+	area, m, sp = SectionConfig{Left: 10, Padding: 1}.Grid()
+	cells, err := GridCells(image.Rect(0, 0, 33, 12), area, 10, 10, m, sp)
+	assert.NoError(t, err)
+	assert.Equal(t, []image.Rectangle{image.Rect(11, 1, 21, 11), image.Rect(22, 1, 32, 11)}, cells)
+}
+
+func TestCheckSteps(t *testing.T) {
+	assert.NoError(t, CheckSteps([]int{2, 0, 2}, 3, []image.Rectangle{image.Rect(1, 1, 2, 2), {}, image.Rect(1, 1, 2, 2)}))
+	assert.ErrorIs(t, CheckSteps([]int{2, 0, 2}, 3, []image.Point{{1, 1}, {}, {2, 2}}), ErrFrameBoxConflict)
+	assert.ErrorIs(t, CheckSteps([]int{0, 1}, 2, []image.Point{{}}), ErrFrameBoxCount)
+	assert.ErrorIs(t, CheckSteps([]int{0, 3}, 2, []image.Point{{}, {}}), ErrFrameExceedsBounds)
+}
@@ -0,0 +1,157 @@
+// Package config loads and validates the resource configs of vigor and the files they reference.
+// It does not depend on Ebitengine, so tools using it run on machines without a display.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrIncludeCycle   = fmt.Errorf("config includes itself")
+	ErrInvalidInclude = fmt.Errorf("include must be a list of file paths")
+)
+
+// Load loads a config file in JSON, YAML or TOML format, including the files it lists under "include".
+// All formats use the JSON field names of the config structs.
+func Load[T any](fpath string) (T, error) {
+	var t T
+
+	tree, err := loadTree(fpath, nil)
+	if err != nil {
+		return t, err
+	}
+	raw, err := json.Marshal(tree)
+	if err != nil {
+		return t, err
+	}
+	if err := json.Unmarshal(raw, &t); err != nil {
+		return t, fmt.Errorf("%s: %w", fpath, err)
+	}
+
+	return t, nil
+}
+
+// includeKey is the top level key of a config that lists the config files it includes.
+const includeKey = "include"
+
+// decodeTree decodes a config file into generic values. The format is detected by the extension:
+// .yaml and .yml files are YAML, .toml files are TOML and all others are JSON.
+func decodeTree(fpath string, raw []byte) (map[string]any, error) {
+	tree := map[string]any{}
+	var err error
+	switch strings.ToLower(path.Ext(fpath)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &tree)
+	case ".toml":
+		err = toml.Unmarshal(raw, &tree)
+	default:
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		err = dec.Decode(&tree)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fpath, err)
+	}
+	return normalizeValue(tree).(map[string]any), nil
+}
+
+// normalizeValue converts maps with non-string keys, which YAML allows, to maps with string keys.
+func normalizeValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = normalizeValue(e)
+		}
+		return v
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = normalizeValue(e)
+		}
+		return m
+	case []any:
+		for i, e := range v {
+			v[i] = normalizeValue(e)
+		}
+		return v
+	case []map[string]any:
+		// TOML arrays of tables.
+		l := make([]any, len(v))
+		for i, e := range v {
+			l[i] = normalizeValue(e)
+		}
+		return l
+	}
+	return v
+}
+
+// loadTree reads a config file and merges the files it includes. Include paths are relative to the
+// including file. Included files are merged in order, so later ones override earlier ones, and the including
+// file overrides all of them. loading holds the files that are currently being loaded to detect cycles.
+func loadTree(fpath string, loading []string) (map[string]any, error) {
+	abs, err := filepath.Abs(fpath)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range loading {
+		if l == abs {
+			return nil, fmt.Errorf("%w: %s", ErrIncludeCycle, fpath)
+		}
+	}
+
+	raw, err := os.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := decodeTree(fpath, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	includes, ok := tree[includeKey]
+	if !ok {
+		return tree, nil
+	}
+	delete(tree, includeKey)
+	list, ok := includes.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidInclude, fpath)
+	}
+
+	merged := map[string]any{}
+	for _, inc := range list {
+		incPath, ok := inc.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidInclude, fpath)
+		}
+		incTree, err := loadTree(path.Join(path.Dir(filepath.ToSlash(fpath)), incPath), append(loading, abs))
+		if err != nil {
+			return nil, err
+		}
+		mergeTrees(merged, incTree)
+	}
+	mergeTrees(merged, tree)
+	return merged, nil
+}
+
+// mergeTrees merges src into dst. Maps are merged recursively, all other values of src replace those of dst.
+func mergeTrees(dst, src map[string]any) {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]any)
+		dstMap, dstIsMap := dst[k].(map[string]any)
+		if srcIsMap && dstIsMap {
+			mergeTrees(dstMap, srcMap)
+			continue
+		}
+		dst[k] = v
+	}
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		fpath := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(fpath), 0o755))
+		assert.NoError(t, os.WriteFile(fpath, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestConfigFormats(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.json": `{"images": {"hero.png": "hero"}, "animations": {"walk": {"imageName": "hero", "width": 16, "height": 16, "frames": [0, 1, 2], "duration": 0.5}}}`,
+		"config.yaml": `
+# Comments are allowed.
+images:
+  hero.png: hero
+animations:
+  walk:
+    imageName: hero
+    width: 16
+    height: 16
+    frames: [0, 1, 2]
+    duration: 0.5
+`,
+		"config.toml": `
+[images]
+"hero.png" = "hero"
+
+[animations.walk]
+imageName = "hero"
+width = 16
+height = 16
+frames = [0, 1, 2]
+duration = 0.5
+`,
+	})
+
+	expected, err := Load[ResourceConfig](filepath.Join(dir, "config.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2}, expected.Animations["walk"].Frames)
+	for _, name := range []string{"config.yaml", "config.toml"} {
+		cfg, err := Load[ResourceConfig](filepath.Join(dir, name))
+		assert.NoError(t, err, name)
+		assert.Equal(t, expected, cfg, name)
+	}
+}
+
+func TestConfigIncludes(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml": `
+include: [chars/knight.toml, chars/dove.json]
+resourceRoot: assets
+images:
+  ui.png: ui
+animations:
+  fly:
+    duration: 2
+`,
+		"chars/knight.toml": `
+[images]
+"knight.png" = "knight"
+
+[animations.slash]
+imageName = "knight"
+frames = [0, 1]
+`,
+		"chars/dove.json": `{"images": {"dove.png": "dove"}, "animations": {"fly": {"imageName": "dove", "duration": 1}}}`,
+		"cycle.json":      `{"include": ["cycle2.yml"]}`,
+		"cycle2.yml":      `include: [cycle.json]`,
+		"invalid.json":    `{"include": "chars/dove.json"}`,
+		"missing.json":    `{"include": ["chars/missing.json"]}`,
+	})
+
+	cfg, err := Load[ResourceConfig](filepath.Join(dir, "config.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "assets", cfg.ResourceRoot)
+	assert.Equal(t, map[string]string{"ui.png": "ui", "knight.png": "knight", "dove.png": "dove"}, cfg.Images)
+	assert.Equal(t, []int{0, 1}, cfg.Animations["slash"].Frames)
+	// The including file overrides single fields of included ones.
+	assert.Equal(t, "dove", cfg.Animations["fly"].ImageName)
+	assert.Equal(t, 2.0, cfg.Animations["fly"].Duration)
+
+	_, err = Load[ResourceConfig](filepath.Join(dir, "cycle.json"))
+	assert.ErrorIs(t, err, ErrIncludeCycle)
+	_, err = Load[ResourceConfig](filepath.Join(dir, "invalid.json"))
+	assert.ErrorIs(t, err, ErrInvalidInclude)
+	_, err = Load[ResourceConfig](filepath.Join(dir, "missing.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestValidateYAMLConfig(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yml": `
+sections:
+  row:
+    width: -1
+`,
+	})
+
+	err := ValidateFile(filepath.Join(dir, "config.yml"))
+	assert.ErrorIs(t, err, ErrNegative)
+	assert.ErrorContains(t, err, "sections.row.width")
+}
@@ -0,0 +1,357 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrLocaleFormat = fmt.Errorf("string tables must be JSON or PO files")
+	ErrInvalidPO    = fmt.Errorf("invalid PO file")
+)
+
+// DefaultLocale is the locale whose plural rules are used for unknown locales.
+const DefaultLocale = "en"
+
+// BaseLanguage returns the language of a locale, e.g. "de" for "de-AT".
+func BaseLanguage(locale string) string {
+	lang, _, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	return strings.ToLower(lang)
+}
+
+// StringTableConfig holds the entries of a string table file. Plurals map keys to their forms by CLDR plural
+// category, e.g. "one" or "other".
+type StringTableConfig struct {
+	Strings map[string]string
+	Plurals map[string]map[string]string
+}
+
+// Keys returns all keys of the table in sorted order.
+func (stc StringTableConfig) Keys() []string {
+	keys := make([]string, 0, len(stc.Strings)+len(stc.Plurals))
+	for k := range stc.Strings {
+		keys = append(keys, k)
+	}
+	for k := range stc.Plurals {
+		if _, ok := stc.Strings[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (stc StringTableConfig) has(key string) bool {
+	_, ok := stc.Strings[key]
+	_, plural := stc.Plurals[key]
+	return ok || plural
+}
+
+// LoadStringTable reads a string table from a JSON or gettext PO file.
+// JSON tables map keys to strings or, for plurals, to objects mapping plural categories to strings.
+// The forms of PO plural entries are assigned to the plural categories of the locale in CLDR order.
+func LoadStringTable(fsys fs.FS, fpath, locale string) (StringTableConfig, error) {
+	stc := StringTableConfig{Strings: map[string]string{}, Plurals: map[string]map[string]string{}}
+	raw, err := fs.ReadFile(fsys, fpath)
+	if err != nil {
+		return stc, err
+	}
+	switch strings.ToLower(path.Ext(fpath)) {
+	case ".json":
+		err = stc.parseJSON(raw)
+	case ".po":
+		err = stc.parsePO(raw, locale)
+	default:
+		err = fmt.Errorf("%w: %s", ErrLocaleFormat, fpath)
+	}
+	if err != nil {
+		return stc, fmt.Errorf("%s: %w", fpath, err)
+	}
+	return stc, nil
+}
+
+func (stc StringTableConfig) parseJSON(raw []byte) error {
+	entries := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return err
+	}
+	for key, entry := range entries {
+		s := ""
+		if err := json.Unmarshal(entry, &s); err == nil {
+			stc.Strings[key] = s
+			continue
+		}
+		forms := map[string]string{}
+		if err := json.Unmarshal(entry, &forms); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		stc.Plurals[key] = forms
+	}
+	return nil
+}
+
+type poEntry struct {
+	id     string
+	plural string
+	strs   map[int]*string
+	fuzzy  bool
+}
+
+func (stc StringTableConfig) parsePO(raw []byte, locale string) error {
+	entry := &poEntry{strs: map[int]*string{}}
+	// field is the string that continuation lines are appended to.
+	var field *string
+	finish := func() {
+		// The entry with the empty id is the header.
+		if entry.id != "" && !entry.fuzzy {
+			stc.addPOEntry(entry, locale)
+		}
+		entry = &poEntry{strs: map[int]*string{}}
+		field = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for lineNr := 1; scanner.Scan(); lineNr++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			finish()
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			if strings.HasPrefix(line, "#,") && strings.Contains(line, "fuzzy") {
+				entry.fuzzy = true
+			}
+			continue
+		}
+
+		keyword, value := "", line
+		if !strings.HasPrefix(line, `"`) {
+			keyword, value, _ = strings.Cut(line, " ")
+		}
+		s, err := strconv.Unquote(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%w: line %d: %v", ErrInvalidPO, lineNr, err)
+		}
+		// Entries may follow each other without a blank line.
+		if (keyword == "msgctxt" || keyword == "msgid") && len(entry.strs) > 0 {
+			finish()
+		}
+
+		switch {
+		case keyword == "":
+			if field == nil {
+				return fmt.Errorf("%w: line %d: string without keyword", ErrInvalidPO, lineNr)
+			}
+			*field += s
+		case keyword == "msgctxt":
+			// Contexts are not supported, the id alone is the key.
+			field = new(string)
+		case keyword == "msgid":
+			entry.id = s
+			field = &entry.id
+		case keyword == "msgid_plural":
+			entry.plural = s
+			field = &entry.plural
+		case keyword == "msgstr":
+			entry.strs[0] = &s
+			field = &s
+		case strings.HasPrefix(keyword, "msgstr[") && strings.HasSuffix(keyword, "]"):
+			i, err := strconv.Atoi(keyword[len("msgstr[") : len(keyword)-1])
+			if err != nil || i < 0 {
+				return fmt.Errorf("%w: line %d: invalid plural index %s", ErrInvalidPO, lineNr, keyword)
+			}
+			entry.strs[i] = &s
+			field = &s
+		default:
+			return fmt.Errorf("%w: line %d: unknown keyword %s", ErrInvalidPO, lineNr, keyword)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	finish()
+	return nil
+}
+
+// addPOEntry adds the translation of a PO entry. Untranslated entries are left out, so the fallback is used.
+func (stc StringTableConfig) addPOEntry(e *poEntry, locale string) {
+	if e.plural == "" {
+		if s := e.strs[0]; s != nil && *s != "" {
+			stc.Strings[e.id] = *s
+		}
+		return
+	}
+
+	forms := poPluralForms[BaseLanguage(locale)]
+	if forms == nil {
+		forms = poPluralForms[DefaultLocale]
+	}
+	plurals := map[string]string{}
+	for i, s := range e.strs {
+		if i < len(forms) && *s != "" {
+			plurals[forms[i]] = *s
+		}
+	}
+	if len(plurals) > 0 {
+		stc.Plurals[e.id] = plurals
+	}
+}
+
+// LocaleChain returns the locales that are searched for a key in order: the locale, the fallbacks of its tables,
+// its base language and the same for every fallback. fallbacks returns the fallbacks of the tables of a locale.
+func LocaleChain(locale string, fallbacks func(locale string) []string) []string {
+	chain := []string{}
+	seen := map[string]bool{}
+	var visit func(locale string)
+	visit = func(locale string) {
+		if locale == "" || seen[locale] {
+			return
+		}
+		seen[locale] = true
+		chain = append(chain, locale)
+		for _, fallback := range fallbacks(locale) {
+			visit(fallback)
+		}
+		visit(BaseLanguage(locale))
+	}
+	visit(locale)
+	return chain
+}
+
+// LocaleReport lists the problems of the string tables of a config.
+type LocaleReport struct {
+	// Missing holds the keys by locale that other locales define, but neither the locale nor its fallbacks.
+	Missing map[string][]string
+	// Unused holds the keys that are not found as string literal in the Go sources.
+	Unused []string
+}
+
+// Empty reports whether no problems were found.
+func (rep LocaleReport) Empty() bool {
+	return len(rep.Missing) == 0 && len(rep.Unused) == 0
+}
+
+// CheckLocales reports missing keys of the string tables in a config. If source directories are given,
+// the Go files in them are searched for string literals and keys that are never used are reported too.
+func CheckLocales(fname string, srcDirs ...string) (LocaleReport, error) {
+	report := LocaleReport{Missing: map[string][]string{}, Unused: []string{}}
+	cfg, err := Load[ResourceConfig](fname)
+	if err != nil {
+		return report, err
+	}
+	root := cfg.ResourceRoot
+	if root == "" {
+		root = "."
+	}
+
+	byLocale := map[string][]StringTableConfig{}
+	fallbacks := map[string][]string{}
+	for _, name := range sortedKeys(cfg.Locales) {
+		lc := cfg.Locales[name]
+		locale := lc.Locale
+		if locale == "" {
+			locale = name
+		}
+		stc, err := LoadStringTable(os.DirFS(root), path.Clean(lc.Path), locale)
+		if err != nil {
+			return report, err
+		}
+		byLocale[locale] = append(byLocale[locale], stc)
+		fallbacks[locale] = append(fallbacks[locale], lc.Fallback)
+	}
+	localeFallbacks := func(locale string) []string {
+		return fallbacks[locale]
+	}
+
+	keys := map[string]bool{}
+	for _, sts := range byLocale {
+		for _, st := range sts {
+			for _, k := range st.Keys() {
+				keys[k] = true
+			}
+		}
+	}
+	for _, locale := range sortedKeys(byLocale) {
+		chain := LocaleChain(locale, localeFallbacks)
+		for _, key := range sortedKeys(keys) {
+			found := false
+			for _, loc := range chain {
+				for _, st := range byLocale[loc] {
+					found = found || st.has(key)
+				}
+			}
+			if !found {
+				report.Missing[locale] = append(report.Missing[locale], key)
+			}
+		}
+	}
+
+	if len(srcDirs) == 0 {
+		return report, nil
+	}
+	literals, err := stringLiterals(srcDirs)
+	if err != nil {
+		return report, err
+	}
+	for _, key := range sortedKeys(keys) {
+		if !literals[key] {
+			report.Unused = append(report.Unused, key)
+		}
+	}
+	return report, nil
+}
+
+// stringLiterals returns all string literals in the Go files of the directories and their subdirectories.
+func stringLiterals(dirs []string) (map[string]bool, error) {
+	literals := map[string]bool{}
+	fset := token.NewFileSet()
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(fpath string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || filepath.Ext(fpath) != ".go" {
+				return err
+			}
+			f, err := parser.ParseFile(fset, fpath, nil, 0)
+			if err != nil {
+				return err
+			}
+			ast.Inspect(f, func(n ast.Node) bool {
+				if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+					if s, err := strconv.Unquote(lit.Value); err == nil {
+						literals[s] = true
+					}
+				}
+				return true
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return literals, nil
+}
+
+func (rep LocaleReport) String() string {
+	lines := []string{}
+	for _, locale := range sortedKeys(rep.Missing) {
+		for _, key := range rep.Missing[locale] {
+			lines = append(lines, fmt.Sprintf("%s: missing %s", locale, key))
+		}
+	}
+	for _, key := range rep.Unused {
+		lines = append(lines, fmt.Sprintf("unused %s", key))
+	}
+	return strings.Join(lines, "\n")
+}
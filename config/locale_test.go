@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePO(t *testing.T) {
+	po := `# German translation
+msgid ""
+msgstr ""
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+msgid "greeting"
+msgstr "Hallo "
+"Welt"
+
+msgid "coins"
+msgid_plural "coins"
+msgstr[0] "{count} Münze"
+msgstr[1] "{count} Münzen"
+
+#, fuzzy
+msgid "unsure"
+msgstr "Unsicher"
+
+msgid "untranslated"
+msgstr ""
+msgctxt "menu"
+msgid "quit"
+msgstr "Beenden"
+`
+	stc := StringTableConfig{Strings: map[string]string{}, Plurals: map[string]map[string]string{}}
+	assert.NoError(t, stc.parsePO([]byte(po), "de"))
+	assert.Equal(t, map[string]string{"greeting": "Hallo Welt", "quit": "Beenden"}, stc.Strings)
+	assert.Equal(t, map[string]map[string]string{"coins": {"one": "{count} Münze", "other": "{count} Münzen"}}, stc.Plurals)
+	assert.Equal(t, []string{"coins", "greeting", "quit"}, stc.Keys())
+
+	assert.ErrorIs(t, stc.parsePO([]byte(`msgid "a"`+"\n"+`msgfoo "b"`), "de"), ErrInvalidPO)
+	assert.ErrorIs(t, stc.parsePO([]byte(`"dangling"`), "de"), ErrInvalidPO)
+}
+
+func writeLocaleTestFiles(t *testing.T) string {
+	return writeConfigFiles(t, map[string]string{
+		"locales/en.json": `{"title": "Vigor", "greeting": "Hello {name}", "coins": {"one": "{count} coin", "other": "{count} coins"}, "credits": "Credits"}`,
+		"locales/de.po": `msgid "greeting"
+msgstr "Hallo {name}"
+
+msgid "coins"
+msgid_plural "coins"
+msgstr[0] "{count} Münze"
+msgstr[1] "{count} Münzen"
+`,
+		"locales/de-AT.json": `{"greeting": "Servus {name}"}`,
+		"locales/pl.json":    `{"coins": {"one": "{count} moneta", "few": "{count} monety", "many": "{count} monet"}}`,
+		"config.json":        `{"resourceRoot": "%s", "locales": {"en": {"path": "locales/en.json"}, "de": {"path": "locales/de.po"}, "de-AT": {"path": "locales/de-AT.json"}, "pl": {"path": "locales/pl.json", "fallback": "en"}}}`,
+		"main.go":            "package main\n\nvar keys = []string{\"greeting\", `coins`}\n",
+	})
+}
+
+func TestCheckLocales(t *testing.T) {
+	dir := writeLocaleTestFiles(t)
+	fixRoot(t, filepath.Join(dir, "config.json"), dir)
+
+	report, err := CheckLocales(filepath.Join(dir, "config.json"), dir)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"de":    {"credits", "title"},
+		"de-AT": {"credits", "title"},
+	}, report.Missing)
+	assert.Equal(t, []string{"credits", "title"}, report.Unused)
+	assert.False(t, report.Empty())
+	assert.Contains(t, report.String(), "de-AT: missing credits")
+}
+
+// fixRoot sets the resource root of a config written with writeConfigFiles.
+func fixRoot(t *testing.T, fpath, root string) {
+	raw, err := os.ReadFile(fpath)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(fpath, []byte(fmt.Sprintf(string(raw), filepath.ToSlash(root))), 0o644))
+}
@@ -0,0 +1,265 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+)
+
+var ErrInvalidLoop = fmt.Errorf("invalid loop points")
+
+type ResourceConfig struct {
+	// TODO: others
+	Images        map[string]string              `json:"images"`
+	Sounds        map[string]SoundConfig         `json:"sounds"`
+	Music         map[string]MusicConfig         `json:"music"`
+	Synths        map[string]SynthConfig         `json:"synths"`
+	Fonts         map[string]FontConfig          `json:"fonts"`
+	BitmapFonts   map[string]BitmapFontConfig    `json:"bitmapFonts"`
+	Sections      map[string]SectionConfig       `json:"sections"`
+	Animations    map[string]AnimationConfig     `json:"animations"`
+	Aseprite      map[string]AsepriteConfig      `json:"aseprite"`
+	TexturePacker map[string]TexturePackerConfig `json:"texturePacker"`
+	Tilemaps      map[string]TilemapConfig       `json:"tilemaps"`
+	Locales       map[string]LocaleConfig        `json:"locales"`
+	StateMachines map[string]StateMachineConfig  `json:"stateMachines"`
+	Atlas         AtlasConfig                    `json:"atlas"`
+	ResourceRoot  string                         `json:"resourceRoot"`
+
+	// raw holds the JSON of all sections by name, for the asset loaders.
+	raw map[string]json.RawMessage
+}
+
+// Raw returns the JSON of a top level section, so sections of custom asset loaders can be decoded.
+func (cfg ResourceConfig) Raw(section string) (json.RawMessage, bool) {
+	raw, ok := cfg.raw[section]
+	return raw, ok
+}
+
+func (cfg *ResourceConfig) UnmarshalJSON(data []byte) error {
+	type plain ResourceConfig
+	if err := json.Unmarshal(data, (*plain)(cfg)); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &cfg.raw)
+}
+
+// AnimationConfig defines an animation of frames sliced from an image section. FrameDurations optionally holds
+// the duration of every frame in seconds, the total Duration is then their sum and EaseFunc warps time over it.
+// Events maps event names to the index in Frames at which they are emitted.
+// Mode is "forward", "reverse" or "pingpong". Loops is the number of times the animation is played, zero plays
+// looped animations forever and others once. Speed multiplies the time of the animation, zero means normal speed.
+// Hitboxes maps names like "hitbox" or "hurtbox" to a box per step of Frames, null where a step has none.
+// Pivots holds the origin point of every step, relative to the frame like the boxes.
+type AnimationConfig struct {
+	Events         map[string]int           `json:"events"`
+	Hitboxes       map[string][]*RectConfig `json:"hitboxes"`
+	ImageName      string                   `json:"imageName"`
+	SectionName    string                   `json:"sectionName"`
+	EaseFunc       string                   `json:"easeFunc"`
+	Mode           string                   `json:"mode"`
+	Frames         []int                    `json:"frames"`
+	FrameDurations []float64                `json:"frameDurations"`
+	Pivots         []PointConfig            `json:"pivots"`
+	Duration       float64                  `json:"duration"`
+	Speed          float64                  `json:"speed"`
+	Loops          int                      `json:"loops"`
+	Width          int                      `json:"width"`
+	Height         int                      `json:"height"`
+	Looped         bool                     `json:"looped"`
+}
+
+// StepBoxes returns the named boxes per step, steps without a box have an empty rectangle.
+func (ac AnimationConfig) StepBoxes(name string) []image.Rectangle {
+	boxes := make([]image.Rectangle, len(ac.Hitboxes[name]))
+	for i, b := range ac.Hitboxes[name] {
+		if b != nil {
+			boxes[i] = b.Rect()
+		}
+	}
+	return boxes
+}
+
+// StepPivots returns the pivot of every step.
+func (ac AnimationConfig) StepPivots() []image.Point {
+	pivots := make([]image.Point, len(ac.Pivots))
+	for i, p := range ac.Pivots {
+		pivots[i] = image.Pt(p.X, p.Y)
+	}
+	return pivots
+}
+
+type RectConfig struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+func (rc RectConfig) Rect() image.Rectangle {
+	return image.Rect(rc.X, rc.Y, rc.X+rc.W, rc.Y+rc.H)
+}
+
+type PointConfig struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// AtlasConfig enables packing the loaded images into shared atlas pages, so drawing them can be batched.
+// Images larger than MaxImageSize in any direction are not packed, but the frames of animations
+// on them are. If DumpDir is set, all atlas pages are written there as PNG files for debugging.
+type AtlasConfig struct {
+	Enabled      bool   `json:"enabled"`
+	PageSize     int    `json:"pageSize"`
+	MaxImageSize int    `json:"maxImageSize"`
+	Padding      int    `json:"padding"`
+	DumpDir      string `json:"dumpDir"`
+}
+
+// AsepriteConfig references a sprite sheet JSON exported by Aseprite. Every frame tag becomes
+// an animation template named "<name>_<tag>" and the sheet is available as image <name>.
+type AsepriteConfig struct {
+	Path string `json:"path"`
+}
+
+// TexturePackerConfig references a sprite sheet JSON in TexturePacker hash or array format.
+// Every frame becomes an image named like the frame.
+type TexturePackerConfig struct {
+	Path       string                           `json:"path"`
+	Animations map[string]PrefixAnimationConfig `json:"animations"`
+}
+
+// PrefixAnimationConfig defines an animation of all frames whose names start with Prefix,
+// ordered naturally, so "walk_2.png" comes before "walk_10.png".
+type PrefixAnimationConfig struct {
+	Prefix   string  `json:"prefix"`
+	EaseFunc string  `json:"easeFunc"`
+	Duration float64 `json:"duration"`
+	Looped   bool    `json:"looped"`
+}
+
+// TilemapConfig references a map made with Tiled, either in JSON or TMX format.
+// External tilesets and images are loaded relative to the map.
+type TilemapConfig struct {
+	Path string `json:"path"`
+}
+
+// LocaleConfig references a string table in JSON or gettext PO format. Locale is the locale it translates,
+// by default the name of the table. Tables of the same locale are merged, so bundles can add strings.
+// Fallback is the locale that is used for keys the table does not contain.
+type LocaleConfig struct {
+	Path     string `json:"path"`
+	Locale   string `json:"locale"`
+	Fallback string `json:"fallback"`
+}
+
+// StateMachineConfig defines an animation state machine. States maps state names to animation names,
+// Params maps parameter names to "bool", "float" or "trigger".
+type StateMachineConfig struct {
+	States      map[string]string  `json:"states"`
+	Params      map[string]string  `json:"params"`
+	Initial     string             `json:"initial"`
+	Transitions []TransitionConfig `json:"transitions"`
+}
+
+// TransitionConfig defines a transition of a state machine. From may be "*" for all states.
+// Conditions are like "speed > 0.5", "grounded == true", "grounded" or "!grounded".
+type TransitionConfig struct {
+	From       string   `json:"from"`
+	To         string   `json:"to"`
+	Trigger    string   `json:"trigger"`
+	Conditions []string `json:"conditions"`
+	Priority   int      `json:"priority"`
+	OnFinish   bool     `json:"onFinish"`
+}
+
+// SectionConfig defines the area of an image that is sliced into frames. A width or height of zero
+// extends it to the edge of the image. Margin is the space around the grid and Spacing the space
+// between frames, Padding is used for each of them that is not set.
+type SectionConfig struct {
+	Margin  *int `json:"margin"`
+	Spacing *int `json:"spacing"`
+	Left    int  `json:"left"`
+	Top     int  `json:"top"`
+	Width   int  `json:"width"`
+	Height  int  `json:"height"`
+	Padding int  `json:"padding"`
+}
+
+// Grid returns the area of the section and the margin and spacing of its grid, which fall back to the padding.
+func (sec SectionConfig) Grid() (area image.Rectangle, margin, spacing int) {
+	margin, spacing = sec.Padding, sec.Padding
+	if sec.Margin != nil {
+		margin = *sec.Margin
+	}
+	if sec.Spacing != nil {
+		spacing = *sec.Spacing
+	}
+	return image.Rect(sec.Left, sec.Top, sec.Left+sec.Width, sec.Top+sec.Height), margin, spacing
+}
+
+type SoundConfig struct {
+	Path         string  `json:"path"`
+	Bus          string  `json:"bus"`
+	Volume       float64 `json:"volume"`
+	MaxInstances int     `json:"maxInstances"`
+}
+
+// MusicConfig defines a streamed music track. LoopStart and LoopEnd are sample offsets,
+// everything before LoopStart is played once as intro. A LoopEnd of zero means the end of the track.
+type MusicConfig struct {
+	Path      string  `json:"path"`
+	Volume    float64 `json:"volume"`
+	LoopStart int64   `json:"loopStart"`
+	LoopEnd   int64   `json:"loopEnd"`
+	Looped    bool    `json:"looped"`
+}
+
+// CheckLoop checks that the loop starts before it ends. The loop points are only checked against the length
+// of the track in samples if it is not zero, since it is not known without decoding the file.
+func (mc MusicConfig) CheckLoop(length int64) error {
+	switch {
+	case mc.LoopStart < 0 || mc.LoopEnd < 0:
+		return fmt.Errorf("%w: loop points must not be negative", ErrInvalidLoop)
+	case mc.LoopEnd != 0 && mc.LoopStart >= mc.LoopEnd:
+		return fmt.Errorf("%w: loop start %d is not before loop end %d", ErrInvalidLoop, mc.LoopStart, mc.LoopEnd)
+	case length > 0 && mc.LoopStart >= length:
+		return fmt.Errorf("%w: loop start %d is not before the end of the track at %d", ErrInvalidLoop, mc.LoopStart, length)
+	case length > 0 && mc.LoopEnd > length:
+		return fmt.Errorf("%w: loop end %d is after the end of the track at %d", ErrInvalidLoop, mc.LoopEnd, length)
+	}
+	return nil
+}
+
+// SynthConfig defines a sound effect that is generated by the sfxr synthesizer.
+// Params override single parameters of the preset by their name, e.g. "baseFreq" or "decay".
+type SynthConfig struct {
+	Preset       string             `json:"preset"`
+	Wave         string             `json:"wave"`
+	Params       map[string]float64 `json:"params"`
+	Seed         int64              `json:"seed"`
+	Bus          string             `json:"bus"`
+	Volume       float64            `json:"volume"`
+	MaxInstances int                `json:"maxInstances"`
+}
+
+// FontConfig defines a TTF or OTF font at a given size. The same file can be used for several sizes.
+type FontConfig struct {
+	Path string  `json:"path"`
+	Size float64 `json:"size"`
+}
+
+// BitmapFontConfig defines a font drawn from an image. Either the characters are mapped to the
+// grid cells of a section, row by row, or BMFont references an AngelCode BMFont descriptor.
+// Widths override the advance of single characters, Kerning adjusts pairs of characters like "AV".
+type BitmapFontConfig struct {
+	ImageName   string         `json:"imageName"`
+	SectionName string         `json:"sectionName"`
+	Chars       string         `json:"chars"`
+	Widths      map[string]int `json:"widths"`
+	Kerning     map[string]int `json:"kerning"`
+	Width       int            `json:"width"`
+	Height      int            `json:"height"`
+	LineHeight  int            `json:"lineHeight"`
+	BMFont      string         `json:"bmfont"`
+}
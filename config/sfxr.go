@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+)
+
+var (
+	ErrUnknownSfxrPreset = fmt.Errorf("unknown sfxr preset")
+	ErrUnknownSfxrWave   = fmt.Errorf("unknown sfxr wave")
+	ErrUnknownSfxrParam  = fmt.Errorf("unknown sfxr parameter")
+)
+
+// CheckSfxrPreset returns an error if name is not a preset of the sfxr synthesizer.
+func CheckSfxrPreset(name string) error {
+	if !sfxrPresets[name] {
+		return fmt.Errorf("%w: %s", ErrUnknownSfxrPreset, name)
+	}
+	return nil
+}
+
+// CheckSfxrWave returns an error if name is not a wave of the sfxr synthesizer.
+func CheckSfxrWave(name string) error {
+	if !sfxrWaves[name] {
+		return fmt.Errorf("%w: %s", ErrUnknownSfxrWave, name)
+	}
+	return nil
+}
+
+// CheckSfxrParam returns an error if name is not a parameter of the sfxr synthesizer.
+func CheckSfxrParam(name string) error {
+	if !sfxrParams[name] {
+		return fmt.Errorf("%w: %s", ErrUnknownSfxrParam, name)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownState     = fmt.Errorf("state unknown")
+	ErrUnknownParam     = fmt.Errorf("parameter unknown")
+	ErrParamKind        = fmt.Errorf("parameter has a different kind")
+	ErrInvalidCondition = fmt.Errorf("condition is invalid")
+)
+
+// AnyState is the source state of transitions that are taken from every state.
+const AnyState = "*"
+
+// ConditionConfig is a parsed transition condition. Op is one of "==", "!=", "<", "<=", ">" and ">=",
+// bools are 1 if true and 0 if false.
+type ConditionConfig struct {
+	Param string
+	Op    string
+	Value float64
+}
+
+// ParseCondition parses a condition like "speed > 0.5", "grounded == true", "grounded" or "!grounded".
+func ParseCondition(s string) (ConditionConfig, error) {
+	fields := strings.Fields(s)
+	switch len(fields) {
+	case 1:
+		name, negated := strings.CutPrefix(fields[0], "!")
+		if name == "" {
+			break
+		}
+		if negated {
+			return ConditionConfig{Param: name, Op: "==", Value: 0}, nil
+		}
+		return ConditionConfig{Param: name, Op: "==", Value: 1}, nil
+	case 3:
+		if !compareOps[fields[1]] {
+			break
+		}
+		var value float64
+		switch fields[2] {
+		case "true":
+			value = 1
+		case "false":
+			value = 0
+		default:
+			v, err := strconv.ParseFloat(fields[2], 64)
+			if err != nil {
+				return ConditionConfig{}, fmt.Errorf("%w: %s", ErrInvalidCondition, s)
+			}
+			value = v
+		}
+		return ConditionConfig{Param: fields[0], Op: fields[1], Value: value}, nil
+	}
+	return ConditionConfig{}, fmt.Errorf("%w: %s", ErrInvalidCondition, s)
+}
+
+// checkTransition returns an error if the transition connects unknown states, its trigger is not a trigger
+// parameter, or a condition is invalid or uses an unknown or trigger parameter.
+func (sc StateMachineConfig) checkTransition(tc TransitionConfig) error {
+	if _, ok := sc.States[tc.From]; !ok && tc.From != AnyState {
+		return fmt.Errorf("%w: %s", ErrUnknownState, tc.From)
+	}
+	if _, ok := sc.States[tc.To]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownState, tc.To)
+	}
+	if tc.Trigger != "" {
+		kind, ok := sc.Params[tc.Trigger]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownParam, tc.Trigger)
+		}
+		if kind != "trigger" {
+			return fmt.Errorf("%w: %s", ErrParamKind, tc.Trigger)
+		}
+	}
+	for _, s := range tc.Conditions {
+		c, err := ParseCondition(s)
+		if err != nil {
+			return err
+		}
+		kind, ok := sc.Params[c.Param]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownParam, c.Param)
+		}
+		if kind == "trigger" {
+			return fmt.Errorf("%w: %s is a trigger", ErrParamKind, c.Param)
+		}
+	}
+	return nil
+}
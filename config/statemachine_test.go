@@ -0,0 +1,81 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCondition(t *testing.T) {
+	testcases := []struct {
+		input    string
+		expected ConditionConfig
+		err      error
+	}{
+		{input: "speed > 0.5", expected: ConditionConfig{Param: "speed", Op: ">", Value: 0.5}},
+		{input: "speed <= -1", expected: ConditionConfig{Param: "speed", Op: "<=", Value: -1}},
+		{input: "grounded == true", expected: ConditionConfig{Param: "grounded", Op: "==", Value: 1}},
+		{input: "grounded != false", expected: ConditionConfig{Param: "grounded", Op: "!=", Value: 0}},
+		{input: "grounded", expected: ConditionConfig{Param: "grounded", Op: "==", Value: 1}},
+		{input: "!grounded", expected: ConditionConfig{Param: "grounded", Op: "==", Value: 0}},
+		{input: "!", err: ErrInvalidCondition},
+		{input: "speed >> 1", err: ErrInvalidCondition},
+		{input: "speed > fast", err: ErrInvalidCondition},
+		{input: "speed >", err: ErrInvalidCondition},
+	}
+
+	for _, tc := range testcases {
+		c, err := ParseCondition(tc.input)
+		assert.ErrorIs(t, err, tc.err, tc.input)
+		assert.Equal(t, tc.expected, c, tc.input)
+	}
+}
+
+func TestStateMachineConfig(t *testing.T) {
+	testcases := []struct {
+		name string
+		sc   StateMachineConfig
+		err  error
+	}{
+		{
+			name: "unknown initial",
+			sc:   StateMachineConfig{Initial: "walk", States: map[string]string{"idle": "idle"}},
+			err:  ErrUnknownState,
+		},
+		{
+			name: "unknown param kind",
+			sc:   StateMachineConfig{Initial: "idle", States: map[string]string{"idle": "idle"}, Params: map[string]string{"speed": "int"}},
+			err:  ErrParamKind,
+		},
+		{
+			name: "unknown target",
+			sc:   StateMachineConfig{Initial: "idle", States: map[string]string{"idle": "idle"}, Transitions: []TransitionConfig{{From: "*", To: "run"}}},
+			err:  ErrUnknownState,
+		},
+		{
+			name: "trigger in condition",
+			sc: StateMachineConfig{
+				Initial:     "idle",
+				States:      map[string]string{"idle": "idle"},
+				Params:      map[string]string{"attack": "trigger"},
+				Transitions: []TransitionConfig{{From: "idle", To: "idle", Conditions: []string{"attack"}}},
+			},
+			err: ErrParamKind,
+		},
+		{
+			name: "bool as trigger",
+			sc: StateMachineConfig{
+				Initial:     "idle",
+				States:      map[string]string{"idle": "idle"},
+				Params:      map[string]string{"hurt": "bool"},
+				Transitions: []TransitionConfig{{From: "idle", To: "idle", Trigger: "hurt"}},
+			},
+			err: ErrParamKind,
+		},
+	}
+
+	for _, tc := range testcases {
+		errs := Validate(ResourceConfig{StateMachines: map[string]StateMachineConfig{"hero": tc.sc}})
+		assert.ErrorIs(t, errs, tc.err, tc.name)
+	}
+}
@@ -0,0 +1,127 @@
+package config
+
+var (
+	// paramKinds are the kinds of state machine parameters.
+	paramKinds = map[string]bool{
+		"bool":    true,
+		"float":   true,
+		"trigger": true,
+	}
+
+	// compareOps are the operators of transition conditions.
+	compareOps = map[string]bool{
+		"==": true,
+		"!=": true,
+		"<":  true,
+		"<=": true,
+		">":  true,
+		">=": true,
+	}
+
+	// playModes are the names of the play modes of animations.
+	playModes = map[string]bool{
+		"forward":  true,
+		"reverse":  true,
+		"pingpong": true,
+	}
+
+	// easeFuncs are the names of the ease functions of animations.
+	easeFuncs = map[string]bool{
+		"Linear":       true,
+		"InQuad":       true,
+		"OutQuad":      true,
+		"InOutQuad":    true,
+		"OutInQuad":    true,
+		"InCubic":      true,
+		"OutCubic":     true,
+		"InOutCubic":   true,
+		"OutInCubic":   true,
+		"InQuart":      true,
+		"OutQuart":     true,
+		"InOutQuart":   true,
+		"OutInQuart":   true,
+		"InQuint":      true,
+		"OutQuint":     true,
+		"InOutQuint":   true,
+		"OutInQuint":   true,
+		"InExpo":       true,
+		"OutExpo":      true,
+		"InOutExpo":    true,
+		"OutInExpo":    true,
+		"InSine":       true,
+		"OutSine":      true,
+		"InOutSine":    true,
+		"OutInSine":    true,
+		"InCirc":       true,
+		"OutCirc":      true,
+		"InOutCirc":    true,
+		"OutInCirc":    true,
+		"InBack":       true,
+		"OutBack":      true,
+		"InOutBack":    true,
+		"OutInBack":    true,
+		"InBounce":     true,
+		"OutBounce":    true,
+		"InOutBounce":  true,
+		"OutInBounce":  true,
+		"InElastic":    true,
+		"OutElastic":   true,
+		"InOutElastic": true,
+		"OutInElastic": true,
+	}
+
+	// poPluralForms are the plural categories of the msgstr indices in PO files by language.
+	poPluralForms = map[string][]string{
+		"en": {"one", "other"},
+		"de": {"one", "other"},
+		"fr": {"one", "other", "many"},
+		"pl": {"one", "few", "many"},
+		"ru": {"one", "few", "many"},
+	}
+
+	// sfxrPresets are the presets of the sfxr synthesizer.
+	sfxrPresets = map[string]bool{
+		"coin":      true,
+		"laser":     true,
+		"explosion": true,
+		"powerup":   true,
+		"hit":       true,
+		"jump":      true,
+		"blip":      true,
+	}
+
+	// sfxrWaves are the waves of the sfxr synthesizer.
+	sfxrWaves = map[string]bool{
+		"square":   true,
+		"sawtooth": true,
+		"sine":     true,
+		"noise":    true,
+	}
+
+	// sfxrParams are the parameters of the sfxr synthesizer as used in synth configs.
+	sfxrParams = map[string]bool{
+		"attack":       true,
+		"sustain":      true,
+		"punch":        true,
+		"decay":        true,
+		"baseFreq":     true,
+		"freqLimit":    true,
+		"freqRamp":     true,
+		"freqDRamp":    true,
+		"vibStrength":  true,
+		"vibSpeed":     true,
+		"arpMod":       true,
+		"arpSpeed":     true,
+		"duty":         true,
+		"dutyRamp":     true,
+		"repeatSpeed":  true,
+		"phaserOffset": true,
+		"phaserRamp":   true,
+		"lpfFreq":      true,
+		"lpfRamp":      true,
+		"lpfResonance": true,
+		"hpfFreq":      true,
+		"hpfRamp":      true,
+		"volume":       true,
+	}
+)
@@ -0,0 +1,424 @@
+package config
+
+import (
+	"fmt"
+	"image"
+	// The validator decodes the sizes of the same image formats the asset manager loads.
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrUnknownSection = fmt.Errorf("unknown section")
+	ErrDuplicateName  = fmt.Errorf("name is already used")
+	ErrNotPositive    = fmt.Errorf("value must be positive")
+	ErrNegative       = fmt.Errorf("value must not be negative")
+	ErrInvalidImage   = fmt.Errorf("invalid image")
+
+	ErrCharCountMismatch = fmt.Errorf("more characters than cells in section")
+	ErrInvalidKerning    = fmt.Errorf("kerning pair must consist of two characters")
+)
+
+// Error is a problem in a config, located by the JSON path of the offending field.
+type Error struct {
+	Path string
+	Err  error
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e Error) Unwrap() error {
+	return e.Err
+}
+
+// Errors are all problems found in a config.
+type Errors []Error
+
+func (errs Errors) Error() string {
+	lines := make([]string, len(errs))
+	for i, e := range errs {
+		lines[i] = e.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (errs Errors) Unwrap() []error {
+	unwrapped := make([]error, len(errs))
+	for i, e := range errs {
+		unwrapped[i] = e
+	}
+	return unwrapped
+}
+
+// ValidateFile checks a config file without loading its assets. Unlike loading, it does not stop
+// at the first problem but returns Errors with all of them.
+func ValidateFile(fname string) error {
+	cfg, err := Load[ResourceConfig](fname)
+	if err != nil {
+		return err
+	}
+	if errs := Validate(cfg); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+var jsonIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// jsonPath appends a map key to a JSON path, keys that are no identifiers are quoted.
+func jsonPath(p, key string) string {
+	if jsonIdentifier.MatchString(key) {
+		return p + "." + key
+	}
+	return p + "[" + strconv.Quote(key) + "]"
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type configValidator struct {
+	cfg  ResourceConfig
+	errs Errors
+	// images holds the size of every image by name. The size is zero if it is not known before loading.
+	images map[string]image.Point
+}
+
+// Validate checks a config and the files it references and returns all problems found.
+func Validate(cfg ResourceConfig) Errors {
+	v := &configValidator{
+		cfg:    cfg,
+		errs:   Errors{},
+		images: map[string]image.Point{},
+	}
+	v.validateImages()
+	v.validateAudio()
+	v.validateFonts()
+	v.validateSections()
+	v.validateAnimations()
+	v.validateSheets()
+	v.validateLocales()
+	v.validateStateMachines()
+	v.validateAtlas()
+	return v.errs
+}
+
+func (v *configValidator) add(p string, err error) {
+	v.errs = append(v.errs, Error{Path: p, Err: err})
+}
+
+// file checks that a file relative to the resource root exists.
+func (v *configValidator) file(p, relPath string) bool {
+	if _, err := os.Stat(path.Join(v.cfg.ResourceRoot, relPath)); err != nil {
+		v.add(p, fmt.Errorf("%w: %s", ErrFileNotFound, relPath))
+		return false
+	}
+	return true
+}
+
+func (v *configValidator) root() string {
+	if v.cfg.ResourceRoot == "" {
+		return "."
+	}
+	return v.cfg.ResourceRoot
+}
+
+func (v *configValidator) positive(p string, value float64) {
+	if value <= 0 {
+		v.add(p, fmt.Errorf("%w: %v", ErrNotPositive, value))
+	}
+}
+
+func (v *configValidator) notNegative(p string, value float64) {
+	if value < 0 {
+		v.add(p, fmt.Errorf("%w: %v", ErrNegative, value))
+	}
+}
+
+func (v *configValidator) easeFunc(p, name string) {
+	if err := CheckEaseFunc(name); err != nil {
+		v.add(p, err)
+	}
+}
+
+func (v *configValidator) addImage(p, name string, size image.Point) {
+	if _, ok := v.images[name]; ok {
+		v.add(p, fmt.Errorf("%w: %s", ErrDuplicateName, name))
+		return
+	}
+	v.images[name] = size
+}
+
+func (v *configValidator) validateImages() {
+	for _, relPath := range sortedKeys(v.cfg.Images) {
+		p := jsonPath("images", relPath)
+		size := image.Point{}
+		if v.file(p, relPath) {
+			f, err := os.Open(path.Join(v.cfg.ResourceRoot, relPath))
+			if err == nil {
+				imgCfg, _, err := image.DecodeConfig(f)
+				f.Close()
+				if err != nil {
+					v.add(p, fmt.Errorf("%w: %v", ErrInvalidImage, err))
+				}
+				size = image.Pt(imgCfg.Width, imgCfg.Height)
+			}
+		}
+		v.addImage(p, v.cfg.Images[relPath], size)
+	}
+	for _, name := range sortedKeys(v.cfg.Aseprite) {
+		p := jsonPath("aseprite", name)
+		v.file(jsonPath(p, "path"), v.cfg.Aseprite[name].Path)
+		v.addImage(p, name, image.Point{})
+	}
+}
+
+func (v *configValidator) validateAudio() {
+	for _, name := range sortedKeys(v.cfg.Sounds) {
+		v.file(jsonPath(jsonPath("sounds", name), "path"), v.cfg.Sounds[name].Path)
+	}
+	for _, name := range sortedKeys(v.cfg.Music) {
+		p := jsonPath("music", name)
+		v.file(jsonPath(p, "path"), v.cfg.Music[name].Path)
+		if err := v.cfg.Music[name].CheckLoop(0); err != nil {
+			v.add(jsonPath(p, "loopStart"), err)
+		}
+	}
+	for _, name := range sortedKeys(v.cfg.Synths) {
+		p := jsonPath("synths", name)
+		syn := v.cfg.Synths[name]
+		if _, ok := v.cfg.Sounds[name]; ok {
+			v.add(p, fmt.Errorf("%w: %s", ErrDuplicateName, name))
+		}
+		if syn.Preset != "" {
+			if err := CheckSfxrPreset(syn.Preset); err != nil {
+				v.add(jsonPath(p, "preset"), err)
+			}
+		}
+		if syn.Wave != "" {
+			if err := CheckSfxrWave(syn.Wave); err != nil {
+				v.add(jsonPath(p, "wave"), err)
+			}
+		}
+		for _, param := range sortedKeys(syn.Params) {
+			if err := CheckSfxrParam(param); err != nil {
+				v.add(jsonPath(jsonPath(p, "params"), param), err)
+			}
+		}
+	}
+}
+
+func (v *configValidator) validateFonts() {
+	for _, name := range sortedKeys(v.cfg.Fonts) {
+		p := jsonPath("fonts", name)
+		v.file(jsonPath(p, "path"), v.cfg.Fonts[name].Path)
+		v.positive(jsonPath(p, "size"), v.cfg.Fonts[name].Size)
+	}
+
+	for _, name := range sortedKeys(v.cfg.BitmapFonts) {
+		p := jsonPath("bitmapFonts", name)
+		bf := v.cfg.BitmapFonts[name]
+		if _, ok := v.cfg.Fonts[name]; ok {
+			v.add(p, fmt.Errorf("%w: %s", ErrDuplicateName, name))
+		}
+		for _, pair := range sortedKeys(bf.Kerning) {
+			if utf8.RuneCountInString(pair) != 2 {
+				v.add(jsonPath(jsonPath(p, "kerning"), pair), ErrInvalidKerning)
+			}
+		}
+		if bf.BMFont != "" {
+			v.file(jsonPath(p, "bmfont"), bf.BMFont)
+			continue
+		}
+
+		v.positive(jsonPath(p, "width"), float64(bf.Width))
+		v.positive(jsonPath(p, "height"), float64(bf.Height))
+		cells := v.cells(p, bf.ImageName, bf.SectionName, bf.Width, bf.Height)
+		if cells >= 0 && utf8.RuneCountInString(bf.Chars) > cells {
+			v.add(jsonPath(p, "chars"), fmt.Errorf("%w: %d characters, %d cells", ErrCharCountMismatch, utf8.RuneCountInString(bf.Chars), cells))
+		}
+	}
+}
+
+func (v *configValidator) validateSections() {
+	for _, name := range sortedKeys(v.cfg.Sections) {
+		p := jsonPath("sections", name)
+		sec := v.cfg.Sections[name]
+		v.notNegative(jsonPath(p, "left"), float64(sec.Left))
+		v.notNegative(jsonPath(p, "top"), float64(sec.Top))
+		v.notNegative(jsonPath(p, "width"), float64(sec.Width))
+		v.notNegative(jsonPath(p, "height"), float64(sec.Height))
+		v.notNegative(jsonPath(p, "padding"), float64(sec.Padding))
+		if sec.Margin != nil {
+			v.notNegative(jsonPath(p, "margin"), float64(*sec.Margin))
+		}
+		if sec.Spacing != nil {
+			v.notNegative(jsonPath(p, "spacing"), float64(*sec.Spacing))
+		}
+	}
+}
+
+// cells checks the image and section references of p and returns the number of grid cells,
+// or -1 if it is not known.
+func (v *configValidator) cells(p, imageName, sectionName string, w, h int) int {
+	size, ok := v.images[imageName]
+	if !ok {
+		v.add(jsonPath(p, "imageName"), fmt.Errorf("%w: %s", ErrImageNotLoaded, imageName))
+	}
+	sec, secOk := v.cfg.Sections[sectionName]
+	if sectionName != "" && !secOk {
+		v.add(jsonPath(p, "sectionName"), fmt.Errorf("%w: %s", ErrUnknownSection, sectionName))
+	}
+	if !ok || size == (image.Point{}) || sectionName != "" && !secOk || w <= 0 || h <= 0 {
+		return -1
+	}
+
+	area, margin, spacing := sec.Grid()
+	cells, err := GridCells(image.Rectangle{Max: size}, area, w, h, margin, spacing)
+	if err != nil {
+		v.add(p, err)
+		return -1
+	}
+	return len(cells)
+}
+
+func (v *configValidator) validateAnimations() {
+	for _, name := range sortedKeys(v.cfg.Animations) {
+		p := jsonPath("animations", name)
+		anim := v.cfg.Animations[name]
+		v.positive(jsonPath(p, "width"), float64(anim.Width))
+		v.positive(jsonPath(p, "height"), float64(anim.Height))
+		v.notNegative(jsonPath(p, "duration"), anim.Duration)
+		v.easeFunc(jsonPath(p, "easeFunc"), anim.EaseFunc)
+		if err := CheckPlayMode(anim.Mode); err != nil {
+			v.add(jsonPath(p, "mode"), err)
+		}
+		v.notNegative(jsonPath(p, "loops"), float64(anim.Loops))
+		v.notNegative(jsonPath(p, "speed"), anim.Speed)
+		if len(anim.Frames) == 0 {
+			v.add(jsonPath(p, "frames"), ErrFrameCountZero)
+		}
+		if len(anim.FrameDurations) > 0 && len(anim.FrameDurations) != len(anim.Frames) {
+			v.add(jsonPath(p, "frameDurations"), fmt.Errorf("%w: %d durations, %d frames", ErrFrameDurationCount, len(anim.FrameDurations), len(anim.Frames)))
+		}
+		for i, d := range anim.FrameDurations {
+			v.positive(fmt.Sprintf("%s.frameDurations[%d]", p, i), d)
+		}
+		for _, boxName := range sortedKeys(anim.Hitboxes) {
+			bp := jsonPath(jsonPath(p, "hitboxes"), boxName)
+			for i, b := range anim.Hitboxes[boxName] {
+				if b != nil {
+					v.positive(fmt.Sprintf("%s[%d].w", bp, i), float64(b.W))
+					v.positive(fmt.Sprintf("%s[%d].h", bp, i), float64(b.H))
+				}
+			}
+			validateStepValues(v, bp, anim.Frames, anim.StepBoxes(boxName))
+		}
+		if len(anim.Pivots) > 0 {
+			validateStepValues(v, jsonPath(p, "pivots"), anim.Frames, anim.StepPivots())
+		}
+		for _, event := range sortedKeys(anim.Events) {
+			if step := anim.Events[event]; step < 0 || step >= len(anim.Frames) {
+				v.add(jsonPath(jsonPath(p, "events"), event), fmt.Errorf("%w: step %d of %d frames", ErrFrameExceedsBounds, step, len(anim.Frames)))
+			}
+		}
+
+		cells := v.cells(p, anim.ImageName, anim.SectionName, anim.Width, anim.Height)
+		for i, f := range anim.Frames {
+			if f < 0 || cells >= 0 && f >= cells {
+				v.add(fmt.Sprintf("%s.frames[%d]", p, i), fmt.Errorf("%w: %d", ErrFrameExceedsBounds, f))
+			}
+		}
+	}
+}
+
+// validateStepValues checks that values given per step of frames fit to them. Frames out of bounds are reported elsewhere.
+func validateStepValues[T comparable](v *configValidator, p string, frames []int, values []T) {
+	images := 0
+	for _, f := range frames {
+		if f < 0 {
+			return
+		}
+		images = max(images, f+1)
+	}
+	if err := CheckSteps(frames, images, values); err != nil {
+		v.add(p, err)
+	}
+}
+
+func (v *configValidator) validateSheets() {
+	for _, name := range sortedKeys(v.cfg.TexturePacker) {
+		p := jsonPath("texturePacker", name)
+		tp := v.cfg.TexturePacker[name]
+		v.file(jsonPath(p, "path"), tp.Path)
+		for _, animName := range sortedKeys(tp.Animations) {
+			ap := jsonPath(jsonPath(p, "animations"), animName)
+			anim := tp.Animations[animName]
+			if _, ok := v.cfg.Animations[animName]; ok {
+				v.add(ap, fmt.Errorf("%w: %s", ErrDuplicateName, animName))
+			}
+			v.notNegative(jsonPath(ap, "duration"), anim.Duration)
+			v.easeFunc(jsonPath(ap, "easeFunc"), anim.EaseFunc)
+		}
+	}
+	for _, name := range sortedKeys(v.cfg.Tilemaps) {
+		v.file(jsonPath(jsonPath("tilemaps", name), "path"), v.cfg.Tilemaps[name].Path)
+	}
+}
+
+func (v *configValidator) validateStateMachines() {
+	for _, name := range sortedKeys(v.cfg.StateMachines) {
+		p := jsonPath("stateMachines", name)
+		sc := v.cfg.StateMachines[name]
+		if _, ok := sc.States[sc.Initial]; !ok {
+			v.add(jsonPath(p, "initial"), fmt.Errorf("%w: %s", ErrUnknownState, sc.Initial))
+		}
+		for _, param := range sortedKeys(sc.Params) {
+			if !paramKinds[sc.Params[param]] {
+				v.add(jsonPath(jsonPath(p, "params"), param), fmt.Errorf("%w: %s", ErrParamKind, sc.Params[param]))
+			}
+		}
+		for i, tc := range sc.Transitions {
+			if err := sc.checkTransition(tc); err != nil {
+				v.add(fmt.Sprintf("%s.transitions[%d]", p, i), err)
+			}
+		}
+	}
+}
+
+func (v *configValidator) validateLocales() {
+	for _, name := range sortedKeys(v.cfg.Locales) {
+		p := jsonPath(jsonPath("locales", name), "path")
+		lc := v.cfg.Locales[name]
+		if !v.file(p, lc.Path) {
+			continue
+		}
+		locale := lc.Locale
+		if locale == "" {
+			locale = name
+		}
+		if _, err := LoadStringTable(os.DirFS(v.root()), path.Clean(lc.Path), locale); err != nil {
+			v.add(p, err)
+		}
+	}
+}
+
+func (v *configValidator) validateAtlas() {
+	v.notNegative("atlas.pageSize", float64(v.cfg.Atlas.PageSize))
+	v.notNegative("atlas.maxImageSize", float64(v.cfg.Atlas.MaxImageSize))
+	v.notNegative("atlas.padding", float64(v.cfg.Atlas.Padding))
+}
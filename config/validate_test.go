@@ -0,0 +1,108 @@
+package config
+
+import (
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConfig(t *testing.T) {
+	dir := t.TempDir()
+	f, err := os.Create(filepath.Join(dir, "sheet.png"))
+	assert.NoError(t, err)
+	assert.NoError(t, png.Encode(f, image.NewNRGBA(image.Rect(0, 0, 32, 8))))
+	assert.NoError(t, f.Close())
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "zcopy.png"), []byte("no png"), 0o644))
+
+	cfg := ResourceConfig{
+		ResourceRoot: filepath.ToSlash(dir),
+		Images:       map[string]string{"sheet.png": "hero", "zcopy.png": "hero", "gfx/missing.png": "gone"},
+		Sounds:       map[string]SoundConfig{"jump": {Path: "jump.wav"}},
+		Music:        map[string]MusicConfig{"theme": {Path: "sheet.png", LoopStart: 10, LoopEnd: 10, Looped: true}},
+		Synths:       map[string]SynthConfig{"coin": {Preset: "coins", Params: map[string]float64{"decay": 0.1, "loudness": 1}}},
+		Sections:     map[string]SectionConfig{"row": {Width: 32, Height: 8}},
+		Animations: map[string]AnimationConfig{
+			"walk":  {ImageName: "hero", SectionName: "row", Frames: []int{0, 3, 4}, Width: 8, Height: 8, Duration: 1, Events: map[string]int{"hit": 3}, Hitboxes: map[string][]*RectConfig{"sword": {{W: 0, H: 2}, nil}}},
+			"jump":  {ImageName: "hero", SectionName: "rows", Frames: []int{0}, Width: 8, Height: 8, EaseFunc: "Bounce", FrameDurations: []float64{0.1, 0}},
+			"ghost": {ImageName: "ghost", Frames: []int{}, Width: 0, Height: 8, Duration: -1, Mode: "bounce", Loops: -2},
+		},
+	}
+
+	errs := Validate(cfg)
+	paths := []string{}
+	for _, e := range errs {
+		paths = append(paths, e.Path)
+	}
+	assert.Equal(t, []string{
+		`images["gfx/missing.png"]`,
+		`images["zcopy.png"]`,
+		`images["zcopy.png"]`,
+		`sounds.jump.path`,
+		`music.theme.loopStart`,
+		`synths.coin.preset`,
+		`synths.coin.params.loudness`,
+		`animations.ghost.width`,
+		`animations.ghost.duration`,
+		`animations.ghost.mode`,
+		`animations.ghost.loops`,
+		`animations.ghost.frames`,
+		`animations.ghost.imageName`,
+		`animations.jump.easeFunc`,
+		`animations.jump.frameDurations`,
+		`animations.jump.frameDurations[1]`,
+		`animations.jump.sectionName`,
+		`animations.walk.hitboxes.sword[0].w`,
+		`animations.walk.hitboxes.sword`,
+		`animations.walk.events.hit`,
+		`animations.walk.frames[2]`,
+	}, paths)
+
+	assert.ErrorIs(t, errs[0].Err, ErrFileNotFound)
+	assert.ErrorIs(t, errs[1].Err, ErrInvalidImage)
+	assert.ErrorIs(t, errs[2].Err, ErrDuplicateName)
+	assert.ErrorIs(t, errs, ErrUnknownSection)
+	assert.ErrorIs(t, errs, ErrFrameExceedsBounds)
+	assert.ErrorIs(t, errs, ErrFrameDurationCount)
+	assert.ErrorIs(t, errs, ErrUnknownPlayMode)
+	assert.ErrorIs(t, errs, ErrFrameBoxCount)
+	assert.ErrorIs(t, errs, ErrInvalidLoop)
+	assert.Contains(t, errs.Error(), "animations.walk.frames[2]: frame index exceeds section bounds: 4")
+
+	var target Errors
+	assert.True(t, errors.As(error(errs), &target))
+}
+
+func TestMusicCheckLoop(t *testing.T) {
+	testcases := []struct {
+		name   string
+		mc     MusicConfig
+		length int64
+		err    error
+	}{
+		{name: "whole track", mc: MusicConfig{}, length: 100},
+		{name: "intro", mc: MusicConfig{LoopStart: 20}, length: 100},
+		{name: "loop section", mc: MusicConfig{LoopStart: 20, LoopEnd: 80}, length: 100},
+		{name: "unknown length", mc: MusicConfig{LoopStart: 200}},
+		{name: "negative", mc: MusicConfig{LoopStart: -1}, err: ErrInvalidLoop},
+		{name: "empty loop", mc: MusicConfig{LoopStart: 20, LoopEnd: 20}, err: ErrInvalidLoop},
+		{name: "reversed loop", mc: MusicConfig{LoopStart: 80, LoopEnd: 20}, length: 100, err: ErrInvalidLoop},
+		{name: "start after track", mc: MusicConfig{LoopStart: 100}, length: 100, err: ErrInvalidLoop},
+		{name: "end at track end", mc: MusicConfig{LoopEnd: 100}, length: 100},
+		{name: "end after track", mc: MusicConfig{LoopEnd: 101}, length: 100, err: ErrInvalidLoop},
+	}
+
+	for _, tc := range testcases {
+		assert.ErrorIs(t, tc.mc.CheckLoop(tc.length), tc.err, tc.name)
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	assert.Equal(t, "animations.walk_left", jsonPath("animations", "walk_left"))
+	assert.Equal(t, `images["gfx/hero.png"]`, jsonPath("images", "gfx/hero.png"))
+	assert.Equal(t, `fonts["big font"].size`, jsonPath(jsonPath("fonts", "big font"), "size"))
+}
@@ -0,0 +1,31 @@
+package vigor
+
+import "strings"
+
+// debugInspector is implemented by systems that show their internal state in debug mode.
+type debugInspector interface {
+	debugInfo() string
+}
+
+// SetDebug toggles the debug overlay showing internal infos of the running game.
+func (g *glob) SetDebug(on bool) {
+	g.debug = on
+}
+
+func (g *glob) Debug() bool {
+	return g.debug
+}
+
+func (g *glob) debugOverlay() string {
+	inspectors := []debugInspector{
+		&g.mixer,
+		&g.assets,
+		&g.localizer,
+		&g.stateMachines,
+	}
+	infos := make([]string, 0, len(inspectors))
+	for _, in := range inspectors {
+		infos = append(infos, in.debugInfo())
+	}
+	return strings.Join(infos, "\n")
+}
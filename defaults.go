@@ -1,5 +1,20 @@
 package vigor
 
+import (
+	"github.com/dbriemann/vigor/config"
+)
+
 var (
-	configFilePath = "config.json"
+	configFilePath    = "config.json"
+	mixerSettingsPath = "" // see mixerSettingsFile
+
+	audioSampleRate          = 44100
+	defaultMaxSoundInstances = 4
+
+	defaultAtlasPageSize     = 1024
+	defaultAtlasMaxImageSize = 256
+
+	maxLoaderWorkers = 4
+
+	defaultLocale = config.DefaultLocale
 )
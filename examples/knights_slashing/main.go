@@ -22,6 +22,7 @@ const (
 	ActionFaster
 	ActionPrevEaseFunc
 	ActionNextEaseFunc
+	ActionToggleDebug
 )
 
 func GetFunctionName(i interface{}) string {
@@ -49,12 +50,14 @@ var (
 		ActionFaster:       {input.KeyGamepadUp, input.KeyUp},
 		ActionPrevEaseFunc: {input.KeyGamepadLeft, input.KeyLeft},
 		ActionNextEaseFunc: {input.KeyGamepadRight, input.KeyRight},
+		ActionToggleDebug:  {input.KeyGamepadStart, input.KeyD},
 	}
 )
 
 type Game struct {
 	bgKnights []*Knight
 	knight    *Knight
+	rival     *Knight
 	input     *input.Handler
 	dur       time.Duration
 	funcIndex int
@@ -67,6 +70,13 @@ func (g *Game) Init() {
 	g.knight.SetPos(screenWidth/2-float32(frameWidth/2), screenHeight/2-float32(frameHeight/2))
 	vigor.G.Add(g.knight)
 
+	// The rival faces the knight, close enough that their swords reach each other.
+	g.rival = NewKnight(0, 0)
+	g.rival.FlipX()
+	g.rival.SetDuration(900 * time.Millisecond)
+	g.rival.SetPos(g.knight.Pos().X+20, g.knight.Pos().Y)
+	vigor.G.Add(g.rival)
+
 	g.bgKnights = make([]*Knight, bgKnightsCount)
 	for i := range bgKnightsCount {
 		g.bgKnights[i] = NewKnight(0, 0)
@@ -91,17 +101,26 @@ func (g *Game) Update() {
 			g.funcIndex--
 			g.knight.SetTweenFunc(easeFuncs[g.funcIndex])
 		}
+	} else if g.input.ActionIsJustPressed(ActionToggleDebug) {
+		// Debug mode draws the hitboxes, hurtboxes and pivots of the current frames.
+		vigor.G.SetDebug(!vigor.G.Debug())
 	} else if g.input.ActionIsJustPressed(ActionNextEaseFunc) {
 		if g.funcIndex < len(easeFuncs)-1 {
 			g.funcIndex++
 			g.knight.SetTweenFunc(easeFuncs[g.funcIndex])
 		}
 	}
+	g.knight.strike(g.rival)
+	g.rival.strike(g.knight)
+
 	// fmt.Println("ease func:", GetFunctionName(easeFuncs[g.funcIndex]))
 	// fmt.Println("duration:", g.dur)
-	vigor.DebugPrintf("Ease func: %s (left/right arrows)\nDuration: %s (up/down arrows)",
+	vigor.DebugPrintf("Ease func: %s (left/right arrows)\nDuration: %s (up/down arrows)\nSwings: %d, hits: %d - %d (debug boxes: d)",
 		GetFunctionName(easeFuncs[g.funcIndex]),
 		g.dur,
+		g.knight.swings,
+		g.knight.hits,
+		g.rival.hits,
 	)
 }
 
@@ -111,17 +130,34 @@ func (g *Game) Layout(w, h int) (int, int) {
 
 type Knight struct {
 	vigor.Sprite
+	swings int
+	hits   int
+	// striking is set while the hitbox of the knight overlaps the hurtbox of its target.
+	striking bool
 }
 
 func (k *Knight) Update() {
 	k.Sprite.Update()
 }
 
+// strike counts a hit when the hitbox of the current frame starts to overlap the hurtbox of the target.
+// A swing hits at most once, even if the boxes overlap for several frames.
+func (k *Knight) strike(target *Knight) {
+	hitting := vigor.HitboxesCollide(&k.Sprite, "hitbox", &target.Sprite, "hurtbox")
+	if hitting && !k.striking {
+		k.hits++
+	}
+	k.striking = hitting
+}
+
 func NewKnight(x, y int) *Knight {
 	k := &Knight{
 		Sprite: *vigor.NewSprite("knight_attack1"),
 	}
 	k.SetPos(float32(x), float32(y))
+	k.OnAnimationEvent("hit", func(string) {
+		k.swings++
+	})
 	return k
 }
 
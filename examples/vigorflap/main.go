@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"image/color"
 	"math/rand"
+	"strconv"
 
 	"github.com/dbriemann/vigor"
 	input "github.com/quasilyte/ebitengine-input"
@@ -39,6 +40,8 @@ type Game struct {
 	spikesBottom   *vigor.Image
 	featherEmitter *vigor.Emitter
 	background     *vigor.Image
+	scoreText      *vigor.Text
+	highscoreText  *vigor.Text
 	flash          *vigor.FlashEffect
 	shake          *vigor.ShakeEffect
 	gameOverScene  bool
@@ -55,6 +58,13 @@ func (g *Game) Init() {
 	g.flash = vigor.NewFlashEffect(g.background, 0.3, ease.Linear, ease.Linear)
 	g.shake = vigor.NewShakeEffect(0.6, 6, 6)
 
+	g.scoreText = vigor.NewText("score", "0")
+	g.scoreText.SetShadow(1, 1, color.RGBA{53, 53, 61, 255})
+	vigor.G.Add(g.scoreText)
+	g.highscoreText = vigor.NewText("small", "")
+	g.highscoreText.SetShadow(1, 1, color.RGBA{53, 53, 61, 255})
+	vigor.G.Add(g.highscoreText)
+
 	g.spikesTop = vigor.NewImage("spikes")
 	g.spikesTop.SetPos(0, 0)
 	g.spikesTop.FlipY()
@@ -99,6 +109,7 @@ func (g *Game) Over() {
 
 	g.featherEmitter.SetOrigin(g.dove.Pos().X, g.dove.Pos().Y)
 	g.dove.Die()
+	vigor.PlaySound("death", vigor.SoundOptions{})
 	vigor.G.ApplyEffect(g.flash)
 	vigor.G.ApplyEffect(g.shake)
 	g.featherEmitter.Show(true)
@@ -107,12 +118,12 @@ func (g *Game) Over() {
 }
 
 func (g *Game) Update() {
-	// TODO: create font (placeable) entity
-	scoreStr := fmt.Sprintf("\n\n\n\n\n\n             %d", score)
+	g.scoreText.SetText(strconv.Itoa(score))
+	g.scoreText.SetPos(float32(screenWidth-int(g.scoreText.Dim().X))/2, 90)
 	if highscore > 0 {
-		scoreStr += fmt.Sprintf("\n       high: %d", highscore)
+		g.highscoreText.SetText(fmt.Sprintf("high: %d", highscore))
+		g.highscoreText.SetPos(float32(screenWidth-int(g.highscoreText.Dim().X))/2, 120)
 	}
-	vigor.DebugPrintf(scoreStr)
 
 	if g.gameOverScene {
 		if g.featherEmitter.ActiveParticles() == 0 {
@@ -131,7 +142,8 @@ func (g *Game) Update() {
 			g.dove.Accel().Y = 500
 			g.dove.Vel().X = 80
 		}
-		g.dove.SetAnimation("dove_flap")
+		_ = g.dove.StateMachine().Trigger("flap")
+		vigor.PlaySound("flap", vigor.SoundOptions{PitchVariation: 0.1})
 		g.dove.Vel().Y = -screenHeight
 	}
 
@@ -142,20 +154,29 @@ func (g *Game) Update() {
 		// death
 		g.Over()
 	} else if vigor.Collides(g.dove, g.bouncerLeft.back) {
-		score++
+		g.Score()
 		g.dove.Vel().X *= -1
 		g.dove.FlipX()
 		g.bouncerLeft.back.ApplyEffect(g.bouncerLeft.flash)
+		vigor.PlaySound("bounce", vigor.SoundOptions{Pan: -0.5})
 		g.paddleRight.PlaceRandomly()
 	} else if vigor.Collides(g.dove, g.bouncerRight.back) {
-		score++
+		g.Score()
 		g.dove.Vel().X *= -1
 		g.dove.FlipX()
 		g.bouncerRight.back.ApplyEffect(g.bouncerRight.flash)
+		vigor.PlaySound("bounce", vigor.SoundOptions{Pan: 0.5})
 		g.paddleLeft.PlaceRandomly()
 	}
 }
 
+func (g *Game) Score() {
+	score++
+	if highscore > 0 && score == highscore+1 {
+		vigor.PlaySound("highscore", vigor.SoundOptions{})
+	}
+}
+
 func (g *Game) Layout(w, h int) (int, int) {
 	return screenWidth, screenHeight
 }
@@ -240,15 +261,13 @@ func (d *Dove) Live() {
 
 func (d *Dove) Init() {
 	d.Live()
+	if _, err := d.UseStateMachine("dove"); err != nil {
+		panic(err)
+	}
 	vigor.G.Add(d)
 }
 
 func (d *Dove) Update() {
-	anim, _, finished := d.Animation()
-	if anim == "dove_flap" && finished {
-		d.ResetAnimation()
-		d.SetAnimation("dove_sail")
-	}
 	d.Sprite.Update()
 }
 
@@ -0,0 +1,119 @@
+package vigor
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"os"
+	"path"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+)
+
+var _ Font = (*vectorFont)(nil)
+
+// Font provides the glyph metrics and drawing needed to render a Text.
+type Font interface {
+	// LineHeight is the distance between two baselines in pixels.
+	LineHeight() int
+	// Advance is the horizontal distance from the start of r to the start of the next glyph.
+	Advance(r rune) int
+	// Kern is the additional horizontal adjustment between the two glyphs.
+	Kern(prev, r rune) int
+
+	// drawGlyph draws r with its line box starting at the top left point x, y.
+	drawGlyph(target *ebiten.Image, r rune, x, y int, clr color.Color)
+}
+
+// vectorFont renders TTF or OTF fonts at a fixed size.
+type vectorFont struct {
+	face   font.Face
+	ascent int
+	height int
+}
+
+func newVectorFont(data *opentype.Font, size float64) (*vectorFont, error) {
+	face, err := opentype.NewFace(data, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, err
+	}
+	m := face.Metrics()
+	f := &vectorFont{
+		face:   face,
+		ascent: m.Ascent.Ceil(),
+		height: m.Height.Ceil(),
+	}
+	return f, nil
+}
+
+func (f *vectorFont) LineHeight() int {
+	return f.height
+}
+
+func (f *vectorFont) Advance(r rune) int {
+	a, _ := f.face.GlyphAdvance(r)
+	return a.Round()
+}
+
+func (f *vectorFont) Kern(prev, r rune) int {
+	return f.face.Kern(prev, r).Round()
+}
+
+func (f *vectorFont) drawGlyph(target *ebiten.Image, r rune, x, y int, clr color.Color) {
+	text.Draw(target, string(r), f.face, x, y+f.ascent, clr)
+}
+
+func parseFontFile(fpath string) (*opentype.Font, error) {
+	raw, err := os.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	return opentype.Parse(raw)
+}
+
+// loadVectorFonts parses every font file once and creates a face for each configured size.
+// Font files that were parsed in the background are taken from decoded.
+func loadVectorFonts(root string, cfgs map[string]FontConfig, decoded *decodedFiles) (map[string]Font, error) {
+	parsed := map[string]*opentype.Font{}
+	fonts := map[string]Font{}
+
+	for name, cfg := range cfgs {
+		fpath := path.Join(root, cfg.Path)
+		data, ok := parsed[fpath]
+		if !ok {
+			var err error
+			data, err = decoded.font(fpath)
+			if err != nil {
+				return nil, fmt.Errorf("font %s: %w", name, err)
+			}
+			parsed[fpath] = data
+		}
+
+		f, err := newVectorFont(data, cfg.Size)
+		if err != nil {
+			return nil, fmt.Errorf("font %s: %w", name, err)
+		}
+		fonts[name] = f
+	}
+
+	return fonts, nil
+}
+
+func loadFontAssets(ctx *AssetContext, raw json.RawMessage) (map[string]any, error) {
+	cfg := map[string]FontConfig{}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, err
+	}
+	fonts, err := loadVectorFonts(ctx.Root, cfg, ctx.Assets.decoded)
+	if err != nil {
+		return nil, err
+	}
+	return toAssets(fonts), nil
+}
@@ -29,12 +29,21 @@ func (g *internalGame) Draw(target *ebiten.Image) {
 	for i := 0; i < len(g.effects); i++ {
 		g.effects[i].draw(target, op)
 	}
-	ebitenutil.DebugPrint(target, G.debugMsg)
+	msg := G.debugMsg
+	if G.debug {
+		msg += "\n" + G.debugOverlay()
+	}
+	ebitenutil.DebugPrint(target, msg)
 }
 
 func (g *internalGame) Update() error {
+	G.assets.update()
+	G.stateMachines.clear()
 	g.input.Update()
 	g.stage.Update()
+	voicePlaying := G.audio.update()
+	G.mixer.update(voicePlaying)
+	G.music.update()
 	for j := 0; j < len(g.effects); j++ {
 		finished := g.effects[j].Update()
 		if finished {
@@ -55,6 +64,11 @@ func (g *internalGame) Layout(width, height int) (logicalWidth, logicalHeight in
 
 func InitGame(g Game) error {
 	G.assets = NewAssetManager()
+	G.localizer = newLocalizer(&G.assets)
+	G.mixer = newMixer()
+	if err := G.mixer.Load(); err != nil {
+		return err
+	}
 
 	if err := G.assets.LoadConfig(configFilePath); err != nil {
 		return err
@@ -62,6 +76,8 @@ func InitGame(g Game) error {
 
 	G.SetTPS(60)
 
+	G.audio.init()
+
 	G.internalGame.input.Init(ebinput.SystemConfig{
 		DevicesEnabled: ebinput.AnyDevice,
 	})
@@ -49,6 +49,13 @@ func Vec2Floor[T Number, U constraints.Integer](in Vec2[T]) (out Vec2[U]) {
 	return
 }
 
+func abs[T Number](v T) T {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
 type Rect[T Number] struct {
 	Point Vec2[T]
 	Dim   Vec2[T]
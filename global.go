@@ -14,10 +14,17 @@ type glob struct {
 	internalGame internalGame
 	externalGame Game
 	assets       AssetManager
-	tps          uint32
-	dt           float32
-	idcounter    uint64
-	debugMsg     string // HACK:
+	audio        audioSystem
+	music        MusicPlayer
+	mixer        Mixer
+	localizer    Localizer
+	// stateMachines collects the state machines updated in the current frame for the debug overlay.
+	stateMachines stateMachineInspector
+	debug         bool
+	tps           uint32
+	dt            float32
+	idcounter     uint64
+	debugMsg      string // HACK:
 }
 
 func (g *glob) createId() uint64 {
@@ -40,6 +47,26 @@ func (g *glob) SetTPS(tps uint32) {
 	}
 }
 
+// Music returns the music player of the game.
+func (g *glob) Music() *MusicPlayer {
+	return &g.music
+}
+
+// Mixer returns the audio mixer with all buses.
+func (g *glob) Mixer() *Mixer {
+	return &g.mixer
+}
+
+// Localizer returns the localizer, which translates keys with the string tables of the current locale.
+func (g *glob) Localizer() *Localizer {
+	return &g.localizer
+}
+
+// Assets returns the asset manager, which loads and unloads asset bundles.
+func (g *glob) Assets() *AssetManager {
+	return &g.assets
+}
+
 func (g *glob) Add(s stageable) {
 	g.internalGame.add(s)
 }
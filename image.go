@@ -14,6 +14,7 @@ var _ effected = (*Image)(nil)
 type Image struct {
 	effects []Effect
 	image   *ebiten.Image
+	frame   frameTransform
 	visible bool
 
 	visual
@@ -23,6 +24,7 @@ type Image struct {
 func CopyImage(img *Image) *Image {
 	i := &Image{
 		image:   img.image,
+		frame:   img.frame,
 		visible: img.visible,
 		visual:  img.visual,
 		Object:  img.Object,
@@ -42,6 +44,11 @@ func NewImage(name string) *Image {
 	}
 
 	i.SetDim(uint32(i.image.Bounds().Dx()), uint32(i.image.Bounds().Dy()))
+	// Frames from sprite sheets may be trimmed, their dimension is the source size.
+	if f, ok := G.assets.imageFrames[name]; ok {
+		i.frame = f.transform()
+		i.SetDim(uint32(f.SourceSize.W), uint32(f.SourceSize.H))
+	}
 	return i
 }
 
@@ -82,7 +89,10 @@ func (c *Image) draw(target *ebiten.Image, op colorm.DrawImageOptions) {
 	for i := 0; i < len(c.effects); i++ {
 		c.effects[i].modifyDraw(&op)
 	}
-	colorm.DrawImage(target, c.image, cm, &op)
+	// Effects cover the whole dimension, so the frame transform is only applied to the image.
+	imgOp := op
+	c.frame.apply(&imgOp.GeoM, c.image)
+	colorm.DrawImage(target, c.image, cm, &imgOp)
 	for i := 0; i < len(c.effects); i++ {
 		c.effects[i].draw(target, op)
 	}
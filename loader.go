@@ -0,0 +1,406 @@
+package vigor
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"path"
+	"runtime"
+	"sync"
+
+	"github.com/dbriemann/vigor/config"
+	"golang.org/x/image/font/opentype"
+)
+
+// decodedFiles holds the files that were decoded in the background for a bundle.
+// Files that are missing are decoded when they are requested.
+type decodedFiles struct {
+	mu     sync.Mutex
+	images map[string]image.Image
+	sounds map[string][]byte
+	fonts  map[string]*opentype.Font
+	// maps and tilesets are the parsed Tiled files.
+	maps     map[string]*tiledMapData
+	tilesets map[string]*tiledTilesetData
+}
+
+func newDecodedFiles() *decodedFiles {
+	return &decodedFiles{
+		images:   map[string]image.Image{},
+		sounds:   map[string][]byte{},
+		fonts:    map[string]*opentype.Font{},
+		maps:     map[string]*tiledMapData{},
+		tilesets: map[string]*tiledTilesetData{},
+	}
+}
+
+func (d *decodedFiles) image(fpath string) (image.Image, error) {
+	if img, ok := d.decodedImage(fpath); ok {
+		return img, nil
+	}
+	return decodeImage(fpath)
+}
+
+// decodedImage returns an image only if it was decoded in the background.
+func (d *decodedFiles) decodedImage(fpath string) (image.Image, bool) {
+	if d == nil {
+		return nil, false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	img, ok := d.images[path.Clean(fpath)]
+	return img, ok
+}
+
+func (d *decodedFiles) tiledMap(fpath string) (*tiledMapData, bool) {
+	if d == nil {
+		return nil, false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	data, ok := d.maps[path.Clean(fpath)]
+	return data, ok
+}
+
+func (d *decodedFiles) tiledTileset(fpath string) (*tiledTilesetData, bool) {
+	if d == nil {
+		return nil, false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ts, ok := d.tilesets[path.Clean(fpath)]
+	return ts, ok
+}
+
+func (d *decodedFiles) sound(fpath string) ([]byte, error) {
+	if d != nil {
+		d.mu.Lock()
+		data, ok := d.sounds[path.Clean(fpath)]
+		d.mu.Unlock()
+		if ok {
+			return data, nil
+		}
+	}
+	return decodeSoundFile(fpath)
+}
+
+func (d *decodedFiles) font(fpath string) (*opentype.Font, error) {
+	if d != nil {
+		d.mu.Lock()
+		data, ok := d.fonts[path.Clean(fpath)]
+		d.mu.Unlock()
+		if ok {
+			return data, nil
+		}
+	}
+	return parseFontFile(fpath)
+}
+
+func (d *decodedFiles) decodeImage(fpath string) error {
+	img, err := decodeImage(fpath)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.images[path.Clean(fpath)] = img
+	d.mu.Unlock()
+	return nil
+}
+
+func (d *decodedFiles) decodeSound(fpath string) error {
+	data, err := decodeSoundFile(fpath)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.sounds[path.Clean(fpath)] = data
+	d.mu.Unlock()
+	return nil
+}
+
+func (d *decodedFiles) decodeFont(fpath string) error {
+	data, err := parseFontFile(fpath)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.fonts[path.Clean(fpath)] = data
+	d.mu.Unlock()
+	return nil
+}
+
+// decodeSheet decodes the image of an Aseprite or TexturePacker sprite sheet.
+func (d *decodedFiles) decodeSheet(fpath string) error {
+	raw, err := os.ReadFile(fpath)
+	if err != nil {
+		return err
+	}
+	data := struct {
+		Meta struct {
+			Image string `json:"image"`
+		} `json:"meta"`
+	}{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return err
+	}
+	return d.decodeImage(path.Join(path.Dir(fpath), data.Meta.Image))
+}
+
+func (d *decodedFiles) decodeBMFontPages(fpath string) error {
+	f, err := os.Open(fpath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := parseBMFont(f)
+	if err != nil {
+		return err
+	}
+	for _, p := range data.Pages {
+		if err := d.decodeImage(path.Join(path.Dir(fpath), p.File)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeTilemap parses a Tiled map and its external tilesets and decodes the tileset images.
+func (d *decodedFiles) decodeTilemap(fpath string) error {
+	raw, err := os.ReadFile(fpath)
+	if err != nil {
+		return err
+	}
+	data, err := parseTiledMap(fpath, raw)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.maps[path.Clean(fpath)] = data
+	d.mu.Unlock()
+
+	for _, ts := range data.Tilesets {
+		dir := path.Dir(fpath)
+		if ts.Source != "" {
+			source := path.Join(dir, ts.Source)
+			raw, err := os.ReadFile(source)
+			if err != nil {
+				return err
+			}
+			external, err := parseTiledTileset(source, raw)
+			if err != nil {
+				return err
+			}
+			d.mu.Lock()
+			d.tilesets[path.Clean(source)] = external
+			d.mu.Unlock()
+			ts, dir = *external, path.Dir(source)
+		}
+		// Tilesets without an image are rejected when the map is built.
+		if ts.Image != "" {
+			if err := d.decodeImage(path.Join(dir, ts.Image)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type loadTask struct {
+	// file is shown as the current asset in the progress.
+	file   string
+	decode func(fpath string) error
+}
+
+// bundleTasks returns the files of a config that can be decoded in the background.
+func bundleTasks(cfg ResourceConfig, d *decodedFiles) []loadTask {
+	tasks := []loadTask{}
+	for relPath := range cfg.Images {
+		tasks = append(tasks, loadTask{file: path.Join(cfg.ResourceRoot, relPath), decode: d.decodeImage})
+	}
+	for _, snd := range cfg.Sounds {
+		tasks = append(tasks, loadTask{file: path.Join(cfg.ResourceRoot, snd.Path), decode: d.decodeSound})
+	}
+	for _, ase := range cfg.Aseprite {
+		tasks = append(tasks, loadTask{file: path.Join(cfg.ResourceRoot, ase.Path), decode: d.decodeSheet})
+	}
+	for _, tp := range cfg.TexturePacker {
+		tasks = append(tasks, loadTask{file: path.Join(cfg.ResourceRoot, tp.Path), decode: d.decodeSheet})
+	}
+	// Font files used in several sizes are parsed once.
+	fonts := map[string]bool{}
+	for _, f := range cfg.Fonts {
+		fpath := path.Join(cfg.ResourceRoot, f.Path)
+		if !fonts[fpath] {
+			fonts[fpath] = true
+			tasks = append(tasks, loadTask{file: fpath, decode: d.decodeFont})
+		}
+	}
+	for _, bf := range cfg.BitmapFonts {
+		if bf.BMFont != "" {
+			tasks = append(tasks, loadTask{file: path.Join(cfg.ResourceRoot, bf.BMFont), decode: d.decodeBMFontPages})
+		}
+	}
+	for _, tm := range cfg.Tilemaps {
+		tasks = append(tasks, loadTask{file: path.Join(cfg.ResourceRoot, tm.Path), decode: d.decodeTilemap})
+	}
+	return tasks
+}
+
+type LoadProgress struct {
+	// Current is the file that is decoded last.
+	Current string
+	Loaded  int
+	Total   int
+}
+
+// Fraction returns the decoded part of all files between 0 and 1.
+func (p LoadProgress) Fraction() float32 {
+	if p.Total == 0 {
+		return 1
+	}
+	return float32(p.Loaded) / float32(p.Total)
+}
+
+// BundleLoader loads a bundle in the background. The files are decoded by worker goroutines,
+// the images and other assets are created on the game goroutine when all files are decoded.
+type BundleLoader struct {
+	name    string
+	cfg     ResourceConfig
+	decoded *decodedFiles
+
+	mu       sync.Mutex
+	progress LoadProgress
+	err      error
+	// decodedAll is set by the workers when all files are decoded.
+	decodedAll bool
+	done       bool
+}
+
+// LoadBundleAsync starts loading a bundle in the background. The progress can be polled every frame,
+// for example to draw a loading screen, and the bundle is usable as soon as the loader is done.
+func (r *AssetManager) LoadBundleAsync(name, fname string) (*BundleLoader, error) {
+	if name == baseBundle {
+		return nil, ErrBundleName
+	}
+	if r.bundle(name) != nil || r.loader(name) != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBundleLoaded, name)
+	}
+	cfg, err := config.Load[ResourceConfig](fname)
+	if err != nil {
+		return nil, err
+	}
+	if errs := config.Validate(cfg); len(errs) > 0 {
+		return nil, errs
+	}
+
+	l := &BundleLoader{
+		name:    name,
+		cfg:     cfg,
+		decoded: newDecodedFiles(),
+	}
+	tasks := bundleTasks(cfg, l.decoded)
+	l.progress.Total = len(tasks)
+	r.loaders = append(r.loaders, l)
+
+	queue := make(chan loadTask, len(tasks))
+	for _, task := range tasks {
+		queue <- task
+	}
+	close(queue)
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < min(maxLoaderWorkers, runtime.NumCPU(), len(tasks)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range queue {
+				l.run(task)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		l.mu.Lock()
+		l.decodedAll = true
+		l.mu.Unlock()
+	}()
+
+	return l, nil
+}
+
+func (l *BundleLoader) run(task loadTask) {
+	l.mu.Lock()
+	failed := l.err != nil
+	l.progress.Current = task.file
+	l.mu.Unlock()
+	// After an error the remaining files are skipped.
+	if failed {
+		return
+	}
+
+	err := task.decode(task.file)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.progress.Loaded++
+	if err != nil && l.err == nil {
+		l.err = fmt.Errorf("%s: %w", task.file, err)
+	}
+}
+
+func (l *BundleLoader) Name() string {
+	return l.name
+}
+
+func (l *BundleLoader) Progress() LoadProgress {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.progress
+}
+
+// Done reports whether the bundle is loaded or loading failed.
+func (l *BundleLoader) Done() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.done
+}
+
+// Err returns the error that stopped loading.
+func (l *BundleLoader) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+func (r *AssetManager) loader(name string) *BundleLoader {
+	for _, l := range r.loaders {
+		if l.name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// update creates the bundles of all loaders that finished decoding. It runs on the game goroutine.
+func (r *AssetManager) update() {
+	pending := r.loaders[:0]
+	for _, l := range r.loaders {
+		l.mu.Lock()
+		decodedAll, err := l.decodedAll, l.err
+		l.mu.Unlock()
+		if !decodedAll {
+			pending = append(pending, l)
+			continue
+		}
+
+		if err == nil {
+			err = r.loadBundle(l.name, l.cfg, l.decoded)
+		}
+		l.mu.Lock()
+		l.err = err
+		l.done = true
+		l.mu.Unlock()
+	}
+	r.loaders = pending
+}
@@ -0,0 +1,99 @@
+package vigor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func waitForLoader(t *testing.T, r *AssetManager, l *BundleLoader) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !l.Done() {
+		if time.Now().After(deadline) {
+			t.Fatal("loader did not finish")
+		}
+		r.update()
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLoadBundleAsync(t *testing.T) {
+	dir := writeBundleTestFiles(t)
+	r := NewAssetManager()
+
+	l, err := r.LoadBundleAsync("level1", filepath.Join(dir, "level1.json"))
+	assert.NoError(t, err)
+	_, err = r.LoadBundleAsync("level1", filepath.Join(dir, "level1.json"))
+	assert.ErrorIs(t, err, ErrBundleLoaded)
+	_, err = r.LoadBundleAsync("", filepath.Join(dir, "level1.json"))
+	assert.ErrorIs(t, err, ErrBundleName)
+
+	waitForLoader(t, &r, l)
+	assert.NoError(t, l.Err())
+	progress := l.Progress()
+	assert.Equal(t, 2, progress.Total)
+	assert.Equal(t, 2, progress.Loaded)
+	assert.Equal(t, float32(1), progress.Fraction())
+	assert.Equal(t, []string{"level1"}, r.Bundles())
+	assert.Contains(t, r.Images, "hero")
+	assert.Empty(t, r.loaders)
+
+	// Configs are validated before loading starts.
+	_, err = r.LoadBundleAsync("broken", filepath.Join(dir, "broken.json"))
+	assert.ErrorIs(t, err, ErrFileNotFound)
+	assert.Equal(t, []string{"level1"}, r.Bundles())
+	assert.NotContains(t, r.Images, "missing")
+}
+
+func TestLoadBundleAsyncFonts(t *testing.T) {
+	dir := writeBundleTestFiles(t)
+	r := NewAssetManager()
+
+	l, err := r.LoadBundleAsync("fonts", filepath.Join(dir, "fonts.json"))
+	assert.NoError(t, err)
+	// The bundle cannot be loaded synchronously while it is loaded in the background.
+	assert.ErrorIs(t, r.LoadBundle("fonts", filepath.Join(dir, "fonts.json")), ErrBundleLoaded)
+
+	waitForLoader(t, &r, l)
+	assert.NoError(t, l.Err())
+	// The font file is parsed once in the background for both sizes.
+	assert.Equal(t, 1, l.Progress().Total)
+	assert.Len(t, l.decoded.fonts, 1)
+	assert.Contains(t, r.Fonts, "small")
+	assert.Contains(t, r.Fonts, "large")
+	assert.Greater(t, r.Fonts["large"].LineHeight(), r.Fonts["small"].LineHeight())
+}
+
+func TestLoadProgressFraction(t *testing.T) {
+	assert.Equal(t, float32(1), LoadProgress{}.Fraction())
+	assert.Equal(t, float32(0.25), LoadProgress{Loaded: 1, Total: 4}.Fraction())
+}
+
+func TestLoadBundleAsyncTilemap(t *testing.T) {
+	dir := t.TempDir()
+	for name, f := range tiledTestFS(t) {
+		fpath := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(fpath), 0o755))
+		assert.NoError(t, os.WriteFile(fpath, f.Data, 0o644))
+	}
+	raw := fmt.Sprintf(`{"resourceRoot": "%s", "tilemaps": {"level": {"path": "maps/level.tmx"}}}`, filepath.ToSlash(dir))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "tilemaps.json"), []byte(raw), 0o644))
+	r := NewAssetManager()
+
+	l, err := r.LoadBundleAsync("tilemaps", filepath.Join(dir, "tilemaps.json"))
+	assert.NoError(t, err)
+	waitForLoader(t, &r, l)
+	assert.NoError(t, l.Err())
+	// The map, its external tileset and the tileset image are decoded by the workers.
+	assert.Equal(t, 1, l.Progress().Total)
+	assert.Len(t, l.decoded.maps, 1)
+	assert.Len(t, l.decoded.tilesets, 1)
+	assert.Len(t, l.decoded.images, 1)
+	m, err := FindAsset[*TiledMap](&r, "level")
+	assert.NoError(t, err)
+	assert.Len(t, m.Tilesets[0].images, 2)
+}
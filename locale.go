@@ -0,0 +1,308 @@
+package vigor
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/dbriemann/vigor/config"
+)
+
+var (
+	ErrUnknownLocale = fmt.Errorf("no string table for locale")
+	ErrLocaleFormat  = config.ErrLocaleFormat
+	ErrInvalidPO     = config.ErrInvalidPO
+)
+
+// PluralCategory is a CLDR plural category.
+type PluralCategory string
+
+const (
+	PluralZero  PluralCategory = "zero"
+	PluralOne   PluralCategory = "one"
+	PluralTwo   PluralCategory = "two"
+	PluralFew   PluralCategory = "few"
+	PluralMany  PluralCategory = "many"
+	PluralOther PluralCategory = "other"
+)
+
+// PluralCategoryOf returns the plural category of an integer count in a locale.
+// Locales without known rules use the English ones.
+func PluralCategoryOf(locale string, n int) PluralCategory {
+	rule, ok := pluralRules[config.BaseLanguage(locale)]
+	if !ok {
+		rule = pluralRules[defaultLocale]
+	}
+	if n < 0 {
+		n = -n
+	}
+	return rule(n)
+}
+
+func pluralOneOther(n int) PluralCategory {
+	if n == 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+func pluralFrench(n int) PluralCategory {
+	switch {
+	case n == 0 || n == 1:
+		return PluralOne
+	case n%1000000 == 0:
+		return PluralMany
+	}
+	return PluralOther
+}
+
+func pluralPolish(n int) PluralCategory {
+	switch {
+	case n == 1:
+		return PluralOne
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return PluralFew
+	}
+	return PluralMany
+}
+
+func pluralRussian(n int) PluralCategory {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return PluralOne
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return PluralFew
+	}
+	return PluralMany
+}
+
+// StringTable holds the translated strings of a locale.
+type StringTable struct {
+	locale   string
+	fallback string
+	strings  map[string]string
+	plurals  map[string]map[PluralCategory]string
+}
+
+// newStringTable creates the string table of a locale from the entries of a string table file.
+func newStringTable(locale, fallback string, entries config.StringTableConfig) *StringTable {
+	st := &StringTable{
+		locale:   locale,
+		fallback: fallback,
+		strings:  map[string]string{},
+		plurals:  map[string]map[PluralCategory]string{},
+	}
+	for key, s := range entries.Strings {
+		st.strings[key] = s
+	}
+	for key, forms := range entries.Plurals {
+		st.plurals[key] = map[PluralCategory]string{}
+		for category, s := range forms {
+			st.plurals[key][PluralCategory(category)] = s
+		}
+	}
+	return st
+}
+
+func (st *StringTable) Locale() string {
+	return st.locale
+}
+
+// Keys returns all keys of the table in sorted order.
+func (st *StringTable) Keys() []string {
+	keys := make([]string, 0, len(st.strings)+len(st.plurals))
+	for k := range st.strings {
+		keys = append(keys, k)
+	}
+	for k := range st.plurals {
+		if _, ok := st.strings[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// lookup returns the string of a key. If count is not nil, the plural form of the count is preferred.
+func (st *StringTable) lookup(key string, count *int) (string, bool) {
+	if forms, ok := st.plurals[key]; ok && count != nil {
+		if s, ok := forms[PluralCategoryOf(st.locale, *count)]; ok {
+			return s, true
+		}
+		if s, ok := forms[PluralOther]; ok {
+			return s, true
+		}
+	}
+	if s, ok := st.strings[key]; ok {
+		return s, true
+	}
+	if forms, ok := st.plurals[key]; ok {
+		s, ok := forms[PluralOther]
+		return s, ok
+	}
+	return "", false
+}
+
+func loadLocaleAssets(ctx *AssetContext, raw json.RawMessage) (map[string]any, error) {
+	cfg := map[string]LocaleConfig{}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, err
+	}
+	tables := map[string]*StringTable{}
+	for name, lc := range cfg {
+		locale := lc.Locale
+		if locale == "" {
+			locale = name
+		}
+		entries, err := config.LoadStringTable(ctx.FS, path.Clean(lc.Path), locale)
+		if err != nil {
+			return nil, err
+		}
+		tables[name] = newStringTable(locale, lc.Fallback, entries)
+	}
+	return toAssets(tables), nil
+}
+
+// interpolate replaces named placeholders like {name} with the values of params.
+// Placeholders without a value are kept.
+func interpolate(s string, params map[string]any) string {
+	if len(params) == 0 || !strings.Contains(s, "{") {
+		return s
+	}
+	sb := strings.Builder{}
+	for {
+		start := strings.IndexByte(s, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		sb.WriteString(s[:start])
+		if v, ok := params[s[start+1:end]]; ok {
+			fmt.Fprint(&sb, v)
+		} else {
+			sb.WriteString(s[start : end+1])
+		}
+		s = s[end+1:]
+	}
+	sb.WriteString(s)
+	return sb.String()
+}
+
+// Localizer translates keys with the string tables of the current locale.
+type Localizer struct {
+	assets   *AssetManager
+	locale   string
+	fallback string
+	// revision changes with the locale, localized texts translate their key again when it differs
+	// from the revision they were translated for. No list of texts is kept, so they can be garbage collected.
+	revision uint64
+}
+
+func newLocalizer(assets *AssetManager) Localizer {
+	return Localizer{
+		assets:   assets,
+		locale:   defaultLocale,
+		fallback: defaultLocale,
+	}
+}
+
+func (l *Localizer) Locale() string {
+	return l.locale
+}
+
+// SetLocale switches the locale. Localized texts are translated again before they are updated or drawn next.
+func (l *Localizer) SetLocale(locale string) error {
+	if len(l.tables(locale)) == 0 && len(l.tables(config.BaseLanguage(locale))) == 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownLocale, locale)
+	}
+	l.locale = locale
+	l.revision++
+	return nil
+}
+
+// SetFallbackLocale sets the locale that is used for keys missing in the current locale and its fallbacks.
+// It defaults to English.
+func (l *Localizer) SetFallbackLocale(locale string) {
+	l.fallback = locale
+	l.revision++
+}
+
+// Translate returns the string of a key with the placeholders replaced by params. If params contains
+// an integer "count", the plural form of the count is used. Keys that are not found are returned as is.
+func (l *Localizer) Translate(key string, params map[string]any) string {
+	var count *int
+	if n, ok := params["count"].(int); ok {
+		count = &n
+	}
+	for _, locale := range append(config.LocaleChain(l.locale, l.fallbacks), config.LocaleChain(l.fallback, l.fallbacks)...) {
+		for _, st := range l.tables(locale) {
+			if s, ok := st.lookup(key, count); ok {
+				return interpolate(s, params)
+			}
+		}
+	}
+	return interpolate(key, params)
+}
+
+// TranslatePlural returns the plural form of a key for count, which is also available as placeholder {count}.
+func (l *Localizer) TranslatePlural(key string, count int, params map[string]any) string {
+	p := map[string]any{"count": count}
+	for k, v := range params {
+		if k != "count" {
+			p[k] = v
+		}
+	}
+	return l.Translate(key, p)
+}
+
+// tables returns the string tables of a locale. Tables of bundles loaded later come first.
+func (l *Localizer) tables(locale string) []*StringTable {
+	r := l.assets
+	if r == nil {
+		r = &G.assets
+	}
+	tables := []*StringTable{}
+	for i := len(r.bundles) - 1; i >= 0; i-- {
+		names := []string{}
+		for key := range r.bundles[i].assets {
+			if key.kind == assetLocale {
+				names = append(names, key.name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if st := r.bundles[i].assets[assetKey{assetLocale, name}].(*StringTable); st.Locale() == locale {
+				tables = append(tables, st)
+			}
+		}
+	}
+	return tables
+}
+
+// fallbacks returns the fallback locales of the string tables of a locale.
+func (l *Localizer) fallbacks(locale string) []string {
+	fallbacks := []string{}
+	for _, st := range l.tables(locale) {
+		fallbacks = append(fallbacks, st.fallback)
+	}
+	return fallbacks
+}
+
+func (l *Localizer) debugInfo() string {
+	return fmt.Sprintf("locale: %s, fallback %s", strings.Join(config.LocaleChain(l.locale, l.fallbacks), " > "), l.fallback)
+}
+
+// LocaleReport lists the problems of the string tables of a config.
+type LocaleReport = config.LocaleReport
+
+// CheckLocales reports missing keys of the string tables in a config. If source directories are given,
+// the Go files in them are searched for string literals and keys that are never used are reported too.
+func CheckLocales(fname string, srcDirs ...string) (LocaleReport, error) {
+	return config.CheckLocales(fname, srcDirs...)
+}
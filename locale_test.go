@@ -0,0 +1,110 @@
+package vigor
+
+import (
+	"testing"
+
+	"github.com/dbriemann/vigor/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpolate(t *testing.T) {
+	params := map[string]any{"name": "Ada", "count": 3}
+	assert.Equal(t, "Ada has 3 coins", interpolate("{name} has {count} coins", params))
+	assert.Equal(t, "{missing} {name", interpolate("{missing} {name", params))
+	assert.Equal(t, "{name}", interpolate("{name}", nil))
+}
+
+func TestPluralCategoryOf(t *testing.T) {
+	testcases := []struct {
+		locale   string
+		counts   []int
+		expected PluralCategory
+	}{
+		{locale: "en", counts: []int{1, -1}, expected: PluralOne},
+		{locale: "en-US", counts: []int{0, 2, 11}, expected: PluralOther},
+		{locale: "de", counts: []int{1}, expected: PluralOne},
+		{locale: "de_AT", counts: []int{0, 5}, expected: PluralOther},
+		{locale: "fr", counts: []int{0, 1}, expected: PluralOne},
+		{locale: "fr", counts: []int{2, 100}, expected: PluralOther},
+		{locale: "fr", counts: []int{1000000, 3000000}, expected: PluralMany},
+		{locale: "pl", counts: []int{1}, expected: PluralOne},
+		{locale: "pl", counts: []int{2, 4, 22, 104}, expected: PluralFew},
+		{locale: "pl", counts: []int{0, 5, 11, 12, 14, 21, 25}, expected: PluralMany},
+		{locale: "ru", counts: []int{1, 21, 101}, expected: PluralOne},
+		{locale: "ru", counts: []int{2, 3, 24}, expected: PluralFew},
+		{locale: "ru", counts: []int{0, 5, 11, 12, 111}, expected: PluralMany},
+		{locale: "ja", counts: []int{1}, expected: PluralOne},
+	}
+
+	for _, tc := range testcases {
+		for _, n := range tc.counts {
+			assert.Equal(t, tc.expected, PluralCategoryOf(tc.locale, n), "%s %d", tc.locale, n)
+		}
+	}
+}
+
+// newLocaleTestAssets returns an asset manager with a bundle of string tables.
+func newLocaleTestAssets() *AssetManager {
+	r := NewAssetManager()
+	b := &bundle{name: "locales", assets: map[assetKey]any{}}
+	for name, st := range map[string]*StringTable{
+		"en": newStringTable("en", "", config.StringTableConfig{
+			Strings: map[string]string{"title": "Vigor", "greeting": "Hello {name}", "credits": "Credits"},
+			Plurals: map[string]map[string]string{"coins": {"one": "{count} coin", "other": "{count} coins"}},
+		}),
+		"de": newStringTable("de", "", config.StringTableConfig{
+			Strings: map[string]string{"greeting": "Hallo {name}"},
+			Plurals: map[string]map[string]string{"coins": {"one": "{count} Münze", "other": "{count} Münzen"}},
+		}),
+		"de-AT": newStringTable("de-AT", "", config.StringTableConfig{
+			Strings: map[string]string{"greeting": "Servus {name}"},
+		}),
+		"pl": newStringTable("pl", "en", config.StringTableConfig{
+			Plurals: map[string]map[string]string{"coins": {"one": "{count} moneta", "few": "{count} monety", "many": "{count} monet"}},
+		}),
+	} {
+		b.assets[assetKey{assetLocale, name}] = st
+	}
+	r.bundles = append(r.bundles, b)
+	return &r
+}
+
+func TestLocalizer(t *testing.T) {
+	defer func(l Localizer) {
+		G.localizer = l
+	}(G.localizer)
+	G.localizer = newLocalizer(newLocaleTestAssets())
+	l := &G.localizer
+
+	txt := &Text{font: monoFont{}}
+	txt.SetKey("greeting", map[string]any{"name": "Ada"})
+	assert.Equal(t, "Hello Ada", txt.Text())
+
+	assert.ErrorIs(t, l.SetLocale("fr"), ErrUnknownLocale)
+	assert.NoError(t, l.SetLocale("de-AT"))
+	assert.Equal(t, "Servus Ada", txt.Text())
+	assert.Equal(t, []string{"de-AT", "de"}, config.LocaleChain("de-AT", l.fallbacks))
+	// Keys missing in de-AT are taken from de, then from the fallback locale.
+	assert.Equal(t, "1 Münze", l.TranslatePlural("coins", 1, nil))
+	assert.Equal(t, "5 Münzen", l.TranslatePlural("coins", 5, nil))
+	assert.Equal(t, "Vigor", l.Translate("title", nil))
+	assert.Equal(t, "missing.key", l.Translate("missing.key", nil))
+
+	assert.NoError(t, l.SetLocale("pl"))
+	assert.Equal(t, "Hello Ada", txt.Text())
+	assert.Equal(t, "22 monety", l.TranslatePlural("coins", 22, nil))
+	assert.Equal(t, "25 monet", l.TranslatePlural("coins", 25, nil))
+
+	// Texts with fixed content are not translated anymore.
+	txt.SetText("fixed")
+	assert.NoError(t, l.SetLocale("de"))
+	assert.Equal(t, "fixed", txt.Text())
+
+	// Texts are translated again when they are updated after the locale changed.
+	txt.SetKey("greeting", map[string]any{"name": "Ada"})
+	assert.Equal(t, "Hallo Ada", txt.content)
+	assert.NoError(t, l.SetLocale("en"))
+	assert.Equal(t, "Hallo Ada", txt.content)
+	txt.Update()
+	assert.Equal(t, "Hello Ada", txt.content)
+}
@@ -0,0 +1,252 @@
+package vigor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidMarkup    = fmt.Errorf("invalid markup")
+	ErrUnknownMarkupTag = fmt.Errorf("unknown markup tag")
+	ErrUnbalancedMarkup = fmt.Errorf("unbalanced markup tags")
+	ErrInvalidColor     = fmt.Errorf("invalid color")
+)
+
+type richEffect uint8
+
+const (
+	richWave richEffect = 1 << iota
+	richShake
+	richRainbow
+)
+
+// richGlyph is a single character or icon of parsed markup.
+type richGlyph struct {
+	// color is nil if the default color of the text is used.
+	color   color.Color
+	icon    string
+	r       rune
+	effects richEffect
+}
+
+// markupSpan is a tagged range of glyphs [start, end).
+type markupSpan struct {
+	color color.Color
+	tag   string
+	value string
+	start int
+	end   int
+}
+
+// parseMarkup parses text with tags like [color=red]danger[/color] into glyphs and spans.
+// Supported tags are color, wave, shake, rainbow, span and the self-closing icon.
+// A literal '[' is written as "[[".
+func parseMarkup(s string) ([]richGlyph, []markupSpan, error) {
+	glyphs := []richGlyph{}
+	spans := []markupSpan{}
+	open := []int{}
+
+	style := func() richGlyph {
+		g := richGlyph{}
+		for _, i := range open {
+			switch spans[i].tag {
+			case "color":
+				g.color = spans[i].color
+			case "wave":
+				g.effects |= richWave
+			case "shake":
+				g.effects |= richShake
+			case "rainbow":
+				g.effects |= richRainbow
+			}
+		}
+		return g
+	}
+
+	for len(s) > 0 {
+		if strings.HasPrefix(s, "[[") {
+			g := style()
+			g.r = '['
+			glyphs = append(glyphs, g)
+			s = s[2:]
+			continue
+		}
+		if s[0] != '[' {
+			g := style()
+			r, size := utf8.DecodeRuneInString(s)
+			g.r = r
+			glyphs = append(glyphs, g)
+			s = s[size:]
+			continue
+		}
+
+		end := strings.IndexByte(s, ']')
+		if end < 0 {
+			return nil, nil, fmt.Errorf("%w: unterminated tag %q", ErrInvalidMarkup, s)
+		}
+		tag := s[1:end]
+		s = s[end+1:]
+
+		if name, ok := strings.CutPrefix(tag, "/"); ok {
+			if len(open) == 0 || spans[open[len(open)-1]].tag != name {
+				return nil, nil, fmt.Errorf("%w: unexpected [/%s]", ErrUnbalancedMarkup, name)
+			}
+			spans[open[len(open)-1]].end = len(glyphs)
+			open = open[:len(open)-1]
+			continue
+		}
+
+		name, value, _ := strings.Cut(tag, "=")
+		span := markupSpan{tag: name, value: value, start: len(glyphs)}
+		switch name {
+		case "icon":
+			if value == "" {
+				return nil, nil, fmt.Errorf("%w: icon without name", ErrInvalidMarkup)
+			}
+			g := style()
+			g.icon = value
+			span.end = len(glyphs) + 1
+			spans = append(spans, span)
+			glyphs = append(glyphs, g)
+			continue
+		case "color":
+			c, ok := colorNameMappings[value]
+			if !ok {
+				var err error
+				if c, err = parseHexColor(value); err != nil {
+					return nil, nil, err
+				}
+			}
+			span.color = c
+		case "wave", "shake", "rainbow", "span":
+		default:
+			return nil, nil, fmt.Errorf("%w: %s", ErrUnknownMarkupTag, name)
+		}
+		spans = append(spans, span)
+		open = append(open, len(spans)-1)
+	}
+
+	if len(open) > 0 {
+		return nil, nil, fmt.Errorf("%w: [%s] is not closed", ErrUnbalancedMarkup, spans[open[len(open)-1]].tag)
+	}
+
+	return glyphs, spans, nil
+}
+
+// parseHexColor parses colors in the form #rgb or #rrggbb.
+func parseHexColor(s string) (color.Color, error) {
+	hex, ok := strings.CutPrefix(s, "#")
+	if !ok || (len(hex) != 3 && len(hex) != 6) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidColor, s)
+	}
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidColor, s)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// placedGlyph is the position of a glyph after layouting.
+type placedGlyph struct {
+	x     int
+	width int
+	line  int
+}
+
+// layoutRich places the glyphs on lines like layoutText does. The width of icons is provided by iconWidth.
+// It returns the position of every glyph and the width of every line.
+func layoutRich(f Font, glyphs []richGlyph, iconWidth func(string) int, wrapWidth int) ([]placedGlyph, []int) {
+	isText := func(i int) bool {
+		return glyphs[i].icon == "" && glyphs[i].r != '\n'
+	}
+	advance := func(i int) int {
+		if glyphs[i].icon != "" {
+			return iconWidth(glyphs[i].icon)
+		}
+		if glyphs[i].r == '\n' {
+			return 0
+		}
+		return f.Advance(glyphs[i].r)
+	}
+	kern := func(i int) int {
+		if i == 0 || !isText(i) || !isText(i-1) {
+			return 0
+		}
+		return f.Kern(glyphs[i-1].r, glyphs[i].r)
+	}
+
+	placed := make([]placedGlyph, len(glyphs))
+	widths := []int{0}
+	x, line := 0, 0
+	place := func(i int) {
+		if x > 0 {
+			x += kern(i)
+		}
+		placed[i] = placedGlyph{x: x, width: advance(i), line: line}
+		x += placed[i].width
+		// Trailing spaces do not count for the line width.
+		if glyphs[i].r != ' ' || glyphs[i].icon != "" {
+			widths[line] = x
+		}
+	}
+
+	for i := 0; i < len(glyphs); {
+		g := glyphs[i]
+		switch {
+		case g.icon == "" && g.r == '\n':
+			place(i)
+			line++
+			x = 0
+			widths = append(widths, 0)
+			i++
+		case g.icon == "" && g.r == ' ':
+			place(i)
+			i++
+		default:
+			j := i
+			w := 0
+			for ; j < len(glyphs) && (glyphs[j].icon != "" || (glyphs[j].r != ' ' && glyphs[j].r != '\n')); j++ {
+				if j > i {
+					w += kern(j)
+				}
+				w += advance(j)
+			}
+			if wrapWidth > 0 && x > 0 && x+kern(i)+w > wrapWidth {
+				line++
+				x = 0
+				widths = append(widths, 0)
+			}
+			for ; i < j; i++ {
+				place(i)
+			}
+		}
+	}
+
+	return placed, widths
+}
+
+// spanRects returns one rectangle per line covered by the glyphs [start, end).
+// offsets are the horizontal offsets of the lines and stride is the distance between two lines.
+func spanRects(placed []placedGlyph, offsets []int, start, end, lineHeight, stride int) []image.Rectangle {
+	rects := []image.Rectangle{}
+	for i := start; i < end; i++ {
+		p := placed[i]
+		r := image.Rect(offsets[p.line]+p.x, p.line*stride, offsets[p.line]+p.x+p.width, p.line*stride+lineHeight)
+		if r.Empty() {
+			continue
+		}
+		if n := len(rects); n > 0 && rects[n-1].Min.Y == r.Min.Y {
+			rects[n-1] = rects[n-1].Union(r)
+			continue
+		}
+		rects = append(rects, r)
+	}
+	return rects
+}
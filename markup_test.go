@@ -0,0 +1,190 @@
+package vigor
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/tanema/gween/ease"
+)
+
+func glyphString(glyphs []richGlyph) string {
+	runes := []rune{}
+	for _, g := range glyphs {
+		if g.icon != "" {
+			runes = append(runes, '#')
+			continue
+		}
+		runes = append(runes, g.r)
+	}
+	return string(runes)
+}
+
+func TestParseMarkup(t *testing.T) {
+	testcases := []struct {
+		name   string
+		markup string
+		text   string
+		spans  []markupSpan
+		err    error
+	}{
+		{
+			name:   "plain",
+			markup: "hello ünicode",
+			text:   "hello ünicode",
+			spans:  []markupSpan{},
+		},
+		{
+			name:   "color and icon",
+			markup: "[color=red]danger[/color] press [icon=button_a]",
+			text:   "danger press #",
+			spans: []markupSpan{
+				{color: colorNameMappings["red"], tag: "color", value: "red", start: 0, end: 6},
+				{tag: "icon", value: "button_a", start: 13, end: 14},
+			},
+		},
+		{
+			name:   "nested",
+			markup: "[span=tip][wave]a[/wave]b[/span]",
+			text:   "ab",
+			spans: []markupSpan{
+				{tag: "span", value: "tip", start: 0, end: 2},
+				{tag: "wave", start: 0, end: 1},
+			},
+		},
+		{
+			name:   "escaped bracket",
+			markup: "[[x]",
+			text:   "[x]",
+			spans:  []markupSpan{},
+		},
+		{
+			name:   "unknown tag",
+			markup: "[bold]x[/bold]",
+			err:    ErrUnknownMarkupTag,
+		},
+		{
+			name:   "not closed",
+			markup: "[shake]x",
+			err:    ErrUnbalancedMarkup,
+		},
+		{
+			name:   "wrong closing",
+			markup: "[shake][wave]x[/shake][/wave]",
+			err:    ErrUnbalancedMarkup,
+		},
+		{
+			name:   "unterminated tag",
+			markup: "[color=red",
+			err:    ErrInvalidMarkup,
+		},
+		{
+			name:   "invalid color",
+			markup: "[color=#12]x[/color]",
+			err:    ErrInvalidColor,
+		},
+	}
+
+	for _, tc := range testcases {
+		glyphs, spans, err := parseMarkup(tc.markup)
+		if tc.err != nil {
+			assert.ErrorIs(t, err, tc.err, tc.name)
+			continue
+		}
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, tc.text, glyphString(glyphs), tc.name)
+		assert.Equal(t, tc.spans, spans, tc.name)
+	}
+}
+
+func TestParseMarkupStyles(t *testing.T) {
+	glyphs, _, err := parseMarkup("[color=#0f0][rainbow]a[shake]b[/shake][/rainbow]c[/color]d")
+	assert.NoError(t, err)
+
+	green := color.RGBA{G: 0xff, A: 0xff}
+	assert.Equal(t, richGlyph{r: 'a', color: green, effects: richRainbow}, glyphs[0])
+	assert.Equal(t, richGlyph{r: 'b', color: green, effects: richRainbow | richShake}, glyphs[1])
+	assert.Equal(t, richGlyph{r: 'c', color: green}, glyphs[2])
+	assert.Equal(t, richGlyph{r: 'd'}, glyphs[3])
+}
+
+func TestLayoutRich(t *testing.T) {
+	glyphs, spans, err := parseMarkup("the [span=q]quick brown[/span] [icon=fox]\nAV")
+	assert.NoError(t, err)
+
+	iconWidth := func(string) int { return 10 }
+	placed, widths := layoutRich(monoFont{}, glyphs, iconWidth, 40)
+
+	// "the quick" | "brown #" | "AV"
+	assert.Equal(t, []int{36, 34, 7}, widths)
+	assert.Equal(t, placedGlyph{x: 24, width: 10, line: 1}, placed[16])
+	assert.Equal(t, placedGlyph{x: 3, width: 4, line: 2}, placed[19])
+
+	rects := spanRects(placed, []int{0, 0, 0}, spans[0].start, spans[0].end, 8, 10)
+	assert.Equal(t, []image.Rectangle{image.Rect(16, 0, 40, 8), image.Rect(0, 10, 20, 18)}, rects)
+}
+
+func TestHueColor(t *testing.T) {
+	assert.Equal(t, color.RGBA{R: 0xff, A: 0xff}, hueColor(0))
+	assert.Equal(t, color.RGBA{G: 0xff, A: 0xff}, hueColor(1.0/3))
+	assert.Equal(t, color.RGBA{B: 0xff, A: 0xff}, hueColor(2.0/3))
+}
+
+func TestRichTextRedraw(t *testing.T) {
+	defer func(r AssetManager) {
+		G.assets = r
+	}(G.assets)
+	G.assets = NewAssetManager()
+	G.assets.Fonts["mono"] = monoFont{}
+	G.assets.Images["coin"] = ebiten.NewImage(8, 8)
+	G.assets.AnimationTemplates["gem"] = &AnimationTemplate{
+		Images:   []*ebiten.Image{ebiten.NewImage(8, 8), ebiten.NewImage(8, 8)},
+		Frames:   []int{0, 1},
+		Duration: time.Second,
+		EaseFunc: ease.Linear,
+		Looped:   true,
+	}
+	tps, dt := G.tps, G.dt
+	t.Cleanup(func() { G.tps, G.dt = tps, dt })
+	G.SetTPS(10)
+
+	// updated reports whether the text needs a new render after an update.
+	updated := func(rt *RichText) bool {
+		rt.dirty = false
+		rt.Update()
+		return rt.dirty
+	}
+
+	testcases := []struct {
+		name    string
+		markup  string
+		redraws []bool
+	}{
+		{name: "plain", markup: "press [color=red]A[/color]", redraws: []bool{false, false, false}},
+		{name: "static icon", markup: "press [icon=coin]", redraws: []bool{false, false, false}},
+		{name: "wave", markup: "[wave]hey[/wave]", redraws: []bool{true, true, true}},
+		// The icon changes its frame after half a second.
+		{name: "animated icon", markup: "[icon=gem]", redraws: []bool{false, false, false, false, true, false}},
+	}
+
+	for _, tc := range testcases {
+		rt, err := NewRichText("mono", tc.markup)
+		assert.NoError(t, err, tc.name)
+		redraws := []bool{}
+		for range tc.redraws {
+			redraws = append(redraws, updated(rt))
+		}
+		assert.Equal(t, tc.redraws, redraws, tc.name)
+	}
+
+	// The typewriter only redraws when another character is revealed.
+	rt, err := NewRichText("mono", "abc")
+	assert.NoError(t, err)
+	rt.SetTypewriter(5)
+	assert.Equal(t, []bool{false, true, false, true, false, true, false}, []bool{
+		updated(rt), updated(rt), updated(rt), updated(rt), updated(rt), updated(rt), updated(rt),
+	})
+}
@@ -0,0 +1,220 @@
+package vigor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var ErrUnknownBus = fmt.Errorf("unknown mixer bus")
+
+const (
+	BusMaster = "master"
+	BusMusic  = "music"
+	BusSfx    = "sfx"
+	BusUI     = "ui"
+	BusVoice  = "voice"
+)
+
+type bus struct {
+	volume float64
+	muted  bool
+}
+
+// Mixer routes all sounds and music through named buses. Every bus is also affected by the master bus.
+// While a sound on the voice bus is playing, the music bus is ducked.
+type Mixer struct {
+	buses      map[string]*bus
+	duck       float64 // duck is the current factor applied to the music bus.
+	duckVolume float64 // duckVolume is the factor the music is lowered to while a voice plays.
+	duckSpeed  float64 // duckSpeed is the change of the duck factor per second.
+}
+
+func newMixer() Mixer {
+	m := Mixer{
+		buses:      map[string]*bus{},
+		duck:       1,
+		duckVolume: 0.3,
+		duckSpeed:  4,
+	}
+	for _, name := range []string{BusMaster, BusMusic, BusSfx, BusUI, BusVoice} {
+		m.buses[name] = &bus{volume: 1}
+	}
+	return m
+}
+
+func (m *Mixer) hasBus(name string) bool {
+	_, ok := m.buses[name]
+	return ok
+}
+
+func (m *Mixer) Volume(name string) float64 {
+	if b, ok := m.buses[name]; ok {
+		return b.volume
+	}
+	return 0
+}
+
+func (m *Mixer) SetVolume(name string, v float64) {
+	if b, ok := m.buses[name]; ok {
+		b.volume = v
+	}
+}
+
+func (m *Mixer) Muted(name string) bool {
+	if b, ok := m.buses[name]; ok {
+		return b.muted
+	}
+	return false
+}
+
+func (m *Mixer) Mute(name string, muted bool) {
+	if b, ok := m.buses[name]; ok {
+		b.muted = muted
+	}
+}
+
+// SetDucking sets the factor the music bus is lowered to while a voice plays
+// and how fast the factor changes per second.
+func (m *Mixer) SetDucking(volume, speed float64) {
+	m.duckVolume = volume
+	m.duckSpeed = speed
+}
+
+// effective returns the volume of a bus including the master bus and ducking.
+func (m *Mixer) effective(name string) float64 {
+	b, ok := m.buses[name]
+	if !ok || b.muted {
+		return 0
+	}
+	master := m.buses[BusMaster]
+	if master.muted {
+		return 0
+	}
+	v := b.volume * master.volume
+	if name == BusMusic {
+		v *= m.duck
+	}
+	return v
+}
+
+func (m *Mixer) update(voicePlaying bool) {
+	target := 1.0
+	if voicePlaying {
+		target = m.duckVolume
+	}
+	step := m.duckSpeed * float64(G.Dt())
+	if m.duck < target {
+		m.duck = min(target, m.duck+step)
+	} else if m.duck > target {
+		m.duck = max(target, m.duck-step)
+	}
+}
+
+type mixerSettings struct {
+	Buses map[string]busSettings `json:"buses"`
+}
+
+type busSettings struct {
+	Volume float64 `json:"volume"`
+	Muted  bool    `json:"muted"`
+}
+
+// Save writes the volume settings of all buses to the mixer settings file.
+func (m *Mixer) Save() error {
+	s := mixerSettings{Buses: map[string]busSettings{}}
+	for name, b := range m.buses {
+		s.Buses[name] = busSettings{Volume: b.volume, Muted: b.muted}
+	}
+	raw, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+	fpath, err := mixerSettingsFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fpath), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(fpath, raw, 0o644)
+}
+
+// Load reads the volume settings of all buses from the mixer settings file.
+// A missing file is not an error, the buses keep their volumes. Settings of buses
+// the game does not define (anymore) are skipped, so an outdated file cannot stop the game.
+func (m *Mixer) Load() error {
+	fpath, err := mixerSettingsFile()
+	if err != nil {
+		// Without a config directory there are no saved settings.
+		return nil
+	}
+	raw, err := os.ReadFile(fpath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s := mixerSettings{}
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return err
+	}
+	for name, bs := range s.Buses {
+		b, ok := m.buses[name]
+		if !ok {
+			continue
+		}
+		b.volume = bs.Volume
+		b.muted = bs.Muted
+	}
+	return nil
+}
+
+func (m *Mixer) debugInfo() string {
+	names := make([]string, 0, len(m.buses))
+	for name := range m.buses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sb := strings.Builder{}
+	sb.WriteString("mixer:\n")
+	for _, name := range names {
+		b := m.buses[name]
+		fmt.Fprintf(&sb, "  %-6s %.2f", name, b.volume)
+		if b.muted {
+			sb.WriteString(" muted")
+		}
+		sb.WriteString("\n")
+	}
+	fmt.Fprintf(&sb, "  duck   %.2f", m.duck)
+	return sb.String()
+}
+
+// SetMixerSettingsFile sets the file the mixer settings are saved to and loaded from.
+// By default this is mixer.json in a directory named after the executable in the user's config directory.
+func SetMixerSettingsFile(fpath string) {
+	mixerSettingsPath = fpath
+}
+
+func mixerSettingsFile() (string, error) {
+	if mixerSettingsPath != "" {
+		return mixerSettingsPath, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	name := strings.TrimSuffix(filepath.Base(exe), filepath.Ext(exe))
+	return filepath.Join(dir, name, "mixer.json"), nil
+}
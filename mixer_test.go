@@ -0,0 +1,90 @@
+package vigor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMixerEffectiveVolume(t *testing.T) {
+	m := newMixer()
+	m.SetVolume(BusMaster, 0.5)
+	m.SetVolume(BusSfx, 0.5)
+	assert.Equal(t, 0.25, m.effective(BusSfx))
+	assert.Equal(t, 0.5, m.effective(BusUI))
+
+	m.Mute(BusSfx, true)
+	assert.Equal(t, 0.0, m.effective(BusSfx))
+	assert.Equal(t, 0.5, m.effective(BusUI))
+
+	m.Mute(BusMaster, true)
+	assert.Equal(t, 0.0, m.effective(BusUI))
+
+	assert.Equal(t, 0.0, m.effective("unknown"))
+}
+
+func TestMixerDucking(t *testing.T) {
+	tps, dt := G.tps, G.dt
+	t.Cleanup(func() { G.tps, G.dt = tps, dt })
+	G.SetTPS(10)
+	m := newMixer()
+	m.SetDucking(0.2, 2)
+
+	m.update(true)
+	assert.InDelta(t, 0.8, m.effective(BusMusic), 1e-6)
+	for i := 0; i < 10; i++ {
+		m.update(true)
+	}
+	assert.InDelta(t, 0.2, m.effective(BusMusic), 1e-6)
+	assert.InDelta(t, 1, m.effective(BusSfx), 1e-6)
+
+	for i := 0; i < 10; i++ {
+		m.update(false)
+	}
+	assert.InDelta(t, 1, m.effective(BusMusic), 1e-6)
+}
+
+func TestMixerSaveLoad(t *testing.T) {
+	defer SetMixerSettingsFile(mixerSettingsPath)
+	SetMixerSettingsFile(filepath.Join(t.TempDir(), "mixer.json"))
+
+	m := newMixer()
+	assert.NoError(t, m.Load())
+	m.SetVolume(BusMusic, 0.7)
+	m.Mute(BusVoice, true)
+	assert.NoError(t, m.Save())
+
+	loaded := newMixer()
+	assert.NoError(t, loaded.Load())
+	assert.Equal(t, 0.7, loaded.Volume(BusMusic))
+	assert.True(t, loaded.Muted(BusVoice))
+	assert.Equal(t, 1.0, loaded.Volume(BusSfx))
+
+	// Unknown buses of outdated settings are skipped.
+	assert.NoError(t, os.WriteFile(mixerSettingsPath, []byte(`{"buses": {"ambience": {"volume": 0.1}, "sfx": {"volume": 0.3}}}`), 0o644))
+	loaded = newMixer()
+	assert.NoError(t, loaded.Load())
+	assert.Equal(t, 0.3, loaded.Volume(BusSfx))
+}
+
+func TestMixerSettingsFile(t *testing.T) {
+	defer SetMixerSettingsFile(mixerSettingsPath)
+	SetMixerSettingsFile("")
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	fpath, err := mixerSettingsFile()
+	assert.NoError(t, err)
+	dir, _ := os.UserConfigDir()
+	assert.Equal(t, dir, filepath.Dir(filepath.Dir(fpath)))
+	assert.Equal(t, "mixer.json", filepath.Base(fpath))
+
+	m := newMixer()
+	m.SetVolume(BusMusic, 0.4)
+	assert.NoError(t, m.Save())
+	loaded := newMixer()
+	assert.NoError(t, loaded.Load())
+	assert.Equal(t, 0.4, loaded.Volume(BusMusic))
+}
@@ -0,0 +1,410 @@
+package vigor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/dbriemann/vigor/config"
+	"github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+var (
+	ErrUnknownMusic = fmt.Errorf("unknown music track")
+	ErrInvalidLoop  = config.ErrInvalidLoop
+)
+
+// MusicTrack describes a music file that is streamed from disk when played.
+// Loop points are sample offsets at the audio sample rate of the game.
+type MusicTrack struct {
+	path      string
+	volume    float64
+	loopStart int64
+	loopEnd   int64
+	looped    bool
+}
+
+type musicStream struct {
+	player *audio.Player
+	file   *os.File
+	track  *MusicTrack
+	name   string
+	length time.Duration
+	// queued is set if the stream was started by the playlist, which then continues when it ends.
+	queued bool
+}
+
+// musicLength returns the length of a music file in samples.
+func musicLength(fpath string) (int64, error) {
+	f, err := os.Open(fpath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	stream, err := decodeAudio(fpath, f)
+	if err != nil {
+		return 0, err
+	}
+	return stream.Length() / bytesPerFrame, nil
+}
+
+// loadMusicAssets checks the music tracks of a config. Music is streamed when played, so only the existence
+// of the file and the loop points are checked.
+func loadMusicAssets(ctx *AssetContext, raw json.RawMessage) (map[string]any, error) {
+	cfg := map[string]MusicConfig{}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, err
+	}
+	tracks := map[string]*MusicTrack{}
+	for name, mus := range cfg {
+		fpath := path.Join(ctx.Root, mus.Path)
+		if _, err := os.Stat(fpath); err != nil {
+			return nil, err
+		}
+		length := int64(0)
+		if mus.Looped && (mus.LoopStart > 0 || mus.LoopEnd > 0) {
+			var err error
+			if length, err = musicLength(fpath); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		if err := mus.CheckLoop(length); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if mus.Volume == 0 {
+			mus.Volume = 1
+		}
+		tracks[name] = &MusicTrack{
+			path:      fpath,
+			volume:    mus.Volume,
+			loopStart: mus.LoopStart,
+			loopEnd:   mus.LoopEnd,
+			looped:    mus.Looped,
+		}
+	}
+	return toAssets(tracks), nil
+}
+
+func openMusicStream(ctx *audio.Context, name string, track *MusicTrack) (*musicStream, error) {
+	f, err := os.Open(track.path)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := decodeAudio(track.path, f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	var src io.Reader = stream
+	if track.looped {
+		end := track.loopEnd * bytesPerFrame
+		if end <= 0 || end > stream.Length() {
+			end = stream.Length()
+		}
+		start := track.loopStart * bytesPerFrame
+		src = audio.NewInfiniteLoopWithIntro(stream, start, end-start)
+	}
+
+	p, err := ctx.NewPlayer(src)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	s := &musicStream{
+		player: p,
+		file:   f,
+		track:  track,
+		name:   name,
+		length: time.Duration(stream.Length()/bytesPerFrame) * time.Second / time.Duration(audioSampleRate),
+	}
+	return s, nil
+}
+
+// ending reports whether a track that is not looped is within fade seconds of its end.
+func (s *musicStream) ending(fade float32) bool {
+	if s.track.looped {
+		return false
+	}
+	return !s.player.IsPlaying() || s.length-s.player.Position() <= time.Duration(fade*float32(time.Second))
+}
+
+func (s *musicStream) setVolume(v float64) {
+	s.player.SetVolume(v * s.track.volume)
+}
+
+func (s *musicStream) close() {
+	s.player.Pause()
+	s.player.Close()
+	s.file.Close()
+}
+
+// playlist is the play order of the tracks of a MusicPlayer.
+type playlist struct {
+	names []string
+	// order holds the names in the order of the current pass, pos is the index of the current track in it.
+	order   []string
+	pos     int
+	shuffle bool
+	looped  bool
+}
+
+func (l *playlist) set(names []string) {
+	l.names = slices.Clone(names)
+	l.order = nil
+	l.pos = -1
+}
+
+// add appends tracks to the playlist and to the current pass.
+func (l *playlist) add(names ...string) {
+	l.names = append(l.names, names...)
+	if l.order != nil {
+		l.order = append(l.order, names...)
+	}
+}
+
+// next advances to the next track. At the end of a pass, a new one starts if the playlist is looped.
+func (l *playlist) next() (string, bool) {
+	if l.pos+1 >= len(l.order) {
+		if len(l.names) == 0 || l.order != nil && !l.looped {
+			return "", false
+		}
+		l.newPass()
+	}
+	l.pos++
+	return l.order[l.pos], true
+}
+
+func (l *playlist) newPass() {
+	last := ""
+	if len(l.order) > 0 {
+		last = l.order[len(l.order)-1]
+	}
+	l.order = slices.Clone(l.names)
+	l.pos = -1
+	if !l.shuffle {
+		return
+	}
+	rand.Shuffle(len(l.order), func(i, j int) {
+		l.order[i], l.order[j] = l.order[j], l.order[i]
+	})
+	// A shuffled pass does not start with the track that ended the one before.
+	if len(l.order) > 1 && l.order[0] == last {
+		l.order[0], l.order[len(l.order)-1] = l.order[len(l.order)-1], l.order[0]
+	}
+}
+
+// musicFade is a replaced track that fades out from the gain it had when it was replaced.
+type musicFade struct {
+	stream   *musicStream
+	gain     float64
+	fadeTime float32
+	fadeDur  float32
+}
+
+func (f *musicFade) done() bool {
+	return f.fadeTime >= f.fadeDur
+}
+
+func (f *musicFade) currentGain() float64 {
+	if f.done() {
+		return 0
+	}
+	return f.gain * float64(1-f.fadeTime/f.fadeDur)
+}
+
+// MusicPlayer streams one music track at a time and crossfades between tracks.
+// Its volume is controlled by the music bus of the mixer.
+// It also plays playlists, where every track that is not looped crossfades into the next one.
+type MusicPlayer struct {
+	current *musicStream
+	// fadeTime and fadeDur are the fade in of the current track.
+	fadeTime float32
+	fadeDur  float32
+	// fading holds the replaced tracks that are still fading out.
+	fading []*musicFade
+	list   playlist
+	// crossfade is the duration in seconds the tracks of the playlist are crossfaded over.
+	crossfade float32
+}
+
+// Play starts the music track with the given name and ends the playlist. A currently playing track is
+// crossfaded over the given duration in seconds. Playing the current track again does nothing.
+func (m *MusicPlayer) Play(name string, crossfade float32) error {
+	if m.current != nil && m.current.name == name {
+		m.current.queued = false
+		m.list.set(nil)
+		return nil
+	}
+	if err := m.start(name, crossfade); err != nil {
+		return err
+	}
+	m.list.set(nil)
+	return nil
+}
+
+// PlayPlaylist plays the tracks one after another, crossfading over the given duration in seconds.
+// Looped tracks play until Next is called.
+func (m *MusicPlayer) PlayPlaylist(names []string, crossfade float32) error {
+	if err := checkMusic(names); err != nil {
+		return err
+	}
+	m.list.set(names)
+	m.crossfade = crossfade
+	return m.Next()
+}
+
+// Enqueue adds tracks to the end of the playlist. If the playlist has ended, the first of them starts.
+func (m *MusicPlayer) Enqueue(names ...string) error {
+	if err := checkMusic(names); err != nil {
+		return err
+	}
+	m.list.add(names...)
+	if m.current == nil || !m.current.queued {
+		return m.Next()
+	}
+	return nil
+}
+
+// Next crossfades to the next track of the playlist. After the last track the music fades out,
+// unless the playlist is looped.
+func (m *MusicPlayer) Next() error {
+	name, ok := m.list.next()
+	if !ok {
+		m.fadeOut(m.crossfade)
+		return nil
+	}
+	if err := m.start(name, m.crossfade); err != nil {
+		return err
+	}
+	m.current.queued = true
+	return nil
+}
+
+// SetShuffle sets whether every pass over the playlist plays its tracks in random order.
+// It takes effect when the next pass starts.
+func (m *MusicPlayer) SetShuffle(shuffle bool) {
+	m.list.shuffle = shuffle
+}
+
+// SetPlaylistLooped sets whether the playlist starts over after its last track.
+func (m *MusicPlayer) SetPlaylistLooped(looped bool) {
+	m.list.looped = looped
+}
+
+// Playlist returns the names of the tracks of the playlist.
+func (m *MusicPlayer) Playlist() []string {
+	return slices.Clone(m.list.names)
+}
+
+func checkMusic(names []string) error {
+	for _, name := range names {
+		if _, ok := G.assets.Music[name]; !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownMusic, name)
+		}
+	}
+	return nil
+}
+
+// start crossfades to a track, even if it is the current one.
+func (m *MusicPlayer) start(name string, crossfade float32) error {
+	track, ok := G.assets.Music[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownMusic, name)
+	}
+	s, err := openMusicStream(G.audio.context, name, track)
+	if err != nil {
+		return err
+	}
+
+	m.retire(crossfade)
+	m.current = s
+	m.fadeTime = 0
+	m.fadeDur = crossfade
+
+	m.applyVolume()
+	m.current.player.Play()
+	return nil
+}
+
+// Stop fades out the current track over the given duration in seconds and ends the playlist.
+func (m *MusicPlayer) Stop(fade float32) {
+	m.list.set(nil)
+	m.fadeOut(fade)
+}
+
+func (m *MusicPlayer) fadeOut(fade float32) {
+	m.retire(fade)
+	m.applyVolume()
+}
+
+// retire fades out the current track from its current gain. Tracks that are still fading out from earlier
+// changes keep fading.
+func (m *MusicPlayer) retire(fade float32) {
+	if m.current == nil {
+		return
+	}
+	m.fading = append(m.fading, &musicFade{stream: m.current, gain: m.gain(), fadeDur: fade})
+	m.current = nil
+}
+
+// gain returns the fade in of the current track.
+func (m *MusicPlayer) gain() float64 {
+	if m.fadeTime >= m.fadeDur {
+		return 1
+	}
+	return float64(m.fadeTime / m.fadeDur)
+}
+
+// Playing returns the name of the current track or an empty string.
+func (m *MusicPlayer) Playing() string {
+	if m.current == nil {
+		return ""
+	}
+	return m.current.name
+}
+
+func (m *MusicPlayer) applyVolume() {
+	volume := G.mixer.effective(BusMusic)
+	if m.current != nil {
+		m.current.setVolume(volume * m.gain())
+	}
+	for _, f := range m.fading {
+		f.stream.setVolume(volume * f.currentGain())
+	}
+}
+
+func (m *MusicPlayer) update() {
+	if m.fadeTime < m.fadeDur {
+		m.fadeTime += G.Dt()
+	}
+	fading := m.fading[:0]
+	for _, f := range m.fading {
+		f.fadeTime += G.Dt()
+		if f.done() {
+			f.stream.close()
+			continue
+		}
+		fading = append(fading, f)
+	}
+	clear(m.fading[len(fading):])
+	m.fading = fading
+	if m.current != nil && m.current.queued && m.current.ending(m.crossfade) {
+		// A track of the playlist that cannot be opened ends the playlist.
+		if err := m.Next(); err != nil {
+			m.Stop(m.crossfade)
+		}
+	}
+	if m.current != nil && !m.current.player.IsPlaying() {
+		// A track that is not looped has ended.
+		m.current.close()
+		m.current = nil
+	}
+	m.applyVolume()
+}
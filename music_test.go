@@ -0,0 +1,144 @@
+package vigor
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlaylist(t *testing.T) {
+	l := playlist{}
+	l.set([]string{"a", "b", "c"})
+	played := []string{}
+	for name, ok := l.next(); ok; name, ok = l.next() {
+		played = append(played, name)
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, played)
+
+	// Tracks added after the end are played next.
+	l.add("d")
+	name, ok := l.next()
+	assert.True(t, ok)
+	assert.Equal(t, "d", name)
+	_, ok = l.next()
+	assert.False(t, ok)
+
+	l.set([]string{"a", "b"})
+	l.looped = true
+	played = []string{}
+	for i := 0; i < 5; i++ {
+		name, _ := l.next()
+		played = append(played, name)
+	}
+	assert.Equal(t, []string{"a", "b", "a", "b", "a"}, played)
+
+	l.set([]string{"a", "b", "c", "d"})
+	l.shuffle = true
+	last := ""
+	for pass := 0; pass < 20; pass++ {
+		played = []string{}
+		for i := 0; i < 4; i++ {
+			name, _ := l.next()
+			played = append(played, name)
+		}
+		assert.ElementsMatch(t, []string{"a", "b", "c", "d"}, played)
+		assert.NotEqual(t, last, played[0])
+		last = played[3]
+	}
+
+	l.set(nil)
+	_, ok = l.next()
+	assert.False(t, ok)
+}
+
+// writeTestWAV writes a silent 16 bit stereo WAV file with the given number of samples.
+func writeTestWAV(t *testing.T, fpath string, samples int) {
+	data := make([]byte, samples*bytesPerFrame)
+	header := make([]byte, 44)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(36+len(data)))
+	copy(header[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], 1)
+	binary.LittleEndian.PutUint16(header[22:], 2)
+	binary.LittleEndian.PutUint32(header[24:], uint32(audioSampleRate))
+	binary.LittleEndian.PutUint32(header[28:], uint32(audioSampleRate*bytesPerFrame))
+	binary.LittleEndian.PutUint16(header[32:], bytesPerFrame)
+	binary.LittleEndian.PutUint16(header[34:], 16)
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], uint32(len(data)))
+	assert.NoError(t, os.WriteFile(fpath, append(header, data...), 0o644))
+}
+
+func TestMusicLoopPoints(t *testing.T) {
+	dir := t.TempDir()
+	writeTestWAV(t, filepath.Join(dir, "theme.wav"), 1000)
+
+	length, err := musicLength(filepath.Join(dir, "theme.wav"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), length)
+
+	testcases := []struct {
+		name  string
+		music string
+		err   error
+	}{
+		{name: "intro", music: `{"path": "theme.wav", "looped": true, "loopStart": 200}`},
+		{name: "loop section", music: `{"path": "theme.wav", "looped": true, "loopStart": 200, "loopEnd": 800}`},
+		{name: "start after track", music: `{"path": "theme.wav", "looped": true, "loopStart": 1000}`, err: ErrInvalidLoop},
+		{name: "reversed loop", music: `{"path": "theme.wav", "looped": true, "loopStart": 800, "loopEnd": 200}`, err: ErrInvalidLoop},
+		{name: "end after track", music: `{"path": "theme.wav", "looped": true, "loopEnd": 1200}`, err: ErrInvalidLoop},
+	}
+
+	for _, tc := range testcases {
+		fpath := filepath.Join(dir, "config.json")
+		assert.NoError(t, os.WriteFile(fpath, []byte(`{"resourceRoot": "`+filepath.ToSlash(dir)+`", "music": {"theme": `+tc.music+`}}`), 0o644))
+		r := NewAssetManager()
+		assert.ErrorIs(t, r.LoadConfig(fpath), tc.err, tc.name)
+	}
+}
+
+func TestMusicCrossfade(t *testing.T) {
+	dir := t.TempDir()
+	defer func(assets AssetManager, audio audioSystem, dt float32) {
+		G.assets, G.audio, G.dt = assets, audio, dt
+	}(G.assets, G.audio, G.dt)
+	G.assets = NewAssetManager()
+	G.audio.init()
+	G.dt = 0.25
+	for _, name := range []string{"a", "b", "c"} {
+		fpath := filepath.Join(dir, name+".wav")
+		writeTestWAV(t, fpath, 1000)
+		G.assets.Music[name] = &MusicTrack{path: fpath, volume: 1, looped: true}
+	}
+
+	m := &MusicPlayer{}
+	assert.NoError(t, m.Play("a", 0))
+	assert.NoError(t, m.Play("b", 1))
+	m.update()
+	m.update()
+	// Changing the track again while a is still fading out does not cut it.
+	assert.NoError(t, m.Play("c", 1))
+	assert.Len(t, m.fading, 2)
+	assert.Equal(t, "a", m.fading[0].stream.name)
+	assert.InDelta(t, 0.5, m.fading[0].currentGain(), 1e-6)
+	// b fades out from the volume it had faded in to.
+	assert.Equal(t, "b", m.fading[1].stream.name)
+	assert.InDelta(t, 0.5, m.fading[1].currentGain(), 1e-6)
+
+	m.update()
+	m.update()
+	assert.Len(t, m.fading, 1)
+	assert.InDelta(t, 0.25, m.fading[0].currentGain(), 1e-6)
+	// A fade out without duration ends the track at the next update.
+	m.Stop(0)
+	assert.Equal(t, "", m.Playing())
+	m.update()
+	assert.Len(t, m.fading, 1)
+	assert.Equal(t, "b", m.fading[0].stream.name)
+	m.update()
+	assert.Empty(t, m.fading)
+}
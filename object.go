@@ -15,9 +15,22 @@ type Object struct {
 	accel          Vec2[float32]
 	dim            Vec2[uint32]
 	id             uint64
+	touching       Touch
 	motionDisabled bool
+	// collided is set by the first collision check after an update, which clears touching.
+	collided bool
 }
 
+// Touch is a set of sides an object touched something on during the last collision checks.
+type Touch uint8
+
+const (
+	TouchFloor Touch = 1 << iota
+	TouchCeiling
+	TouchWallLeft
+	TouchWallRight
+)
+
 func NewObject() (o Object) {
 	G.idcounter++
 	o.id = G.createId()
@@ -84,7 +97,28 @@ func (o *Object) Dim() *Vec2[uint32] {
 	return &o.dim
 }
 
+// Touching returns the sides the object was pushed out of something on during the last collision checks.
+// The flags are cleared by the first collision check after an update, so they can still be read in the update,
+// for example to let an object jump only when it stands on the floor.
+func (o *Object) Touching() Touch {
+	return o.touching
+}
+
+// IsTouching reports whether the object touches something on any of the given sides.
+func (o *Object) IsTouching(t Touch) bool {
+	return o.touching&t != 0
+}
+
+// collisionObject is implemented by everything that embeds an Object.
+func (o *Object) collisionObject() *Object {
+	return o
+}
+
 func (o *Object) Update() {
+	// The position before moving is used to find out from which side a collision happens.
+	o.lastPos = o.pos
+	o.collided = false
+
 	if o.motionDisabled {
 		return
 	}
@@ -0,0 +1,357 @@
+package vigor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/colorm"
+)
+
+var ErrUnknownIcon = fmt.Errorf("icon is neither an image nor an animation")
+
+var _ effected = (*RichText)(nil)
+
+const (
+	// richAmplitude is the maximum displacement in pixels of waving and shaking glyphs.
+	richAmplitude = 2
+	richWaveSpeed = 8
+	// richRainbowSpeed is the number of hue cycles per second.
+	richRainbowSpeed = 0.5
+)
+
+// RichSpan is a tagged part of a RichText. Bounds contains one rectangle per line the span covers,
+// relative to the top left corner of the text.
+type RichSpan struct {
+	Tag    string
+	Value  string
+	Bounds []image.Rectangle
+}
+
+// richIcon is either a static image or an animation from the asset manager.
+type richIcon struct {
+	image *ebiten.Image
+	anim  *Animation
+}
+
+func (i *richIcon) current() *ebiten.Image {
+	if i.anim != nil {
+		return i.anim.Images[i.anim.Frame]
+	}
+	return i.image
+}
+
+// RichText is a stageable that renders markup like "[color=red]danger[/color] press [icon=button_a]".
+// Supported tags:
+//   - [color=name] or [color=#rrggbb] colors the enclosed text.
+//   - [wave], [shake] and [rainbow] animate the enclosed characters.
+//   - [icon=name] inserts an image or animation from the asset manager.
+//   - [span=name] only marks a part of the text so its bounds can be queried with Spans.
+//
+// The text can be revealed character by character with SetTypewriter.
+type RichText struct {
+	effects     []Effect
+	font        Font
+	image       *ebiten.Image
+	icons       map[string]*richIcon
+	markup      string
+	glyphs      []richGlyph
+	spans       []markupSpan
+	placed      []placedGlyph
+	lineWidths  []int
+	color       color.Color
+	wrapWidth   int
+	lineSpacing int
+	align       Align
+	time        float32
+	revealSpeed float32
+	revealed    float32
+	// animated is set if characters are animated by effects, so the text is rendered again every frame.
+	animated bool
+	dirty    bool
+
+	visual
+	Object
+}
+
+func NewRichText(fontName, markup string) (*RichText, error) {
+	t := &RichText{
+		Object:  NewObject(),
+		visual:  newVisual(),
+		effects: []Effect{},
+
+		font:  G.assets.GetFontOrPanic(fontName),
+		color: color.White,
+	}
+	if err := t.SetMarkup(markup); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *RichText) Markup() string {
+	return t.markup
+}
+
+// SetMarkup replaces the content and restarts the typewriter.
+func (t *RichText) SetMarkup(markup string) error {
+	glyphs, spans, err := parseMarkup(markup)
+	if err != nil {
+		return err
+	}
+
+	icons := map[string]*richIcon{}
+	for _, g := range glyphs {
+		if g.icon == "" || icons[g.icon] != nil {
+			continue
+		}
+		if img, ok := G.assets.Images[g.icon]; ok {
+			icons[g.icon] = &richIcon{image: img}
+			continue
+		}
+		template, ok := G.assets.AnimationTemplates[g.icon]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownIcon, g.icon)
+		}
+		anim, err := NewAnimation(template)
+		if err != nil {
+			return err
+		}
+		anim.Run()
+		icons[g.icon] = &richIcon{anim: anim}
+	}
+
+	t.markup = markup
+	t.glyphs = glyphs
+	t.spans = spans
+	t.icons = icons
+	t.revealed = 0
+	t.relayout()
+
+	return nil
+}
+
+func (t *RichText) SetFont(f Font) {
+	t.font = f
+	t.relayout()
+}
+
+// SetColor sets the color of all text that is not inside a color tag.
+func (t *RichText) SetColor(c color.Color) {
+	t.color = c
+	t.dirty = true
+}
+
+func (t *RichText) SetAlign(a Align) {
+	t.align = a
+	t.dirty = true
+}
+
+// SetWrapWidth sets the width in pixels at which lines are wrapped between words.
+// Zero disables wrapping.
+func (t *RichText) SetWrapWidth(w int) {
+	t.wrapWidth = w
+	t.relayout()
+}
+
+// SetLineSpacing sets the additional space in pixels between two lines.
+func (t *RichText) SetLineSpacing(s int) {
+	t.lineSpacing = s
+	t.relayout()
+}
+
+// SetTypewriter reveals the text with the given characters per second, starting from the first one.
+// Zero shows the whole text at once.
+func (t *RichText) SetTypewriter(charsPerSecond float32) {
+	t.revealSpeed = charsPerSecond
+	t.revealed = 0
+	t.dirty = true
+}
+
+// SkipTypewriter reveals the remaining text at once.
+func (t *RichText) SkipTypewriter() {
+	t.revealed = float32(len(t.glyphs))
+	t.dirty = true
+}
+
+// Revealed reports whether the typewriter has revealed all characters.
+func (t *RichText) Revealed() bool {
+	return t.visibleGlyphs() == len(t.glyphs)
+}
+
+func (t *RichText) visibleGlyphs() int {
+	if t.revealSpeed <= 0 {
+		return len(t.glyphs)
+	}
+	return min(int(t.revealed), len(t.glyphs))
+}
+
+// Spans returns the tagged parts of the text in the order they were opened.
+func (t *RichText) Spans() []RichSpan {
+	offsets := t.lineOffsets()
+	stride := t.font.LineHeight() + t.lineSpacing
+	spans := []RichSpan{}
+	for _, s := range t.spans {
+		spans = append(spans, RichSpan{
+			Tag:    s.tag,
+			Value:  s.value,
+			Bounds: spanRects(t.placed, offsets, s.start, s.end, t.font.LineHeight(), stride),
+		})
+	}
+	return spans
+}
+
+func (t *RichText) iconWidth(name string) int {
+	return t.icons[name].current().Bounds().Dx()
+}
+
+func (t *RichText) relayout() {
+	t.placed, t.lineWidths = layoutRich(t.font, t.glyphs, t.iconWidth, t.wrapWidth)
+
+	width := t.wrapWidth
+	for _, w := range t.lineWidths {
+		width = max(width, w)
+	}
+	lines := len(t.lineWidths)
+	height := lines*t.font.LineHeight() + (lines-1)*t.lineSpacing
+	t.SetDim(uint32(width), uint32(height))
+
+	t.animated = false
+	for _, g := range t.glyphs {
+		if g.effects != 0 {
+			t.animated = true
+		}
+	}
+	t.dirty = true
+}
+
+func (t *RichText) lineOffsets() []int {
+	offsets := make([]int, len(t.lineWidths))
+	for i, w := range t.lineWidths {
+		switch t.align {
+		case AlignCenter:
+			offsets[i] = (int(t.Dim().X) - w) / 2
+		case AlignRight:
+			offsets[i] = int(t.Dim().X) - w
+		}
+	}
+	return offsets
+}
+
+func (t *RichText) render() {
+	t.dirty = false
+
+	w := int(t.Dim().X) + 2*richAmplitude
+	h := int(t.Dim().Y) + 2*richAmplitude
+	if t.image != nil && t.image.Bounds().Dx() == w && t.image.Bounds().Dy() == h {
+		t.image.Clear()
+	} else {
+		if t.image != nil {
+			t.image.Dispose()
+		}
+		t.image = ebiten.NewImage(w, h)
+	}
+
+	offsets := t.lineOffsets()
+	stride := t.font.LineHeight() + t.lineSpacing
+	for i, g := range t.glyphs[:t.visibleGlyphs()] {
+		p := t.placed[i]
+		x := richAmplitude + offsets[p.line] + p.x
+		y := richAmplitude + p.line*stride
+
+		if g.effects&richWave != 0 {
+			y += int(math.Round(math.Sin(float64(t.time*richWaveSpeed)+float64(i)*0.6) * richAmplitude))
+		}
+		if g.effects&richShake != 0 {
+			x += rand.Intn(2*richAmplitude+1) - richAmplitude
+			y += rand.Intn(2*richAmplitude+1) - richAmplitude
+		}
+
+		if g.icon != "" {
+			img := t.icons[g.icon].current()
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(float64(x), float64(y+(t.font.LineHeight()-img.Bounds().Dy())/2))
+			t.image.DrawImage(img, op)
+			continue
+		}
+
+		clr := t.color
+		if g.color != nil {
+			clr = g.color
+		}
+		if g.effects&richRainbow != 0 {
+			hue := float64(t.time*richRainbowSpeed) + float64(i)*0.08
+			clr = hueColor(hue - math.Floor(hue))
+		}
+		t.font.drawGlyph(t.image, g.r, x, y, clr)
+	}
+}
+
+// hueColor returns the fully saturated color with hue h in [0, 1).
+func hueColor(h float64) color.Color {
+	channel := func(offset float64) uint8 {
+		k := math.Mod(offset+h*6, 6)
+		v := 1 - math.Max(0, math.Min(1, math.Min(k, 4-k)))
+		return uint8(math.Round(v * 0xff))
+	}
+	return color.RGBA{R: channel(5), G: channel(3), B: channel(1), A: 0xff}
+}
+
+func (t *RichText) ApplyEffect(e Effect) {
+	e.Reset()
+	e.Start()
+	t.effects = append(t.effects, e)
+}
+
+func (t *RichText) Update() {
+	t.Object.Update()
+	for j := 0; j < len(t.effects); j++ {
+		finished := t.effects[j].Update()
+		if finished {
+			t.effects = append(t.effects[:j], t.effects[j+1:]...)
+		}
+	}
+
+	t.time += G.Dt()
+	for _, icon := range t.icons {
+		if icon.anim != nil {
+			// Animated icons only need a new render when they show another frame.
+			frame := icon.anim.Frame
+			icon.anim.Update(G.Dt())
+			if icon.anim.Frame != frame {
+				t.dirty = true
+			}
+		}
+	}
+	if !t.Revealed() {
+		visible := t.visibleGlyphs()
+		t.revealed += t.revealSpeed * G.Dt()
+		if t.visibleGlyphs() != visible {
+			t.dirty = true
+		}
+	}
+	if t.animated {
+		t.dirty = true
+	}
+}
+
+func (t *RichText) draw(target *ebiten.Image, op colorm.DrawImageOptions) {
+	if t.dirty {
+		t.render()
+	}
+
+	cm := colorm.ColorM{}
+	t.transform(&op, t.image.Bounds().Dx(), t.image.Bounds().Dy())
+	op.GeoM.Translate(float64(t.PixelPos().X-richAmplitude), float64(t.PixelPos().Y-richAmplitude))
+	for i := 0; i < len(t.effects); i++ {
+		t.effects[i].modifyDraw(&op)
+	}
+	colorm.DrawImage(target, t.image, cm, &op)
+	for i := 0; i < len(t.effects); i++ {
+		t.effects[i].draw(target, op)
+	}
+}
@@ -1,48 +1,43 @@
 package vigor
 
 import (
-	"encoding/json"
-	"os"
-)
-
-func loadConfigData[T any](fpath string) (T, error) {
-	var t T
-
-	raw, err := os.ReadFile(fpath)
-	if err != nil {
-		return t, err
-	}
-	if err := json.Unmarshal(raw, &t); err != nil {
-		return t, err
-	}
+	"sort"
 
-	return t, nil
-}
+	"github.com/dbriemann/vigor/config"
+)
 
-type ResourceConfig struct {
-	// TODO: audio
-	// TODO: others
-	Images       map[string]string          `json:"images"`
-	Sections     map[string]SectionConfig   `json:"sections"`
-	Animations   map[string]AnimationConfig `json:"animations"`
-	ResourceRoot string                     `json:"resourceRoot"`
-}
+// The config structs are defined in package config, which does not depend on Ebitengine.
+type (
+	ResourceConfig        = config.ResourceConfig
+	AnimationConfig       = config.AnimationConfig
+	RectConfig            = config.RectConfig
+	PointConfig           = config.PointConfig
+	AtlasConfig           = config.AtlasConfig
+	AsepriteConfig        = config.AsepriteConfig
+	TexturePackerConfig   = config.TexturePackerConfig
+	PrefixAnimationConfig = config.PrefixAnimationConfig
+	TilemapConfig         = config.TilemapConfig
+	LocaleConfig          = config.LocaleConfig
+	StateMachineConfig    = config.StateMachineConfig
+	TransitionConfig      = config.TransitionConfig
+	SectionConfig         = config.SectionConfig
+	SoundConfig           = config.SoundConfig
+	MusicConfig           = config.MusicConfig
+	SynthConfig           = config.SynthConfig
+	FontConfig            = config.FontConfig
+	BitmapFontConfig      = config.BitmapFontConfig
+)
 
-type AnimationConfig struct {
-	ImageName   string  `json:"imageName"`
-	SectionName string  `json:"sectionName"`
-	EaseFunc    string  `json:"easeFunc"`
-	Frames      []int   `json:"frames"`
-	Duration    float64 `json:"duration"`
-	Width       int     `json:"width"`
-	Height      int     `json:"height"`
-	Looped      bool    `json:"looped"`
-}
+var (
+	ErrIncludeCycle   = config.ErrIncludeCycle
+	ErrInvalidInclude = config.ErrInvalidInclude
+)
 
-type SectionConfig struct {
-	Left    int `json:"left"`
-	Top     int `json:"top"`
-	Width   int `json:"width"`
-	Height  int `json:"height"`
-	Padding int `json:"padding"`
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -0,0 +1,573 @@
+package vigor
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/dbriemann/vigor/config"
+)
+
+var (
+	ErrUnknownSfxrPreset = config.ErrUnknownSfxrPreset
+	ErrUnknownSfxrWave   = config.ErrUnknownSfxrWave
+	ErrUnknownSfxrParam  = config.ErrUnknownSfxrParam
+)
+
+// sfxrSampleRate is the fixed rate the synthesizer generates samples at.
+const sfxrSampleRate = 44100
+
+type SfxrWave int
+
+const (
+	SfxrSquare SfxrWave = iota
+	SfxrSawtooth
+	SfxrSine
+	SfxrNoise
+)
+
+// SfxrParams is a parameter set for the retro sound effect synthesizer.
+// All values are normalized like in the original sfxr: most range from 0 to 1,
+// ramps and slides from -1 to 1.
+type SfxrParams struct {
+	Wave SfxrWave
+
+	// Envelope
+	Attack  float64
+	Sustain float64
+	Punch   float64
+	Decay   float64
+
+	// Frequency and slide
+	BaseFreq    float64
+	FreqLimit   float64
+	FreqRamp    float64
+	FreqDRamp   float64
+	VibStrength float64
+	VibSpeed    float64
+	ArpMod      float64
+	ArpSpeed    float64
+
+	// Square wave duty cycle
+	Duty     float64
+	DutyRamp float64
+
+	RepeatSpeed float64
+
+	PhaserOffset float64
+	PhaserRamp   float64
+
+	LPFFreq      float64
+	LPFRamp      float64
+	LPFResonance float64
+	HPFFreq      float64
+	HPFRamp      float64
+
+	Volume float64
+}
+
+// NewSfxrParams returns the default parameter set, which is a short square beep.
+func NewSfxrParams() SfxrParams {
+	p := SfxrParams{
+		Wave:     SfxrSquare,
+		BaseFreq: 0.3,
+		Sustain:  0.3,
+		Decay:    0.4,
+		LPFFreq:  1,
+		Volume:   0.5,
+	}
+	return p
+}
+
+// SfxrPreset generates a randomized parameter set for the preset with the given name.
+// The same seed always yields the same parameters.
+func SfxrPreset(name string, seed int64) (SfxrParams, error) {
+	f, ok := sfxrPresets[name]
+	if !ok {
+		return SfxrParams{}, fmt.Errorf("%w: %s", ErrUnknownSfxrPreset, name)
+	}
+	r := sfxrRand{rand.New(rand.NewSource(seed))}
+	p := NewSfxrParams()
+	f(&p, r)
+	return p, nil
+}
+
+type sfxrRand struct {
+	*rand.Rand
+}
+
+// frnd returns a random float in [0, limit].
+func (r sfxrRand) frnd(limit float64) float64 {
+	return float64(r.Intn(10001)) / 10000 * limit
+}
+
+// rnd returns a random int in [0, limit].
+func (r sfxrRand) rnd(limit int) int {
+	return r.Intn(limit + 1)
+}
+
+var sfxrPresets = map[string]func(p *SfxrParams, r sfxrRand){
+	"coin": func(p *SfxrParams, r sfxrRand) {
+		p.BaseFreq = 0.4 + r.frnd(0.5)
+		p.Attack = 0
+		p.Sustain = r.frnd(0.1)
+		p.Decay = 0.1 + r.frnd(0.4)
+		p.Punch = 0.3 + r.frnd(0.3)
+		if r.rnd(1) == 1 {
+			p.ArpSpeed = 0.5 + r.frnd(0.2)
+			p.ArpMod = 0.2 + r.frnd(0.4)
+		}
+	},
+	"laser": func(p *SfxrParams, r sfxrRand) {
+		p.Wave = SfxrWave(r.rnd(2))
+		if p.Wave == SfxrSine && r.rnd(1) == 1 {
+			p.Wave = SfxrWave(r.rnd(1))
+		}
+		p.BaseFreq = 0.5 + r.frnd(0.5)
+		p.FreqLimit = max(0.2, p.BaseFreq-0.2-r.frnd(0.6))
+		p.FreqRamp = -0.15 - r.frnd(0.2)
+		if r.rnd(2) == 0 {
+			p.BaseFreq = 0.3 + r.frnd(0.6)
+			p.FreqLimit = r.frnd(0.1)
+			p.FreqRamp = -0.35 - r.frnd(0.3)
+		}
+		if r.rnd(1) == 1 {
+			p.Duty = r.frnd(0.5)
+			p.DutyRamp = r.frnd(0.2)
+		} else {
+			p.Duty = 0.4 + r.frnd(0.5)
+			p.DutyRamp = -r.frnd(0.7)
+		}
+		p.Attack = 0
+		p.Sustain = 0.1 + r.frnd(0.2)
+		p.Decay = r.frnd(0.4)
+		if r.rnd(1) == 1 {
+			p.Punch = r.frnd(0.3)
+		}
+		if r.rnd(2) == 0 {
+			p.PhaserOffset = r.frnd(0.2)
+			p.PhaserRamp = -r.frnd(0.2)
+		}
+		if r.rnd(1) == 1 {
+			p.HPFFreq = r.frnd(0.3)
+		}
+	},
+	"explosion": func(p *SfxrParams, r sfxrRand) {
+		p.Wave = SfxrNoise
+		if r.rnd(1) == 1 {
+			p.BaseFreq = 0.1 + r.frnd(0.4)
+			p.FreqRamp = -0.1 + r.frnd(0.4)
+		} else {
+			p.BaseFreq = 0.2 + r.frnd(0.7)
+			p.FreqRamp = -0.2 - r.frnd(0.2)
+		}
+		p.BaseFreq *= p.BaseFreq
+		if r.rnd(4) == 0 {
+			p.FreqRamp = 0
+		}
+		if r.rnd(2) == 0 {
+			p.RepeatSpeed = 0.3 + r.frnd(0.5)
+		}
+		p.Attack = 0
+		p.Sustain = 0.1 + r.frnd(0.3)
+		p.Decay = r.frnd(0.5)
+		if r.rnd(1) == 0 {
+			p.PhaserOffset = -0.3 + r.frnd(0.9)
+			p.PhaserRamp = -r.frnd(0.3)
+		}
+		p.Punch = 0.2 + r.frnd(0.6)
+		if r.rnd(1) == 1 {
+			p.VibStrength = r.frnd(0.7)
+			p.VibSpeed = r.frnd(0.6)
+		}
+		if r.rnd(2) == 0 {
+			p.ArpSpeed = 0.6 + r.frnd(0.3)
+			p.ArpMod = 0.8 - r.frnd(1.6)
+		}
+	},
+	"powerup": func(p *SfxrParams, r sfxrRand) {
+		if r.rnd(1) == 1 {
+			p.Wave = SfxrSawtooth
+		} else {
+			p.Duty = r.frnd(0.6)
+		}
+		p.BaseFreq = 0.2 + r.frnd(0.3)
+		if r.rnd(1) == 1 {
+			p.FreqRamp = 0.1 + r.frnd(0.4)
+			p.RepeatSpeed = 0.4 + r.frnd(0.4)
+		} else {
+			p.FreqRamp = 0.05 + r.frnd(0.2)
+			if r.rnd(1) == 1 {
+				p.VibStrength = r.frnd(0.7)
+				p.VibSpeed = r.frnd(0.6)
+			}
+		}
+		p.Attack = 0
+		p.Sustain = r.frnd(0.4)
+		p.Decay = 0.1 + r.frnd(0.4)
+	},
+	"hit": func(p *SfxrParams, r sfxrRand) {
+		p.Wave = SfxrWave(r.rnd(2))
+		if p.Wave == SfxrSine {
+			p.Wave = SfxrNoise
+		}
+		if p.Wave == SfxrSquare {
+			p.Duty = r.frnd(0.6)
+		}
+		p.BaseFreq = 0.2 + r.frnd(0.6)
+		p.FreqRamp = -0.3 - r.frnd(0.4)
+		p.Attack = 0
+		p.Sustain = r.frnd(0.1)
+		p.Decay = 0.1 + r.frnd(0.2)
+		if r.rnd(1) == 1 {
+			p.HPFFreq = r.frnd(0.3)
+		}
+	},
+	"jump": func(p *SfxrParams, r sfxrRand) {
+		p.Wave = SfxrSquare
+		p.Duty = r.frnd(0.6)
+		p.BaseFreq = 0.3 + r.frnd(0.3)
+		p.FreqRamp = 0.1 + r.frnd(0.2)
+		p.Attack = 0
+		p.Sustain = 0.1 + r.frnd(0.3)
+		p.Decay = 0.1 + r.frnd(0.2)
+		if r.rnd(1) == 1 {
+			p.HPFFreq = r.frnd(0.3)
+		}
+		if r.rnd(1) == 1 {
+			p.LPFFreq = 1 - r.frnd(0.6)
+		}
+	},
+	"blip": func(p *SfxrParams, r sfxrRand) {
+		p.Wave = SfxrWave(r.rnd(1))
+		if p.Wave == SfxrSquare {
+			p.Duty = r.frnd(0.6)
+		}
+		p.BaseFreq = 0.2 + r.frnd(0.4)
+		p.Attack = 0
+		p.Sustain = 0.1 + r.frnd(0.1)
+		p.Decay = r.frnd(0.2)
+		p.HPFFreq = 0.1
+	},
+}
+
+// Set changes a single parameter by its name as used in the config.
+func (p *SfxrParams) Set(name string, v float64) error {
+	f, ok := p.fields()[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownSfxrParam, name)
+	}
+	*f = v
+	return nil
+}
+
+// fields returns the parameters by their names as used in the config.
+func (p *SfxrParams) fields() map[string]*float64 {
+	return map[string]*float64{
+		"attack":       &p.Attack,
+		"sustain":      &p.Sustain,
+		"punch":        &p.Punch,
+		"decay":        &p.Decay,
+		"baseFreq":     &p.BaseFreq,
+		"freqLimit":    &p.FreqLimit,
+		"freqRamp":     &p.FreqRamp,
+		"freqDRamp":    &p.FreqDRamp,
+		"vibStrength":  &p.VibStrength,
+		"vibSpeed":     &p.VibSpeed,
+		"arpMod":       &p.ArpMod,
+		"arpSpeed":     &p.ArpSpeed,
+		"duty":         &p.Duty,
+		"dutyRamp":     &p.DutyRamp,
+		"repeatSpeed":  &p.RepeatSpeed,
+		"phaserOffset": &p.PhaserOffset,
+		"phaserRamp":   &p.PhaserRamp,
+		"lpfFreq":      &p.LPFFreq,
+		"lpfRamp":      &p.LPFRamp,
+		"lpfResonance": &p.LPFResonance,
+		"hpfFreq":      &p.HPFFreq,
+		"hpfRamp":      &p.HPFRamp,
+		"volume":       &p.Volume,
+	}
+}
+
+// sfxrSynth holds the running state of the synthesizer.
+type sfxrSynth struct {
+	p   SfxrParams
+	rng *rand.Rand
+
+	phase       int
+	fperiod     float64
+	fmaxperiod  float64
+	fslide      float64
+	fdslide     float64
+	period      int
+	squareDuty  float64
+	squareSlide float64
+
+	envStage  int
+	envTime   int
+	envLength [3]int
+	envVol    float64
+
+	fphase       float64
+	fdphase      float64
+	iphase       int
+	phaserBuffer [1024]float64
+	ipp          int
+	noiseBuffer  [32]float64
+
+	fltp    float64
+	fltdp   float64
+	fltw    float64
+	fltwD   float64
+	fltdmp  float64
+	fltphp  float64
+	flthp   float64
+	flthpD  float64
+	vibPh   float64
+	vibSpd  float64
+	vibAmp  float64
+	repTime int
+	repLim  int
+	arpTime int
+	arpLim  int
+	arpMod  float64
+}
+
+func (s *sfxrSynth) reset(restart bool) {
+	p := &s.p
+	if !restart {
+		s.phase = 0
+	}
+	s.fperiod = 100 / (p.BaseFreq*p.BaseFreq + 0.001)
+	s.period = int(s.fperiod)
+	s.fmaxperiod = 100 / (p.FreqLimit*p.FreqLimit + 0.001)
+	s.fslide = 1 - math.Pow(p.FreqRamp, 3)*0.01
+	s.fdslide = -math.Pow(p.FreqDRamp, 3) * 0.000001
+	s.squareDuty = 0.5 - p.Duty*0.5
+	s.squareSlide = -p.DutyRamp * 0.00005
+	if p.ArpMod >= 0 {
+		s.arpMod = 1 - p.ArpMod*p.ArpMod*0.9
+	} else {
+		s.arpMod = 1 + p.ArpMod*p.ArpMod*10
+	}
+	s.arpTime = 0
+	s.arpLim = int((1-p.ArpSpeed)*(1-p.ArpSpeed)*20000 + 32)
+	if p.ArpSpeed == 1 {
+		s.arpLim = 0
+	}
+	if restart {
+		return
+	}
+
+	s.fltp = 0
+	s.fltdp = 0
+	s.fltw = math.Pow(p.LPFFreq, 3) * 0.1
+	s.fltwD = 1 + p.LPFRamp*0.0001
+	s.fltdmp = min(0.8, 5/(1+p.LPFResonance*p.LPFResonance*20)*(0.01+s.fltw))
+	s.fltphp = 0
+	s.flthp = p.HPFFreq * p.HPFFreq * 0.1
+	s.flthpD = 1 + p.HPFRamp*0.0003
+
+	s.vibPh = 0
+	s.vibSpd = p.VibSpeed * p.VibSpeed * 0.01
+	s.vibAmp = p.VibStrength * 0.5
+
+	s.envVol = 0
+	s.envStage = 0
+	s.envTime = 0
+	s.envLength[0] = int(p.Attack * p.Attack * 100000)
+	s.envLength[1] = int(p.Sustain * p.Sustain * 100000)
+	s.envLength[2] = int(p.Decay * p.Decay * 100000)
+
+	s.fphase = math.Copysign(p.PhaserOffset*p.PhaserOffset*1020, p.PhaserOffset)
+	s.fdphase = math.Copysign(p.PhaserRamp*p.PhaserRamp, p.PhaserRamp)
+	s.iphase = int(math.Abs(s.fphase))
+	s.ipp = 0
+	s.phaserBuffer = [1024]float64{}
+	for i := range s.noiseBuffer {
+		s.noiseBuffer[i] = s.rng.Float64()*2 - 1
+	}
+
+	s.repTime = 0
+	s.repLim = int((1-p.RepeatSpeed)*(1-p.RepeatSpeed)*20000 + 32)
+	if p.RepeatSpeed == 0 {
+		s.repLim = 0
+	}
+}
+
+// SfxrSynthesize generates mono samples in the range [-1, 1] at 44100 Hz.
+// The random source is only used for the noise wave.
+func SfxrSynthesize(p SfxrParams, rng *rand.Rand) []float32 {
+	s := &sfxrSynth{p: p, rng: rng}
+	s.reset(false)
+
+	samples := []float32{}
+	for playing := true; playing; {
+		s.repTime++
+		if s.repLim != 0 && s.repTime >= s.repLim {
+			s.repTime = 0
+			s.reset(true)
+		}
+
+		// Frequency slides and arpeggio.
+		s.arpTime++
+		if s.arpLim != 0 && s.arpTime >= s.arpLim {
+			s.arpLim = 0
+			s.fperiod *= s.arpMod
+		}
+		s.fslide += s.fdslide
+		s.fperiod *= s.fslide
+		if s.fperiod > s.fmaxperiod {
+			s.fperiod = s.fmaxperiod
+			if p.FreqLimit > 0 {
+				playing = false
+			}
+		}
+		rfperiod := s.fperiod
+		if s.vibAmp > 0 {
+			s.vibPh += s.vibSpd
+			rfperiod = s.fperiod * (1 + math.Sin(s.vibPh)*s.vibAmp)
+		}
+		s.period = max(8, int(rfperiod))
+		s.squareDuty = max(0, min(0.5, s.squareDuty+s.squareSlide))
+
+		// Volume envelope.
+		s.envTime++
+		if s.envTime > s.envLength[s.envStage] {
+			s.envTime = 0
+			s.envStage++
+			if s.envStage == 3 {
+				break
+			}
+		}
+		t := float64(s.envTime) / float64(max(1, s.envLength[s.envStage]))
+		switch s.envStage {
+		case 0:
+			s.envVol = t
+		case 1:
+			s.envVol = 1 + (1-t)*2*p.Punch
+		case 2:
+			s.envVol = 1 - t
+		}
+
+		// Phaser and high pass filter sweeps.
+		s.fphase += s.fdphase
+		s.iphase = min(1023, int(math.Abs(s.fphase)))
+		if s.flthpD != 0 {
+			s.flthp = max(0.00001, min(0.1, s.flthp*s.flthpD))
+		}
+
+		// 8x supersampling.
+		ssample := 0.0
+		for si := 0; si < 8; si++ {
+			sample := 0.0
+			s.phase++
+			if s.phase >= s.period {
+				s.phase %= s.period
+				if p.Wave == SfxrNoise {
+					for i := range s.noiseBuffer {
+						s.noiseBuffer[i] = s.rng.Float64()*2 - 1
+					}
+				}
+			}
+
+			fp := float64(s.phase) / float64(s.period)
+			switch p.Wave {
+			case SfxrSquare:
+				sample = -0.5
+				if fp < s.squareDuty {
+					sample = 0.5
+				}
+			case SfxrSawtooth:
+				sample = 1 - fp*2
+			case SfxrSine:
+				sample = math.Sin(fp * 2 * math.Pi)
+			case SfxrNoise:
+				sample = s.noiseBuffer[s.phase*32/s.period]
+			}
+
+			// Low pass filter.
+			pp := s.fltp
+			s.fltw = max(0, min(0.1, s.fltw*s.fltwD))
+			if p.LPFFreq != 1 {
+				s.fltdp += (sample - s.fltp) * s.fltw
+				s.fltdp -= s.fltdp * s.fltdmp
+			} else {
+				s.fltp = sample
+				s.fltdp = 0
+			}
+			s.fltp += s.fltdp
+
+			// High pass filter.
+			s.fltphp += s.fltp - pp
+			s.fltphp -= s.fltphp * s.flthp
+			sample = s.fltphp
+
+			// Phaser.
+			s.phaserBuffer[s.ipp&1023] = sample
+			sample += s.phaserBuffer[(s.ipp-s.iphase+1024)&1023]
+			s.ipp = (s.ipp + 1) & 1023
+
+			ssample += sample * s.envVol
+		}
+
+		ssample = ssample / 8 * 2 * p.Volume
+		samples = append(samples, float32(max(-1, min(1, ssample))))
+	}
+
+	return samples
+}
+
+// newSynthSound renders the parameters to PCM data in the format of the sound effects.
+func newSynthSound(p SfxrParams, seed int64) []byte {
+	samples := SfxrSynthesize(p, rand.New(rand.NewSource(seed)))
+	data := make([]byte, len(samples)*bytesPerFrame)
+	for i, v := range samples {
+		s := uint16(int16(v * math.MaxInt16))
+		binary.LittleEndian.PutUint16(data[i*bytesPerFrame:], s)
+		binary.LittleEndian.PutUint16(data[i*bytesPerFrame+2:], s)
+	}
+	return processPCM(data, float64(sfxrSampleRate)/float64(audioSampleRate), 0)
+}
+
+func loadSynth(cfg SynthConfig) (*Sound, error) {
+	p := NewSfxrParams()
+	if cfg.Preset != "" {
+		var err error
+		if p, err = SfxrPreset(cfg.Preset, cfg.Seed); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Wave != "" {
+		w, ok := sfxrWaveMappings[cfg.Wave]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownSfxrWave, cfg.Wave)
+		}
+		p.Wave = w
+	}
+	for name, v := range cfg.Params {
+		if err := p.Set(name, v); err != nil {
+			return nil, err
+		}
+	}
+	return newSound(newSynthSound(p, cfg.Seed), cfg.Bus, cfg.Volume, cfg.MaxInstances)
+}
+
+func loadSynthAssets(_ *AssetContext, raw json.RawMessage) (map[string]any, error) {
+	cfg := map[string]SynthConfig{}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, err
+	}
+	sounds := map[string]*Sound{}
+	for name, syn := range cfg {
+		s, err := loadSynth(syn)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		sounds[name] = s
+	}
+	return toAssets(sounds), nil
+}
@@ -0,0 +1,105 @@
+package vigor
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/dbriemann/vigor/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSfxrEnvelopeLength(t *testing.T) {
+	p := NewSfxrParams()
+	p.Attack = 0.1
+	p.Sustain = 0.2
+	p.Decay = 0.3
+
+	samples := SfxrSynthesize(p, rand.New(rand.NewSource(1)))
+	expected := int(0.1*0.1*100000) + int(0.2*0.2*100000) + int(0.3*0.3*100000)
+	assert.InDelta(t, expected, len(samples), 3)
+
+	// The attack starts silent and the decay ends silent.
+	assert.InDelta(t, 0, samples[0], 0.01)
+	assert.InDelta(t, 0, samples[len(samples)-1], 0.01)
+}
+
+func TestSfxrSineFrequency(t *testing.T) {
+	p := NewSfxrParams()
+	p.Wave = SfxrSine
+	p.Sustain = 0.5
+	p.Decay = 0
+
+	samples := SfxrSynthesize(p, rand.New(rand.NewSource(1)))
+
+	// Count rising zero crossings, there is one per wave period.
+	crossings := 0
+	for i := 1; i < len(samples); i++ {
+		if samples[i-1] < 0 && samples[i] >= 0 {
+			crossings++
+		}
+	}
+	// One wave period takes int(fperiod) ticks of the 8x supersampled clock.
+	fperiod := 100 / (p.BaseFreq*p.BaseFreq + 0.001)
+	expected := float64(len(samples)) * 8 / math.Floor(fperiod)
+	assert.InDelta(t, expected, crossings, 2)
+}
+
+func TestSfxrFreqLimitStopsEarly(t *testing.T) {
+	p := NewSfxrParams()
+	p.FreqRamp = -0.5
+	p.FreqLimit = 0.2
+
+	full := NewSfxrParams()
+	full.FreqRamp = -0.5
+
+	limited := SfxrSynthesize(p, rand.New(rand.NewSource(1)))
+	unlimited := SfxrSynthesize(full, rand.New(rand.NewSource(1)))
+	assert.Less(t, len(limited), len(unlimited))
+}
+
+func TestSfxrPresets(t *testing.T) {
+	for name := range sfxrPresets {
+		p, err := SfxrPreset(name, 42)
+		assert.NoError(t, err, name)
+
+		samples := SfxrSynthesize(p, rand.New(rand.NewSource(42)))
+		assert.NotEmpty(t, samples, name)
+
+		peak := float32(0)
+		for _, s := range samples {
+			assert.LessOrEqual(t, s, float32(1), name)
+			assert.GreaterOrEqual(t, s, float32(-1), name)
+			peak = max(peak, s)
+		}
+		assert.Greater(t, peak, float32(0.01), name)
+
+		// The same seed always creates the same sound.
+		again, _ := SfxrPreset(name, 42)
+		assert.Equal(t, samples, SfxrSynthesize(again, rand.New(rand.NewSource(42))), name)
+	}
+
+	_, err := SfxrPreset("unknown", 1)
+	assert.ErrorIs(t, err, ErrUnknownSfxrPreset)
+}
+
+func TestSfxrSet(t *testing.T) {
+	p := NewSfxrParams()
+	assert.NoError(t, p.Set("baseFreq", 0.7))
+	assert.Equal(t, 0.7, p.BaseFreq)
+	assert.ErrorIs(t, p.Set("nope", 1), ErrUnknownSfxrParam)
+}
+
+func TestSfxrConfigNames(t *testing.T) {
+	for name := range sfxrPresets {
+		assert.NoError(t, config.CheckSfxrPreset(name))
+	}
+	for name := range sfxrWaveMappings {
+		assert.NoError(t, config.CheckSfxrWave(name))
+	}
+	p := NewSfxrParams()
+	for name := range p.fields() {
+		assert.NoError(t, config.CheckSfxrParam(name))
+	}
+	assert.ErrorIs(t, config.CheckSfxrParam("nope"), ErrUnknownSfxrParam)
+}
@@ -0,0 +1,157 @@
+package vigor
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"math"
+	"unicode"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type sheetRect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+func (r sheetRect) rect() image.Rectangle {
+	return image.Rect(r.X, r.Y, r.X+r.W, r.Y+r.H)
+}
+
+// sheetFrame is a frame of a sprite sheet in the JSON format of TexturePacker, which Aseprite also uses.
+type sheetFrame struct {
+	Filename         string    `json:"filename"`
+	Frame            sheetRect `json:"frame"`
+	SpriteSourceSize sheetRect `json:"spriteSourceSize"`
+	SourceSize       struct {
+		W int `json:"w"`
+		H int `json:"h"`
+	} `json:"sourceSize"`
+	// Duration is the frame duration in milliseconds, only exported by Aseprite.
+	Duration int  `json:"duration"`
+	Rotated  bool `json:"rotated"`
+	Trimmed  bool `json:"trimmed"`
+}
+
+// sheetFrames are exported either as array or as hash with the file names as keys.
+// The order of the hash is kept, because it is the frame order.
+type sheetFrames []sheetFrame
+
+func (l *sheetFrames) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		return json.Unmarshal(data, (*[]sheetFrame)(l))
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		f := sheetFrame{}
+		if err := dec.Decode(&f); err != nil {
+			return err
+		}
+		if f.Filename == "" {
+			f.Filename, _ = key.(string)
+		}
+		*l = append(*l, f)
+	}
+	return nil
+}
+
+// region is the rectangle of the frame in the sheet. Rotated frames are stored
+// 90 degrees clockwise, so their width and height are swapped.
+func (f *sheetFrame) region() image.Rectangle {
+	r := f.Frame.rect()
+	if f.Rotated {
+		r.Max = r.Min.Add(image.Pt(f.Frame.H, f.Frame.W))
+	}
+	return r
+}
+
+func (f *sheetFrame) transform() frameTransform {
+	return frameTransform{
+		offset:  image.Pt(f.SpriteSourceSize.X, f.SpriteSourceSize.Y),
+		rotated: f.Rotated,
+	}
+}
+
+// sliceFrames returns the image and transform of every frame.
+func sliceFrames(sheet *ebiten.Image, frames sheetFrames) ([]*ebiten.Image, []frameTransform) {
+	images := make([]*ebiten.Image, len(frames))
+	transforms := make([]frameTransform, len(frames))
+	for i := range frames {
+		images[i] = sheet.SubImage(frames[i].region()).(*ebiten.Image)
+		transforms[i] = frames[i].transform()
+	}
+	return images, transforms
+}
+
+// frameTransform restores a packed frame to its place within the untrimmed source size.
+type frameTransform struct {
+	offset  image.Point
+	rotated bool
+}
+
+// apply prepends the transformation of the frame to geoM, so it happens before all others.
+func (f frameTransform) apply(geoM *ebiten.GeoM, img *ebiten.Image) {
+	if f == (frameTransform{}) {
+		return
+	}
+	g := ebiten.GeoM{}
+	if f.rotated {
+		g.Rotate(-math.Pi / 2)
+		g.Translate(0, float64(img.Bounds().Dx()))
+	}
+	g.Translate(float64(f.offset.X), float64(f.offset.Y))
+	g.Concat(*geoM)
+	*geoM = g
+}
+
+// naturalLess compares strings with numbers by their value, so "walk_2" comes before "walk_10".
+func naturalLess(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	i, j := 0, 0
+	for i < len(ra) && j < len(rb) {
+		if unicode.IsDigit(ra[i]) && unicode.IsDigit(rb[j]) {
+			si, sj := i, j
+			for i < len(ra) && unicode.IsDigit(ra[i]) {
+				i++
+			}
+			for j < len(rb) && unicode.IsDigit(rb[j]) {
+				j++
+			}
+			// Compare without leading zeros, first by length, then by digits.
+			na := trimZeros(ra[si:i])
+			nb := trimZeros(rb[sj:j])
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			if string(na) != string(nb) {
+				return string(na) < string(nb)
+			}
+			continue
+		}
+		if ra[i] != rb[j] {
+			return ra[i] < rb[j]
+		}
+		i++
+		j++
+	}
+	return len(ra)-i < len(rb)-j
+}
+
+func trimZeros(digits []rune) []rune {
+	for len(digits) > 1 && digits[0] == '0' {
+		digits = digits[1:]
+	}
+	return digits
+}
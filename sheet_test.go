@@ -0,0 +1,76 @@
+package vigor
+
+import (
+	"encoding/json"
+	"image"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNaturalLess(t *testing.T) {
+	testcases := []struct {
+		a, b string
+		less bool
+	}{
+		{a: "walk_2.png", b: "walk_10.png", less: true},
+		{a: "walk_10.png", b: "walk_2.png", less: false},
+		{a: "walk_002.png", b: "walk_10.png", less: true},
+		{a: "walk_1.png", b: "walk_1.png", less: false},
+		{a: "idle", b: "walk", less: true},
+		{a: "walk", b: "walk_1", less: true},
+		{a: "a9b", b: "a10a", less: true},
+	}
+
+	for _, tc := range testcases {
+		assert.Equal(t, tc.less, naturalLess(tc.a, tc.b), "%s < %s", tc.a, tc.b)
+	}
+}
+
+const texturePackerArray = `{"frames": [
+	{"filename": "walk_10.png", "frame": {"x": 0, "y": 0, "w": 8, "h": 4}, "rotated": true, "trimmed": true,
+	 "spriteSourceSize": {"x": 2, "y": 3, "w": 8, "h": 4}, "sourceSize": {"w": 12, "h": 10}},
+	{"filename": "idle.png", "frame": {"x": 4, "y": 0, "w": 12, "h": 10}, "rotated": false, "trimmed": false,
+	 "spriteSourceSize": {"x": 0, "y": 0, "w": 12, "h": 10}, "sourceSize": {"w": 12, "h": 10}},
+	{"filename": "walk_9.png", "frame": {"x": 16, "y": 0, "w": 12, "h": 10}, "rotated": false, "trimmed": false,
+	 "spriteSourceSize": {"x": 0, "y": 0, "w": 12, "h": 10}, "sourceSize": {"w": 12, "h": 10}}
+],
+"meta": {"image": "sheet.png"}}`
+
+func TestTexturePackerFrames(t *testing.T) {
+	data := texturePackerData{}
+	assert.NoError(t, json.Unmarshal([]byte(texturePackerArray), &data))
+
+	assert.Equal(t, []int{2, 0}, prefixFrames(data.Frames, "walk_"))
+	assert.Equal(t, []int{}, prefixFrames(data.Frames, "run_"))
+
+	// Rotated frames are stored with swapped width and height.
+	rotated := data.Frames[0]
+	assert.Equal(t, image.Rect(0, 0, 4, 8), rotated.region())
+	assert.Equal(t, frameTransform{offset: image.Pt(2, 3), rotated: true}, rotated.transform())
+	assert.Equal(t, image.Rect(4, 0, 16, 10), data.Frames[1].region())
+}
+
+func TestFrameTransformApply(t *testing.T) {
+	img := ebiten.NewImage(4, 8)
+
+	// A rotated 8x4 frame, trimmed at offset 2,3, is restored before the other transformations.
+	geoM := ebiten.GeoM{}
+	geoM.Translate(100, 0)
+	frameTransform{offset: image.Pt(2, 3), rotated: true}.apply(&geoM, img)
+
+	// The top right corner of the stored frame is the top left corner of the sprite.
+	x, y := geoM.Apply(4, 0)
+	assert.InDelta(t, 102, x, 1e-9)
+	assert.InDelta(t, 3, y, 1e-9)
+	// The bottom left corner of the stored frame is the bottom right corner of the sprite.
+	x, y = geoM.Apply(0, 8)
+	assert.InDelta(t, 110, x, 1e-9)
+	assert.InDelta(t, 7, y, 1e-9)
+
+	// Untransformed frames keep the matrix.
+	geoM = ebiten.GeoM{}
+	frameTransform{}.apply(&geoM, img)
+	assert.Equal(t, ebiten.GeoM{}, geoM)
+}
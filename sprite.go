@@ -1,10 +1,15 @@
 package vigor
 
 import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/colorm"
+	"github.com/hajimehoshi/ebiten/v2/vector"
 	"github.com/tanema/gween/ease"
 )
 
@@ -16,6 +21,7 @@ type Sprite struct {
 	animations     map[string]*Animation
 	effects        []Effect
 	activeAnimName string
+	stateMachine   *StateMachine
 
 	visual
 	Object
@@ -66,6 +72,42 @@ func (s *Sprite) SetAnimation(name string) {
 	s.activeAnim.Run()
 }
 
+// SetStateMachine lets a state machine switch the animations of the sprite, starting with its initial state.
+// Animations of states that were not passed to NewSprite are created from the asset manager.
+func (s *Sprite) SetStateMachine(template *StateMachineTemplate) (*StateMachine, error) {
+	for _, state := range sortedKeys(template.States) {
+		animName := template.States[state]
+		if _, ok := s.animations[animName]; ok {
+			continue
+		}
+		anim, err := NewAnimation(G.assets.AnimationTemplates[animName])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownAnimation, animName)
+		}
+		s.animations[animName] = anim
+	}
+	m := newStateMachine(template, s)
+	if err := m.SetState(template.Initial); err != nil {
+		return nil, err
+	}
+	s.stateMachine = m
+	return m, nil
+}
+
+// UseStateMachine sets the state machine with the given name from the config.
+func (s *Sprite) UseStateMachine(name string) (*StateMachine, error) {
+	template, err := GetAsset[*StateMachineTemplate](name)
+	if err != nil {
+		return nil, err
+	}
+	return s.SetStateMachine(template)
+}
+
+// StateMachine returns the state machine of the sprite or nil.
+func (s *Sprite) StateMachine() *StateMachine {
+	return s.stateMachine
+}
+
 func (s *Sprite) Animation() (name string, paused, finished bool) {
 	name = s.activeAnimName
 	paused = s.activeAnim.Paused
@@ -73,6 +115,40 @@ func (s *Sprite) Animation() (name string, paused, finished bool) {
 	return
 }
 
+// OnAnimationFrame registers a callback that is called whenever the named animation enters the step of its frames
+// with the given index.
+func (s *Sprite) OnAnimationFrame(animName string, step int, f func()) {
+	if anim, ok := s.animations[animName]; ok {
+		anim.OnFrame(step, f)
+	}
+}
+
+// OnAnimationLoop registers a callback that is called whenever the named animation starts over.
+func (s *Sprite) OnAnimationLoop(animName string, f func()) {
+	if anim, ok := s.animations[animName]; ok {
+		anim.OnLoop(f)
+	}
+}
+
+// OnAnimationFinish registers a callback that is called when the named animation reaches its end.
+func (s *Sprite) OnAnimationFinish(animName string, f func()) {
+	if anim, ok := s.animations[animName]; ok {
+		anim.OnFinish(f)
+	}
+}
+
+// OnAnimationEvent registers a callback for the named event of all animations of the sprite.
+// It is called with the name of the animation emitting the event.
+func (s *Sprite) OnAnimationEvent(event string, f func(animName string)) {
+	for animName, anim := range s.animations {
+		anim.OnEvent(func(name string) {
+			if name == event {
+				f(animName)
+			}
+		})
+	}
+}
+
 func (s *Sprite) ApplyEffect(e Effect) {
 	e.Reset()
 	e.Start()
@@ -80,6 +156,7 @@ func (s *Sprite) ApplyEffect(e Effect) {
 }
 
 func (s *Sprite) draw(target *ebiten.Image, op colorm.DrawImageOptions) {
+	parent := op.GeoM
 	s.transform(&op, int(s.Dim().X), int(s.Dim().Y))
 	op.GeoM.Translate(float64(s.PixelPos().X), float64(s.PixelPos().Y))
 	for i := 0; i < len(s.effects); i++ {
@@ -89,6 +166,82 @@ func (s *Sprite) draw(target *ebiten.Image, op colorm.DrawImageOptions) {
 	for i := 0; i < len(s.effects); i++ {
 		s.effects[i].draw(target, op)
 	}
+	if G.debug {
+		s.drawBoxes(target, parent)
+	}
+}
+
+var (
+	debugHitboxColor  = color.RGBA{R: 0xff, A: 0xff}
+	debugHurtboxColor = color.RGBA{G: 0xff, A: 0xff}
+	debugPivotColor   = color.RGBA{R: 0xff, G: 0xff, A: 0xff}
+)
+
+// drawBoxes outlines the boxes of the current frame and marks its pivot. Hurtboxes are green, all other boxes red.
+func (s *Sprite) drawBoxes(target *ebiten.Image, geoM ebiten.GeoM) {
+	for name, box := range s.Hitboxes() {
+		x0, y0 := geoM.Apply(float64(box.Point.X), float64(box.Point.Y))
+		x1, y1 := geoM.Apply(float64(box.Point.X+box.Dim.X), float64(box.Point.Y+box.Dim.Y))
+		col := debugHitboxColor
+		if name == "hurtbox" {
+			col = debugHurtboxColor
+		}
+		vector.StrokeRect(target, float32(min(x0, x1)), float32(min(y0, y1)), float32(math.Abs(x1-x0)), float32(math.Abs(y1-y0)), 1, col, false)
+	}
+	pivot := s.Pivot()
+	x, y := geoM.Apply(float64(pivot.X), float64(pivot.Y))
+	vector.StrokeLine(target, float32(x)-2, float32(y), float32(x)+2, float32(y), 1, debugPivotColor, false)
+	vector.StrokeLine(target, float32(x), float32(y)-2, float32(x), float32(y)+2, 1, debugPivotColor, false)
+}
+
+// Hitbox returns the named box of the current frame in world space, respecting flip and scale.
+func (s *Sprite) Hitbox(name string) (Rect[float32], bool) {
+	box, ok := s.activeAnim.Hitbox(name)
+	if !ok {
+		return Rect[float32]{}, false
+	}
+	p0 := s.toWorld(box.Min)
+	p1 := s.toWorld(box.Max)
+	return Rect[float32]{
+		Point: Vec2[float32]{X: min(p0.X, p1.X), Y: min(p0.Y, p1.Y)},
+		Dim:   Vec2[float32]{X: abs(p1.X - p0.X), Y: abs(p1.Y - p0.Y)},
+	}, true
+}
+
+// Hitboxes returns all boxes of the current frame in world space by name.
+func (s *Sprite) Hitboxes() map[string]Rect[float32] {
+	boxes := map[string]Rect[float32]{}
+	for name := range s.activeAnim.Hitboxes {
+		if box, ok := s.Hitbox(name); ok {
+			boxes[name] = box
+		}
+	}
+	return boxes
+}
+
+// Pivot returns the pivot of the current frame in world space, respecting flip and scale.
+func (s *Sprite) Pivot() Vec2[float32] {
+	return s.toWorld(s.activeAnim.Pivot())
+}
+
+// toWorld transforms a point of the current frame like it is drawn.
+func (s *Sprite) toWorld(p image.Point) Vec2[float32] {
+	x := float32(p.X) * s.scale.X
+	if s.scale.X < 0 {
+		x -= float32(s.Dim().X) * s.scale.X
+	}
+	y := float32(p.Y) * s.scale.Y
+	if s.scale.Y < 0 {
+		y -= float32(s.Dim().Y) * s.scale.Y
+	}
+	return Vec2[float32]{X: s.Pos().X + x, Y: s.Pos().Y + y}
+}
+
+// HitboxesCollide checks if a named box of one sprite intersects a named box of another in their current frames.
+func HitboxesCollide(s1 *Sprite, box1 string, s2 *Sprite, box2 string) bool {
+	r1, ok1 := s1.Hitbox(box1)
+	r2, ok2 := s2.Hitbox(box2)
+	return ok1 && ok2 && r1.Intersects(r2)
 }
 
 // TODO: should Object be scaled instead?
@@ -99,6 +252,9 @@ func (s *Sprite) Scale(x, y float32) {
 
 func (s *Sprite) Update() {
 	s.activeAnim.Update(G.Dt())
+	if s.stateMachine != nil {
+		s.stateMachine.update()
+	}
 	s.Object.Update()
 	for j := 0; j < len(s.effects); j++ {
 		finished := s.effects[j].Update()
@@ -123,12 +279,31 @@ func (s *Sprite) ResetAnimation() {
 // SetTweenFunc sets the easing function for the active animation.
 func (s *Sprite) SetTweenFunc(f ease.TweenFunc) {
 	s.activeAnim.SetTweenFunc(f)
-	s.activeAnim.InitTween()
 }
 
+// SetDuration sets the duration of the active animation, its template and other sprites are not affected.
 func (s *Sprite) SetDuration(dur time.Duration) {
 	s.activeAnim.SetDuration(dur)
-	s.activeAnim.InitTween()
+}
+
+// SetPlayMode sets the order in which the frames of the active animation are played and resets it.
+func (s *Sprite) SetPlayMode(mode PlayMode) {
+	s.activeAnim.SetPlayMode(mode)
+}
+
+// SetLoops sets the number of times the active animation is played, zero plays it forever.
+func (s *Sprite) SetLoops(loops int) {
+	s.activeAnim.SetLoops(loops)
+}
+
+// SetAnimationSpeed sets the factor the time of the active animation is multiplied with.
+func (s *Sprite) SetAnimationSpeed(speed float64) {
+	s.activeAnim.SetSpeed(speed)
+}
+
+// PlayFrom restarts the active animation at a step of its frames.
+func (s *Sprite) PlayFrom(step int) {
+	s.activeAnim.PlayFrom(step)
 }
 
 // func (s *Sprite) ActiveAnimation() *Animation {
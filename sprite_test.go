@@ -0,0 +1,55 @@
+package vigor
+
+import (
+	"image"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpriteHitboxes(t *testing.T) {
+	template := &AnimationTemplate{
+		Frames:      []int{0, 1},
+		Images:      make([]*ebiten.Image, 2),
+		FrameWidth:  20,
+		FrameHeight: 10,
+	}
+	assert.NoError(t, template.SetStepBoxes("hitbox", []image.Rectangle{{}, image.Rect(12, 2, 18, 6)}))
+	assert.NoError(t, template.SetStepBoxes("hurtbox", []image.Rectangle{image.Rect(2, 0, 8, 10), image.Rect(2, 0, 8, 10)}))
+	assert.NoError(t, template.SetStepPivots([]image.Point{{5, 10}, {6, 10}}))
+	anim, err := NewAnimation(template)
+	assert.NoError(t, err)
+
+	testcases := []struct {
+		name          string
+		scale         Vec2[float32]
+		hitbox        Rect[float32]
+		expectedPivot Vec2[float32]
+	}{
+		{name: "plain", scale: Vec2[float32]{X: 1, Y: 1}, hitbox: Rect[float32]{Point: Vec2[float32]{X: 112, Y: 52}, Dim: Vec2[float32]{X: 6, Y: 4}}, expectedPivot: Vec2[float32]{X: 106, Y: 60}},
+		{name: "flipped", scale: Vec2[float32]{X: -1, Y: 1}, hitbox: Rect[float32]{Point: Vec2[float32]{X: 102, Y: 52}, Dim: Vec2[float32]{X: 6, Y: 4}}, expectedPivot: Vec2[float32]{X: 114, Y: 60}},
+		{name: "scaled", scale: Vec2[float32]{X: 2, Y: 0.5}, hitbox: Rect[float32]{Point: Vec2[float32]{X: 124, Y: 51}, Dim: Vec2[float32]{X: 12, Y: 2}}, expectedPivot: Vec2[float32]{X: 112, Y: 55}},
+		{name: "flipped and scaled", scale: Vec2[float32]{X: -2, Y: -1}, hitbox: Rect[float32]{Point: Vec2[float32]{X: 104, Y: 54}, Dim: Vec2[float32]{X: 12, Y: 4}}, expectedPivot: Vec2[float32]{X: 128, Y: 50}},
+	}
+
+	for _, tc := range testcases {
+		s := &Sprite{Object: NewObject(), visual: newVisual(), activeAnim: anim}
+		s.SetPos(100, 50)
+		s.dim = Vec2[uint32]{X: 20, Y: 10}
+		s.scale = tc.scale
+
+		anim.Frame = 0
+		_, ok := s.Hitbox("hitbox")
+		assert.False(t, ok, tc.name)
+		assert.Len(t, s.Hitboxes(), 1, tc.name)
+
+		anim.Frame = 1
+		box, ok := s.Hitbox("hitbox")
+		assert.True(t, ok, tc.name)
+		assert.Equal(t, tc.hitbox, box, tc.name)
+		assert.Equal(t, tc.expectedPivot, s.Pivot(), tc.name)
+		assert.True(t, HitboxesCollide(s, "hitbox", s, "hitbox"), tc.name)
+		assert.False(t, HitboxesCollide(s, "hitbox", s, "missing"), tc.name)
+	}
+}
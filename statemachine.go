@@ -0,0 +1,364 @@
+package vigor
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dbriemann/vigor/config"
+)
+
+var (
+	ErrUnknownState     = config.ErrUnknownState
+	ErrUnknownParam     = config.ErrUnknownParam
+	ErrParamKind        = config.ErrParamKind
+	ErrInvalidCondition = config.ErrInvalidCondition
+)
+
+// AnyState is the source state of transitions that are taken from every state.
+const AnyState = config.AnyState
+
+// ParamKind is the kind of a state machine parameter.
+type ParamKind int
+
+const (
+	ParamBool ParamKind = iota
+	ParamFloat
+	// ParamTrigger is set until a transition consumes it.
+	ParamTrigger
+)
+
+type CompareOp int
+
+const (
+	CompareEqual CompareOp = iota
+	CompareNotEqual
+	CompareLess
+	CompareLessEqual
+	CompareGreater
+	CompareGreaterEqual
+)
+
+// Condition compares a bool or float parameter with a value. Bools are 1 if true and 0 if false.
+type Condition struct {
+	Param string
+	Op    CompareOp
+	Value float64
+}
+
+func (c Condition) holds(value float64) bool {
+	switch c.Op {
+	case CompareNotEqual:
+		return value != c.Value
+	case CompareLess:
+		return value < c.Value
+	case CompareLessEqual:
+		return value <= c.Value
+	case CompareGreater:
+		return value > c.Value
+	case CompareGreaterEqual:
+		return value >= c.Value
+	}
+	return value == c.Value
+}
+
+// ParseCondition parses a condition like "speed > 0.5", "grounded == true", "grounded" or "!grounded".
+func ParseCondition(s string) (Condition, error) {
+	cc, err := config.ParseCondition(s)
+	if err != nil {
+		return Condition{}, err
+	}
+	return Condition{Param: cc.Param, Op: compareOpMappings[cc.Op], Value: cc.Value}, nil
+}
+
+// Transition switches the state machine from one state to another. It is taken when its trigger is set,
+// the animation of the state is finished if OnFinish is true, and all conditions hold. If several transitions
+// can be taken, the one with the highest priority wins, then the one added first.
+type Transition struct {
+	From       string
+	To         string
+	Trigger    string
+	Conditions []Condition
+	Priority   int
+	OnFinish   bool
+}
+
+// StateMachineTemplate is the shared definition of animation state machines. Its states are bound to animation names.
+type StateMachineTemplate struct {
+	Name        string
+	Initial     string
+	States      map[string]string
+	Params      map[string]ParamKind
+	Transitions []Transition
+}
+
+func NewStateMachineTemplate(name string) *StateMachineTemplate {
+	return &StateMachineTemplate{
+		Name:        name,
+		States:      map[string]string{},
+		Params:      map[string]ParamKind{},
+		Transitions: []Transition{},
+	}
+}
+
+// AddState adds a state that plays the named animation. The first state is the initial state.
+func (t *StateMachineTemplate) AddState(name, animName string) {
+	if t.Initial == "" {
+		t.Initial = name
+	}
+	t.States[name] = animName
+}
+
+func (t *StateMachineTemplate) AddParam(name string, kind ParamKind) {
+	t.Params[name] = kind
+}
+
+// AddTransition adds a transition between known states that uses known parameters.
+func (t *StateMachineTemplate) AddTransition(tr Transition) error {
+	if _, ok := t.States[tr.From]; !ok && tr.From != AnyState {
+		return fmt.Errorf("%w: %s", ErrUnknownState, tr.From)
+	}
+	if _, ok := t.States[tr.To]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownState, tr.To)
+	}
+	if tr.Trigger != "" {
+		if err := t.checkParam(tr.Trigger, ParamTrigger); err != nil {
+			return err
+		}
+	}
+	for _, c := range tr.Conditions {
+		kind, ok := t.Params[c.Param]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownParam, c.Param)
+		}
+		if kind == ParamTrigger {
+			return fmt.Errorf("%w: %s is a trigger", ErrParamKind, c.Param)
+		}
+	}
+	t.Transitions = append(t.Transitions, tr)
+	return nil
+}
+
+func (t *StateMachineTemplate) checkParam(name string, kind ParamKind) error {
+	k, ok := t.Params[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownParam, name)
+	}
+	if k != kind {
+		return fmt.Errorf("%w: %s", ErrParamKind, name)
+	}
+	return nil
+}
+
+// StateMachine switches the animations of a sprite by the transitions of its template.
+type StateMachine struct {
+	*StateMachineTemplate
+	sprite   *Sprite
+	state    string
+	values   map[string]float64
+	triggers map[string]bool
+}
+
+func newStateMachine(template *StateMachineTemplate, sprite *Sprite) *StateMachine {
+	return &StateMachine{
+		StateMachineTemplate: template,
+		sprite:               sprite,
+		values:               map[string]float64{},
+		triggers:             map[string]bool{},
+	}
+}
+
+// State returns the name of the current state.
+func (m *StateMachine) State() string {
+	return m.state
+}
+
+// SetState switches to a state without a transition.
+func (m *StateMachine) SetState(name string) error {
+	animName, ok := m.States[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownState, name)
+	}
+	m.state = name
+	m.sprite.SetAnimation(animName)
+	return nil
+}
+
+func (m *StateMachine) SetBool(name string, value bool) error {
+	if err := m.checkParam(name, ParamBool); err != nil {
+		return err
+	}
+	m.values[name] = 0
+	if value {
+		m.values[name] = 1
+	}
+	return nil
+}
+
+func (m *StateMachine) SetFloat(name string, value float64) error {
+	if err := m.checkParam(name, ParamFloat); err != nil {
+		return err
+	}
+	m.values[name] = value
+	return nil
+}
+
+// Trigger sets a trigger parameter, which stays set until a transition consumes it.
+func (m *StateMachine) Trigger(name string) error {
+	if err := m.checkParam(name, ParamTrigger); err != nil {
+		return err
+	}
+	m.triggers[name] = true
+	return nil
+}
+
+func (m *StateMachine) Bool(name string) bool {
+	return m.values[name] != 0
+}
+
+func (m *StateMachine) Float(name string) float64 {
+	return m.values[name]
+}
+
+// update takes at most one transition, after the animation of the sprite was updated.
+func (m *StateMachine) update() {
+	if G.debug {
+		G.stateMachines.add(m)
+	}
+	var next *Transition
+	for i := range m.Transitions {
+		tr := &m.Transitions[i]
+		if m.enabled(tr) && (next == nil || tr.Priority > next.Priority) {
+			next = tr
+		}
+	}
+	if next == nil {
+		return
+	}
+	if next.Trigger != "" {
+		delete(m.triggers, next.Trigger)
+	}
+	_ = m.SetState(next.To)
+}
+
+func (m *StateMachine) enabled(tr *Transition) bool {
+	if tr.From != m.state && (tr.From != AnyState || tr.To == m.state) {
+		return false
+	}
+	if tr.Trigger != "" && !m.triggers[tr.Trigger] {
+		return false
+	}
+	if tr.OnFinish && !m.sprite.activeAnim.Finished {
+		return false
+	}
+	for _, c := range tr.Conditions {
+		if !c.holds(m.values[c.Param]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *StateMachine) String() string {
+	params := make([]string, 0, len(m.Params))
+	for _, name := range sortedKeys(m.Params) {
+		switch m.Params[name] {
+		case ParamBool:
+			params = append(params, fmt.Sprintf("%s=%t", name, m.Bool(name)))
+		case ParamFloat:
+			params = append(params, fmt.Sprintf("%s=%.2f", name, m.Float(name)))
+		case ParamTrigger:
+			if m.triggers[name] {
+				params = append(params, name)
+			}
+		}
+	}
+	return fmt.Sprintf("%s: %s (%s)", m.Name, m.state, strings.Join(params, " "))
+}
+
+// stateMachineInspector collects the state machines updated in the current frame for the debug overlay.
+type stateMachineInspector struct {
+	updated []*StateMachine
+}
+
+func (in *stateMachineInspector) add(m *StateMachine) {
+	in.updated = append(in.updated, m)
+}
+
+func (in *stateMachineInspector) clear() {
+	in.updated = in.updated[:0]
+}
+
+func (in *stateMachineInspector) debugInfo() string {
+	lines := make([]string, 0, len(in.updated))
+	for _, m := range in.updated {
+		lines = append(lines, "  "+m.String())
+	}
+	sort.Strings(lines)
+	return strings.Join(append([]string{fmt.Sprintf("state machines: %d", len(in.updated))}, lines...), "\n")
+}
+
+func loadStateMachineAssets(_ *AssetContext, raw json.RawMessage) (map[string]any, error) {
+	cfg := map[string]StateMachineConfig{}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, err
+	}
+	templates := map[string]*StateMachineTemplate{}
+	for name, sc := range cfg {
+		t, err := newConfigStateMachineTemplate(name, sc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		templates[name] = t
+	}
+	return toAssets(templates), nil
+}
+
+// newConfigStateMachineTemplate creates the state machine template with the given name from its config.
+func newConfigStateMachineTemplate(name string, sc StateMachineConfig) (*StateMachineTemplate, error) {
+	t := NewStateMachineTemplate(name)
+	for _, state := range sortedKeys(sc.States) {
+		t.AddState(state, sc.States[state])
+	}
+	if _, ok := sc.States[sc.Initial]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownState, sc.Initial)
+	}
+	t.Initial = sc.Initial
+	for _, param := range sortedKeys(sc.Params) {
+		kind, ok := paramKindMappings[sc.Params[param]]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrParamKind, sc.Params[param])
+		}
+		t.AddParam(param, kind)
+	}
+	for i, tc := range sc.Transitions {
+		tr, err := newConfigTransition(tc)
+		if err == nil {
+			err = t.AddTransition(tr)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("transition %d: %w", i, err)
+		}
+	}
+	return t, nil
+}
+
+func newConfigTransition(tc TransitionConfig) (Transition, error) {
+	tr := Transition{
+		From:       tc.From,
+		To:         tc.To,
+		Trigger:    tc.Trigger,
+		Conditions: make([]Condition, 0, len(tc.Conditions)),
+		Priority:   tc.Priority,
+		OnFinish:   tc.OnFinish,
+	}
+	for _, s := range tc.Conditions {
+		c, err := ParseCondition(s)
+		if err != nil {
+			return tr, err
+		}
+		tr.Conditions = append(tr.Conditions, c)
+	}
+	return tr, nil
+}
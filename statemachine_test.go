@@ -0,0 +1,152 @@
+package vigor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tanema/gween/ease"
+)
+
+func TestParseCondition(t *testing.T) {
+	testcases := []struct {
+		input    string
+		expected Condition
+		err      error
+	}{
+		{input: "speed > 0.5", expected: Condition{Param: "speed", Op: CompareGreater, Value: 0.5}},
+		{input: "speed <= -1", expected: Condition{Param: "speed", Op: CompareLessEqual, Value: -1}},
+		{input: "grounded == true", expected: Condition{Param: "grounded", Op: CompareEqual, Value: 1}},
+		{input: "grounded != false", expected: Condition{Param: "grounded", Op: CompareNotEqual, Value: 0}},
+		{input: "grounded", expected: Condition{Param: "grounded", Op: CompareEqual, Value: 1}},
+		{input: "!grounded", expected: Condition{Param: "grounded", Op: CompareEqual, Value: 0}},
+		{input: "!", err: ErrInvalidCondition},
+		{input: "speed >> 1", err: ErrInvalidCondition},
+		{input: "speed > fast", err: ErrInvalidCondition},
+		{input: "speed >", err: ErrInvalidCondition},
+	}
+
+	for _, tc := range testcases {
+		c, err := ParseCondition(tc.input)
+		assert.ErrorIs(t, err, tc.err, tc.input)
+		assert.Equal(t, tc.expected, c, tc.input)
+	}
+}
+
+func newStateMachineTestSprite(t *testing.T) *Sprite {
+	s := &Sprite{animations: map[string]*Animation{}}
+	for name, template := range map[string]*AnimationTemplate{
+		"idle":   {Frames: []int{0}, Looped: true},
+		"run":    {Frames: []int{1, 2}, Duration: time.Second, EaseFunc: ease.Linear, Looped: true},
+		"attack": {Frames: []int{3, 4}, Duration: time.Second, EaseFunc: ease.Linear},
+	} {
+		anim, err := NewAnimation(template)
+		assert.NoError(t, err)
+		s.animations[name] = anim
+	}
+	s.activeAnim = s.animations["idle"]
+	return s
+}
+
+func TestStateMachine(t *testing.T) {
+	sc := StateMachineConfig{
+		Initial: "idle",
+		States:  map[string]string{"idle": "idle", "run": "run", "attack": "attack"},
+		Params:  map[string]string{"speed": "float", "hurt": "bool", "attack": "trigger"},
+		Transitions: []TransitionConfig{
+			{From: "idle", To: "run", Conditions: []string{"speed > 0.1", "!hurt"}},
+			{From: "run", To: "idle", Conditions: []string{"speed <= 0.1"}},
+			{From: "*", To: "attack", Trigger: "attack"},
+			{From: "*", To: "idle", Conditions: []string{"hurt"}, Priority: 1},
+			{From: "attack", To: "idle", OnFinish: true},
+		},
+	}
+	template, err := newConfigStateMachineTemplate("hero", sc)
+	assert.NoError(t, err)
+	s := newStateMachineTestSprite(t)
+	m, err := s.SetStateMachine(template)
+	assert.NoError(t, err)
+
+	step := func(dt float32) string {
+		s.activeAnim.Update(dt)
+		m.update()
+		return m.State()
+	}
+
+	assert.Equal(t, "idle", m.State())
+	assert.Equal(t, "idle", step(0.1))
+	assert.NoError(t, m.SetFloat("speed", 2))
+	assert.Equal(t, "run", step(0.1))
+	assert.Equal(t, "run", s.activeAnimName)
+	assert.Equal(t, "run", step(0.1))
+
+	// The trigger stays set until it is consumed.
+	assert.NoError(t, m.Trigger("attack"))
+	assert.Equal(t, "attack", step(0.1))
+	assert.Equal(t, "attack", step(0.5))
+	assert.Equal(t, "idle", step(0.5))
+	assert.Equal(t, "run", step(0.1))
+
+	// The transition with the higher priority wins.
+	assert.NoError(t, m.Trigger("attack"))
+	assert.NoError(t, m.SetBool("hurt", true))
+	assert.Equal(t, "idle", step(0.1))
+	assert.Equal(t, "hero: idle (attack hurt=true speed=2.00)", m.String())
+	// Transitions from all states do not lead to the current state, so the trigger is consumed now.
+	assert.Equal(t, "attack", step(0.1))
+	assert.Equal(t, "hero: attack (hurt=true speed=2.00)", m.String())
+	assert.Equal(t, "idle", step(0.1))
+
+	assert.ErrorIs(t, m.SetBool("speed", true), ErrParamKind)
+	assert.ErrorIs(t, m.Trigger("jump"), ErrUnknownParam)
+	assert.ErrorIs(t, m.SetState("jump"), ErrUnknownState)
+}
+
+func TestStateMachineConfig(t *testing.T) {
+	testcases := []struct {
+		name string
+		sc   StateMachineConfig
+		err  error
+	}{
+		{
+			name: "unknown initial",
+			sc:   StateMachineConfig{Initial: "walk", States: map[string]string{"idle": "idle"}},
+			err:  ErrUnknownState,
+		},
+		{
+			name: "unknown param kind",
+			sc:   StateMachineConfig{Initial: "idle", States: map[string]string{"idle": "idle"}, Params: map[string]string{"speed": "int"}},
+			err:  ErrParamKind,
+		},
+		{
+			name: "unknown target",
+			sc:   StateMachineConfig{Initial: "idle", States: map[string]string{"idle": "idle"}, Transitions: []TransitionConfig{{From: "*", To: "run"}}},
+			err:  ErrUnknownState,
+		},
+		{
+			name: "trigger in condition",
+			sc: StateMachineConfig{
+				Initial:     "idle",
+				States:      map[string]string{"idle": "idle"},
+				Params:      map[string]string{"attack": "trigger"},
+				Transitions: []TransitionConfig{{From: "idle", To: "idle", Conditions: []string{"attack"}}},
+			},
+			err: ErrParamKind,
+		},
+		{
+			name: "bool as trigger",
+			sc: StateMachineConfig{
+				Initial:     "idle",
+				States:      map[string]string{"idle": "idle"},
+				Params:      map[string]string{"hurt": "bool"},
+				Transitions: []TransitionConfig{{From: "idle", To: "idle", Trigger: "hurt"}},
+			},
+			err: ErrParamKind,
+		},
+	}
+
+	for _, tc := range testcases {
+		_, err := newConfigStateMachineTemplate(tc.name, tc.sc)
+		assert.ErrorIs(t, err, tc.err, tc.name)
+	}
+}
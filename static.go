@@ -1,8 +1,71 @@
 package vigor
 
-import "github.com/tanema/gween/ease"
+import (
+	"image/color"
+
+	"github.com/tanema/gween/ease"
+)
 
 var (
+	paramKindMappings = map[string]ParamKind{
+		"bool":    ParamBool,
+		"float":   ParamFloat,
+		"trigger": ParamTrigger,
+	}
+
+	compareOpMappings = map[string]CompareOp{
+		"==": CompareEqual,
+		"!=": CompareNotEqual,
+		"<":  CompareLess,
+		"<=": CompareLessEqual,
+		">":  CompareGreater,
+		">=": CompareGreaterEqual,
+	}
+
+	playModeMappings = map[string]PlayMode{
+		"forward":  PlayForward,
+		"reverse":  PlayReverse,
+		"pingpong": PlayPingPong,
+	}
+
+	sfxrWaveMappings = map[string]SfxrWave{
+		"square":   SfxrSquare,
+		"sawtooth": SfxrSawtooth,
+		"sine":     SfxrSine,
+		"noise":    SfxrNoise,
+	}
+
+	colorNameMappings = map[string]color.Color{
+		"white":   color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+		"black":   color.RGBA{A: 0xff},
+		"gray":    color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff},
+		"red":     color.RGBA{R: 0xff, A: 0xff},
+		"green":   color.RGBA{G: 0xff, A: 0xff},
+		"blue":    color.RGBA{B: 0xff, A: 0xff},
+		"yellow":  color.RGBA{R: 0xff, G: 0xff, A: 0xff},
+		"orange":  color.RGBA{R: 0xff, G: 0xa5, A: 0xff},
+		"purple":  color.RGBA{R: 0x80, B: 0x80, A: 0xff},
+		"cyan":    color.RGBA{G: 0xff, B: 0xff, A: 0xff},
+		"magenta": color.RGBA{R: 0xff, B: 0xff, A: 0xff},
+	}
+
+	tileCollisionMappings = map[string]TileCollision{
+		"none":       TileEmpty,
+		"solid":      TileSolid,
+		"oneway":     TileOneWay,
+		"slope_up":   TileSlopeUp,
+		"slope_down": TileSlopeDown,
+	}
+
+	// pluralRules select the plural category of integer counts by language.
+	pluralRules = map[string]func(n int) PluralCategory{
+		"en": pluralOneOther,
+		"de": pluralOneOther,
+		"fr": pluralFrench,
+		"pl": pluralPolish,
+		"ru": pluralRussian,
+	}
+
 	easeFuncMappings = map[string]ease.TweenFunc{
 		"Linear":       ease.Linear,
 		"InQuad":       ease.InQuad,
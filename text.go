@@ -0,0 +1,304 @@
+package vigor
+
+import (
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/colorm"
+)
+
+var _ effected = (*Text)(nil)
+
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+type textLine struct {
+	runes []rune
+	width int
+}
+
+// measureRunes returns the width of the runes including kerning.
+func measureRunes(f Font, runes []rune) int {
+	w := 0
+	for i, r := range runes {
+		if i > 0 {
+			w += f.Kern(runes[i-1], r)
+		}
+		w += f.Advance(r)
+	}
+	return w
+}
+
+// layoutText breaks s into lines at newlines. If wrapWidth is larger than zero,
+// lines are also broken between words so they do not exceed wrapWidth.
+// A single word that is wider than wrapWidth is not broken.
+func layoutText(f Font, s string, wrapWidth int) []textLine {
+	lines := []textLine{}
+	for _, paragraph := range strings.Split(s, "\n") {
+		if wrapWidth <= 0 {
+			runes := []rune(paragraph)
+			lines = append(lines, textLine{runes: runes, width: measureRunes(f, runes)})
+			continue
+		}
+
+		line := []rune{}
+		for i, word := range strings.Split(paragraph, " ") {
+			candidate := []rune(word)
+			if i > 0 {
+				candidate = append(append(append([]rune{}, line...), ' '), candidate...)
+			}
+			if len(line) > 0 && measureRunes(f, candidate) > wrapWidth {
+				lines = append(lines, textLine{runes: line, width: measureRunes(f, line)})
+				candidate = []rune(word)
+			}
+			line = candidate
+		}
+		lines = append(lines, textLine{runes: line, width: measureRunes(f, line)})
+	}
+	return lines
+}
+
+// Text is a stageable that renders a string with a font from the asset manager.
+type Text struct {
+	effects []Effect
+	font    Font
+	image   *ebiten.Image
+	content string
+	// key is the localization key of the content, which is translated again when the locale changes.
+	key          string
+	params       map[string]any
+	revision     uint64
+	lines        []textLine
+	color        color.Color
+	outlineColor color.Color
+	shadowColor  color.Color
+	shadow       Vec2[int]
+	outline      int
+	wrapWidth    int
+	lineSpacing  int
+	margin       int
+	align        Align
+	dirty        bool
+
+	visual
+	Object
+}
+
+func NewText(fontName, content string) *Text {
+	t := &Text{
+		Object:  NewObject(),
+		visual:  newVisual(),
+		effects: []Effect{},
+
+		font:         G.assets.GetFontOrPanic(fontName),
+		content:      content,
+		color:        color.White,
+		outlineColor: color.Black,
+		shadowColor:  color.Black,
+	}
+	t.relayout()
+
+	return t
+}
+
+// NewLocalizedText creates a text showing the translation of key, see SetKey.
+func NewLocalizedText(fontName, key string, params map[string]any) *Text {
+	t := NewText(fontName, "")
+	t.SetKey(key, params)
+	return t
+}
+
+func (t *Text) Text() string {
+	t.translate()
+	return t.content
+}
+
+// Dim returns the dimensions of the text, after translating it again if the locale changed.
+func (t *Text) Dim() *Vec2[uint32] {
+	t.translate()
+	return t.Object.Dim()
+}
+
+// SetText shows a fixed content, which is not translated.
+func (t *Text) SetText(content string) {
+	t.key = ""
+	t.setContent(content)
+}
+
+// SetKey shows the translation of key with the placeholders replaced by params. The text is translated again
+// whenever the locale changes.
+func (t *Text) SetKey(key string, params map[string]any) {
+	t.key = key
+	t.params = params
+	t.revision = G.localizer.revision
+	t.setContent(G.localizer.Translate(key, params))
+}
+
+// translate translates the key again if the locale changed since the last translation.
+func (t *Text) translate() {
+	if t.key != "" && t.revision != G.localizer.revision {
+		t.revision = G.localizer.revision
+		t.setContent(G.localizer.Translate(t.key, t.params))
+	}
+}
+
+func (t *Text) setContent(content string) {
+	if content == t.content {
+		return
+	}
+	t.content = content
+	t.relayout()
+}
+
+func (t *Text) SetFont(f Font) {
+	t.font = f
+	t.relayout()
+}
+
+func (t *Text) SetColor(c color.Color) {
+	t.color = c
+	t.dirty = true
+}
+
+func (t *Text) SetAlign(a Align) {
+	t.align = a
+	t.dirty = true
+}
+
+// SetWrapWidth sets the width in pixels at which lines are wrapped between words.
+// Zero disables wrapping.
+func (t *Text) SetWrapWidth(w int) {
+	t.wrapWidth = w
+	t.relayout()
+}
+
+// SetLineSpacing sets the additional space in pixels between two lines.
+func (t *Text) SetLineSpacing(s int) {
+	t.lineSpacing = s
+	t.relayout()
+}
+
+// SetOutline draws an outline with the given width in pixels around all glyphs. Zero disables the outline.
+func (t *Text) SetOutline(width int, c color.Color) {
+	t.outline = width
+	t.outlineColor = c
+	t.relayout()
+}
+
+// SetShadow draws a shadow behind all glyphs, displaced by x and y pixels. Zero displacement disables the shadow.
+func (t *Text) SetShadow(x, y int, c color.Color) {
+	t.shadow = Vec2[int]{X: x, Y: y}
+	t.shadowColor = c
+	t.relayout()
+}
+
+// relayout breaks the content into lines and updates the dimensions, which do not include outline and shadow.
+func (t *Text) relayout() {
+	t.lines = layoutText(t.font, t.content, t.wrapWidth)
+
+	width := t.wrapWidth
+	for _, l := range t.lines {
+		width = max(width, l.width)
+	}
+	height := len(t.lines)*t.font.LineHeight() + (len(t.lines)-1)*t.lineSpacing
+	t.SetDim(uint32(width), uint32(height))
+
+	t.margin = t.outline + max(abs(t.shadow.X), abs(t.shadow.Y))
+	t.dirty = true
+}
+
+func (t *Text) render() {
+	t.dirty = false
+
+	w := int(t.Dim().X) + 2*t.margin
+	h := int(t.Dim().Y) + 2*t.margin
+	if t.image != nil && t.image.Bounds().Dx() == w && t.image.Bounds().Dy() == h {
+		t.image.Clear()
+	} else {
+		if t.image != nil {
+			t.image.Dispose()
+			t.image = nil
+		}
+		if w == 0 || h == 0 {
+			return
+		}
+		t.image = ebiten.NewImage(w, h)
+	}
+
+	if t.shadow.X != 0 || t.shadow.Y != 0 {
+		t.drawLines(t.shadow.X, t.shadow.Y, t.shadowColor)
+	}
+	for dy := -t.outline; dy <= t.outline; dy++ {
+		for dx := -t.outline; dx <= t.outline; dx++ {
+			if dx != 0 || dy != 0 {
+				t.drawLines(dx, dy, t.outlineColor)
+			}
+		}
+	}
+	t.drawLines(0, 0, t.color)
+}
+
+func (t *Text) drawLines(dx, dy int, clr color.Color) {
+	y := t.margin + dy
+	for _, l := range t.lines {
+		x := t.margin + dx
+		switch t.align {
+		case AlignCenter:
+			x += (int(t.Dim().X) - l.width) / 2
+		case AlignRight:
+			x += int(t.Dim().X) - l.width
+		}
+		for i, r := range l.runes {
+			if i > 0 {
+				x += t.font.Kern(l.runes[i-1], r)
+			}
+			t.font.drawGlyph(t.image, r, x, y, clr)
+			x += t.font.Advance(r)
+		}
+		y += t.font.LineHeight() + t.lineSpacing
+	}
+}
+
+func (t *Text) ApplyEffect(e Effect) {
+	e.Reset()
+	e.Start()
+	t.effects = append(t.effects, e)
+}
+
+func (t *Text) Update() {
+	t.translate()
+	t.Object.Update()
+	for j := 0; j < len(t.effects); j++ {
+		finished := t.effects[j].Update()
+		if finished {
+			t.effects = append(t.effects[:j], t.effects[j+1:]...)
+		}
+	}
+}
+
+func (t *Text) draw(target *ebiten.Image, op colorm.DrawImageOptions) {
+	t.translate()
+	if t.dirty {
+		t.render()
+	}
+	if t.image == nil {
+		return
+	}
+
+	cm := colorm.ColorM{}
+	t.transform(&op, t.image.Bounds().Dx(), t.image.Bounds().Dy())
+	op.GeoM.Translate(float64(t.PixelPos().X-t.margin), float64(t.PixelPos().Y-t.margin))
+	for i := 0; i < len(t.effects); i++ {
+		t.effects[i].modifyDraw(&op)
+	}
+	colorm.DrawImage(target, t.image, cm, &op)
+	for i := 0; i < len(t.effects); i++ {
+		t.effects[i].draw(target, op)
+	}
+}
@@ -0,0 +1,84 @@
+package vigor
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// monoFont is a font where every glyph is 4 pixels wide, "AV" is kerned by -1.
+type monoFont struct{}
+
+func (monoFont) LineHeight() int    { return 8 }
+func (monoFont) Advance(r rune) int { return 4 }
+func (monoFont) Kern(a, b rune) int {
+	if a == 'A' && b == 'V' {
+		return -1
+	}
+	return 0
+}
+func (monoFont) drawGlyph(*ebiten.Image, rune, int, int, color.Color) {}
+
+func lineStrings(lines []textLine) []string {
+	strs := []string{}
+	for _, l := range lines {
+		strs = append(strs, string(l.runes))
+	}
+	return strs
+}
+
+func TestLayoutText(t *testing.T) {
+	testcases := []struct {
+		name      string
+		text      string
+		wrapWidth int
+		lines     []string
+		widths    []int
+	}{
+		{
+			name:      "no wrapping",
+			text:      "hello world",
+			wrapWidth: 0,
+			lines:     []string{"hello world"},
+			widths:    []int{44},
+		},
+		{
+			name:      "newlines",
+			text:      "a\nbc\n",
+			wrapWidth: 0,
+			lines:     []string{"a", "bc", ""},
+			widths:    []int{4, 8, 0},
+		},
+		{
+			name:      "wrap between words",
+			text:      "the quick brown fox",
+			wrapWidth: 40,
+			lines:     []string{"the quick", "brown fox"},
+			widths:    []int{36, 36},
+		},
+		{
+			name:      "long word is not broken",
+			text:      "a verylongword b",
+			wrapWidth: 20,
+			lines:     []string{"a", "verylongword", "b"},
+			widths:    []int{4, 48, 4},
+		},
+		{
+			name:      "kerning",
+			text:      "AVA",
+			wrapWidth: 0,
+			lines:     []string{"AVA"},
+			widths:    []int{11},
+		},
+	}
+
+	for _, tc := range testcases {
+		lines := layoutText(monoFont{}, tc.text, tc.wrapWidth)
+		assert.Equal(t, tc.lines, lineStrings(lines), tc.name)
+		for i, l := range lines {
+			assert.Equal(t, tc.widths[i], l.width, tc.name)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package vigor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+var ErrNoPrefixFrames = fmt.Errorf("no frames match the prefix")
+
+// texturePackerData is a sprite sheet JSON in the TexturePacker hash or array format.
+type texturePackerData struct {
+	Frames sheetFrames `json:"frames"`
+	Meta   struct {
+		Image string `json:"image"`
+	} `json:"meta"`
+}
+
+// prefixFrames returns the indices of all frames whose names start with prefix in natural order.
+func prefixFrames(frames sheetFrames, prefix string) []int {
+	indices := []int{}
+	for i, f := range frames {
+		if strings.HasPrefix(f.Filename, prefix) {
+			indices = append(indices, i)
+		}
+	}
+	sort.SliceStable(indices, func(a, b int) bool {
+		return naturalLess(frames[indices[a]].Filename, frames[indices[b]].Filename)
+	})
+	return indices
+}
+
+// loadTexturePacker loads a TexturePacker JSON and the sprite sheet it references.
+// Every frame becomes an image named like the frame and every configured animation a template.
+func (r *AssetManager) loadTexturePacker(cfg TexturePackerConfig) error {
+	fpath := path.Join(r.RootPath, cfg.Path)
+	raw, err := os.ReadFile(fpath)
+	if err != nil {
+		return err
+	}
+	data := &texturePackerData{}
+	if err := json.Unmarshal(raw, data); err != nil {
+		return err
+	}
+
+	sheet, err := r.loadImage(path.Join(path.Dir(fpath), data.Meta.Image))
+	if err != nil {
+		return err
+	}
+	images, transforms := sliceFrames(sheet, data.Frames)
+	for i, f := range data.Frames {
+		r.Images[f.Filename] = images[i]
+		r.imageFrames[f.Filename] = f
+	}
+
+	for animName, anim := range cfg.Animations {
+		indices := prefixFrames(data.Frames, anim.Prefix)
+		if len(indices) == 0 {
+			return fmt.Errorf("%w: %s", ErrNoPrefixFrames, anim.Prefix)
+		}
+		f, err := parseEaseFunc(anim.EaseFunc)
+		if err != nil {
+			return err
+		}
+
+		t := &AnimationTemplate{
+			Sheet:       sheet,
+			EaseFunc:    f,
+			Images:      []*ebiten.Image{},
+			Frames:      []int{},
+			FrameWidth:  data.Frames[indices[0]].SourceSize.W,
+			FrameHeight: data.Frames[indices[0]].SourceSize.H,
+			Duration:    time.Duration(anim.Duration * float64(time.Second)),
+			Looped:      anim.Looped,
+		}
+		for i, index := range indices {
+			t.Images = append(t.Images, images[index])
+			t.frameTransforms = append(t.frameTransforms, transforms[index])
+			t.Frames = append(t.Frames, i)
+		}
+		r.AnimationTemplates[animName] = t
+	}
+
+	return nil
+}
+
+// loadTexturePackerAssets loads TexturePacker sprite sheets. Their frames and animation templates are added
+// to the images and animation templates directly, since the sheets themselves have no name.
+func loadTexturePackerAssets(ctx *AssetContext, raw json.RawMessage) (map[string]any, error) {
+	cfg := map[string]TexturePackerConfig{}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, err
+	}
+	for name, tp := range cfg {
+		if err := ctx.Assets.loadTexturePacker(tp); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return map[string]any{}, nil
+}
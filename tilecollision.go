@@ -0,0 +1,232 @@
+package vigor
+
+import (
+	"math"
+)
+
+// TileCollision describes how objects collide with a tile.
+type TileCollision uint8
+
+const (
+	TileEmpty TileCollision = iota
+	// TileOneWay tiles only stop objects that fall onto them from above.
+	TileOneWay
+	// TileSlopeUp tiles have a floor that rises from the bottom left to the top right corner.
+	TileSlopeUp
+	// TileSlopeDown tiles have a floor that falls from the top left to the bottom right corner.
+	TileSlopeDown
+	TileSolid
+)
+
+// collisionEpsilon is the distance an object may already overlap a tile and still be separated from it.
+const collisionEpsilon = 0.01
+
+type collidable interface {
+	collisionObject() *Object
+}
+
+// SetCollisionLayers sets the layers that objects collide with. By default all layers of the tilemap are used.
+func (t *Tilemap) SetCollisionLayers(layerNames ...string) {
+	t.collisionLayers = []*tilemapLayer{}
+	for _, name := range layerNames {
+		if l := t.layer(name); l != nil {
+			t.collisionLayers = append(t.collisionLayers, l)
+		}
+	}
+}
+
+// SetTileCollision overrides the collision of all tiles with the global tile id of the given tile.
+func (t *Tilemap) SetTileCollision(tile Tile, c TileCollision) {
+	t.tileCollisions[tile.GID()] = c
+}
+
+// tileCollision returns the collision of a tile. Unless overridden it is read from the "collision" property
+// of the tile in its tileset ("solid", "oneway", "slope_up" or "slope_down"), or a boolean "solid" property.
+func (t *Tilemap) tileCollision(tile Tile) TileCollision {
+	if tile.GID() == 0 {
+		return TileEmpty
+	}
+
+	c, ok := t.tileCollisions[tile.GID()]
+	if !ok {
+		ts, id := t.tmap.tileset(tile)
+		if ts == nil || ts.Tiles[id] == nil {
+			return TileEmpty
+		}
+		props := ts.Tiles[id].Properties
+		if name, isString := props["collision"].(string); isString {
+			c = tileCollisionMappings[name]
+		} else if solid, isBool := props["solid"].(bool); isBool && solid {
+			c = TileSolid
+		}
+	}
+
+	// Horizontally flipped slopes fall the other way.
+	if tile&TileFlipH != 0 {
+		switch c {
+		case TileSlopeUp:
+			c = TileSlopeDown
+		case TileSlopeDown:
+			c = TileSlopeUp
+		}
+	}
+	return c
+}
+
+// TileCollisionAt returns the collision of the tile at the grid coordinate of a layer.
+func (t *Tilemap) TileCollisionAt(layer string, x, y int) TileCollision {
+	return t.tileCollision(t.GetTile(layer, x, y))
+}
+
+// eachCollisionTile calls f with the collision and world rectangle of every non-empty tile in the collision layers
+// that overlaps the area. The neighbors are the collisions of the tiles left and right of it.
+func (t *Tilemap) eachCollisionTile(area Rect[float32], f func(c TileCollision, r Rect[float32], neighbors [2]TileCollision)) {
+	layers := t.collisionLayers
+	if layers == nil {
+		layers = t.layers
+	}
+	tw, th := float32(t.tmap.TileWidth), float32(t.tmap.TileHeight)
+
+	for _, l := range layers {
+		origin := Vec2[float32]{X: t.pos.X + l.Offset.X, Y: t.pos.Y + l.Offset.Y}
+		x0 := max(0, int(math.Floor(float64((area.Point.X-origin.X)/tw))))
+		y0 := max(0, int(math.Floor(float64((area.Point.Y-origin.Y)/th))))
+		x1 := min(l.Width, int(math.Ceil(float64((area.Point.X+area.Dim.X-origin.X)/tw))))
+		y1 := min(l.Height, int(math.Ceil(float64((area.Point.Y+area.Dim.Y-origin.Y)/th))))
+
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				c := t.tileCollision(l.Tiles[y*l.Width+x])
+				if c == TileEmpty {
+					continue
+				}
+				neighbors := [2]TileCollision{}
+				if x > 0 {
+					neighbors[0] = t.tileCollision(l.Tiles[y*l.Width+x-1])
+				}
+				if x < l.Width-1 {
+					neighbors[1] = t.tileCollision(l.Tiles[y*l.Width+x+1])
+				}
+				f(c, Rect[float32]{
+					Point: Vec2[float32]{X: origin.X + float32(x)*tw, Y: origin.Y + float32(y)*th},
+					Dim:   Vec2[float32]{X: tw, Y: th},
+				}, neighbors)
+			}
+		}
+	}
+}
+
+// Collide pushes an object out of the tiles it moved into since its last update and returns true if it did.
+// The side a tile is hit on is found with the position before the update, so Collide must be called after
+// Update. The velocity towards a hit tile is zeroed and the hit sides can be queried with Touching
+// until the first Collide after the next Update.
+// Tiles are placed in world space at the position of the tilemap, the camera is not taken into account.
+// On slopes the floor is taken at the horizontal center of the object.
+func (t *Tilemap) Collide(c collidable) bool {
+	o := c.collisionObject()
+	// Collisions with several tilemaps after one update add up.
+	if !o.collided {
+		o.touching = 0
+		o.collided = true
+	}
+	if o.dim.X == 0 || o.dim.Y == 0 {
+		return false
+	}
+	w, h := float32(o.dim.X), float32(o.dim.Y)
+	dx, dy := o.pos.X-o.lastPos.X, o.pos.Y-o.lastPos.Y
+	lastBottom := o.lastPos.Y + h
+	// Objects walking up a slope sink into it by up to step, which must not stop them.
+	step := min(abs(dx)*float32(t.tmap.TileHeight)/float32(t.tmap.TileWidth), float32(t.tmap.TileHeight)) + collisionEpsilon
+	hit := false
+
+	// Horizontal movement is resolved first, at the last vertical position.
+	if dx != 0 {
+		area := Rect[float32]{
+			Point: Vec2[float32]{X: min(o.pos.X, o.lastPos.X), Y: o.lastPos.Y},
+			Dim:   Vec2[float32]{X: abs(dx) + w, Y: h},
+		}
+		newX := o.pos.X
+		t.eachCollisionTile(area, func(c TileCollision, r Rect[float32], neighbors [2]TileCollision) {
+			if c != TileSolid {
+				return
+			}
+			// Tiles at the top of a slope are walked onto and handled as floor.
+			if atSlopeTop(dx, neighbors) {
+				return
+			}
+			if dx > 0 && o.lastPos.X+w <= r.Point.X+collisionEpsilon && r.Point.X-w < newX {
+				newX = r.Point.X - w
+				o.touching |= TouchWallRight
+				hit = true
+			} else if dx < 0 && o.lastPos.X >= r.Point.X+r.Dim.X-collisionEpsilon && r.Point.X+r.Dim.X > newX {
+				newX = r.Point.X + r.Dim.X
+				o.touching |= TouchWallLeft
+				hit = true
+			}
+		})
+		if newX != o.pos.X {
+			o.pos.X = newX
+			o.vel.X = 0
+		}
+	}
+
+	area := Rect[float32]{
+		Point: Vec2[float32]{X: o.pos.X, Y: min(o.pos.Y, o.lastPos.Y)},
+		Dim:   Vec2[float32]{X: w, Y: abs(dy) + h},
+	}
+	if dy >= 0 {
+		// Objects resting on the floor without moving still touch it.
+		area.Dim.Y += collisionEpsilon
+	}
+	newY := o.pos.Y
+	center := o.pos.X + w/2
+	t.eachCollisionTile(area, func(c TileCollision, r Rect[float32], neighbors [2]TileCollision) {
+		top := r.Point.Y
+		// Only slopes and the tiles at their top are landed on from below their top, flat floors
+		// must be above the object before, or fast objects would be lifted onto walls and platforms.
+		tolerance := float32(collisionEpsilon)
+		if c == TileSolid && atSlopeTop(dx, neighbors) {
+			tolerance = step
+		}
+		switch c {
+		case TileSlopeUp, TileSlopeDown:
+			if dy < 0 || center < r.Point.X || center >= r.Point.X+r.Dim.X {
+				return
+			}
+			frac := (center - r.Point.X) / r.Dim.X
+			if c == TileSlopeUp {
+				top = r.Point.Y + r.Dim.Y - frac*r.Dim.Y
+			} else {
+				top = r.Point.Y + frac*r.Dim.Y
+			}
+			tolerance = step
+			fallthrough
+		case TileOneWay:
+			if dy < 0 {
+				return
+			}
+			fallthrough
+		case TileSolid:
+			if dy >= 0 && lastBottom <= top+tolerance && top-h <= newY+collisionEpsilon {
+				newY = min(newY, top-h)
+				o.touching |= TouchFloor
+				hit = true
+			} else if c == TileSolid && dy < 0 && o.lastPos.Y >= r.Point.Y+r.Dim.Y-collisionEpsilon && r.Point.Y+r.Dim.Y > newY {
+				newY = r.Point.Y + r.Dim.Y
+				o.touching |= TouchCeiling
+				hit = true
+			}
+		}
+	})
+	if hit && (newY < o.pos.Y && o.vel.Y > 0 || newY > o.pos.Y && o.vel.Y < 0 || o.IsTouching(TouchFloor) && o.vel.Y > 0) {
+		o.vel.Y = 0
+	}
+	o.pos.Y = newY
+
+	return hit
+}
+
+// atSlopeTop reports whether a tile with the given neighbors is entered from the top of a slope next to it.
+func atSlopeTop(dx float32, neighbors [2]TileCollision) bool {
+	return dx > 0 && neighbors[0] == TileSlopeUp || dx < 0 && neighbors[1] == TileSlopeDown
+}
@@ -0,0 +1,128 @@
+package vigor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newCollisionTestTilemap creates a map of 8x4 tiles with 8 pixels each:
+//
+//	.......#
+//	.....-..
+//	#./#..#.
+//	########
+//
+// where # is solid, - is one way and / is a slope.
+func newCollisionTestTilemap() *Tilemap {
+	m := &TiledMap{
+		Width:      8,
+		Height:     4,
+		TileWidth:  8,
+		TileHeight: 8,
+		Tilesets: []*Tileset{{
+			FirstGID:   1,
+			TileWidth:  8,
+			TileHeight: 8,
+			Tiles: map[int]*TileInfo{
+				0: {Properties: map[string]any{"solid": true}},
+				1: {Properties: map[string]any{"collision": "oneway"}},
+				2: {Properties: map[string]any{"collision": "slope_up"}},
+			},
+		}},
+		Layers: []*TileLayer{{
+			Name:   "ground",
+			Width:  8,
+			Height: 4,
+			Tiles: []Tile{
+				0, 0, 0, 0, 0, 0, 0, 1,
+				0, 0, 0, 0, 0, 2, 0, 0,
+				1, 0, 3, 1, 0, 0, 1, 0,
+				1, 1, 1, 1, 1, 1, 1, 1,
+			},
+		}},
+	}
+	return newTilemap(m)
+}
+
+func TestTileCollision(t *testing.T) {
+	tm := newCollisionTestTilemap()
+
+	assert.Equal(t, TileSolid, tm.TileCollisionAt("ground", 0, 2))
+	assert.Equal(t, TileOneWay, tm.TileCollisionAt("ground", 5, 1))
+	assert.Equal(t, TileSlopeUp, tm.TileCollisionAt("ground", 2, 2))
+	assert.Equal(t, TileSlopeDown, tm.tileCollision(3|TileFlipH))
+	assert.Equal(t, TileEmpty, tm.TileCollisionAt("ground", 1, 2))
+	assert.Equal(t, TileEmpty, tm.TileCollisionAt("ground", 9, 2))
+
+	tm.SetTileCollision(2, TileSolid)
+	assert.Equal(t, TileSolid, tm.TileCollisionAt("ground", 5, 1))
+}
+
+func TestTilemapCollide(t *testing.T) {
+	testcases := []struct {
+		name     string
+		last     Vec2[float32]
+		pos      Vec2[float32]
+		vel      Vec2[float32]
+		expected Vec2[float32]
+		touching Touch
+	}{
+		{name: "fall onto floor", last: Vec2[float32]{X: 10, Y: 16}, pos: Vec2[float32]{X: 10, Y: 21}, vel: Vec2[float32]{Y: 50}, expected: Vec2[float32]{X: 10, Y: 20}, touching: TouchFloor},
+		{name: "rest on floor", last: Vec2[float32]{X: 10, Y: 20}, pos: Vec2[float32]{X: 10, Y: 20}, vel: Vec2[float32]{Y: 5}, expected: Vec2[float32]{X: 10, Y: 20}, touching: TouchFloor},
+		{name: "wall on the left", last: Vec2[float32]{X: 9, Y: 17}, pos: Vec2[float32]{X: 6, Y: 17}, vel: Vec2[float32]{X: -30}, expected: Vec2[float32]{X: 8, Y: 17}, touching: TouchWallLeft},
+		{name: "wall on the right", last: Vec2[float32]{X: 42, Y: 17}, pos: Vec2[float32]{X: 46, Y: 17}, vel: Vec2[float32]{X: 40}, expected: Vec2[float32]{X: 44, Y: 17}, touching: TouchWallRight},
+		{name: "moving too fast to touch the wall", last: Vec2[float32]{X: 33, Y: 17}, pos: Vec2[float32]{X: 60, Y: 17}, vel: Vec2[float32]{X: 220}, expected: Vec2[float32]{X: 44, Y: 17}, touching: TouchWallRight},
+		{name: "ceiling", last: Vec2[float32]{X: 57, Y: 9}, pos: Vec2[float32]{X: 57, Y: 6}, vel: Vec2[float32]{Y: -30}, expected: Vec2[float32]{X: 57, Y: 8}, touching: TouchCeiling},
+		{name: "land on one way", last: Vec2[float32]{X: 41, Y: 3}, pos: Vec2[float32]{X: 41, Y: 6}, vel: Vec2[float32]{Y: 30}, expected: Vec2[float32]{X: 41, Y: 4}, touching: TouchFloor},
+		{name: "jump through one way", last: Vec2[float32]{X: 41, Y: 17}, pos: Vec2[float32]{X: 41, Y: 13}, vel: Vec2[float32]{Y: -40}, expected: Vec2[float32]{X: 41, Y: 13}},
+		{name: "walk from slope onto plateau", last: Vec2[float32]{X: 21, Y: 13}, pos: Vec2[float32]{X: 23, Y: 13.2}, vel: Vec2[float32]{X: 10, Y: 5}, expected: Vec2[float32]{X: 23, Y: 12}, touching: TouchFloor},
+		{name: "fast mover hits one tile high wall", last: Vec2[float32]{X: 38, Y: 20}, pos: Vec2[float32]{X: 50, Y: 20.5}, vel: Vec2[float32]{X: 120, Y: 5}, expected: Vec2[float32]{X: 44, Y: 20}, touching: TouchFloor | TouchWallRight},
+		{name: "fast mover passes beside one way", last: Vec2[float32]{X: 50, Y: 12}, pos: Vec2[float32]{X: 40, Y: 12.5}, vel: Vec2[float32]{X: -100, Y: 5}, expected: Vec2[float32]{X: 40, Y: 12.5}},
+		{name: "walk up slope", last: Vec2[float32]{X: 17, Y: 16}, pos: Vec2[float32]{X: 18, Y: 16.5}, vel: Vec2[float32]{X: 10, Y: 5}, expected: Vec2[float32]{X: 18, Y: 16}, touching: TouchFloor},
+	}
+
+	for _, tc := range testcases {
+		tm := newCollisionTestTilemap()
+		o := newTestObject(tc.pos.X, tc.pos.Y, 4, 4)
+		o.lastPos = tc.last
+		o.vel = tc.vel
+
+		assert.Equal(t, tc.touching != 0, tm.Collide(&o), tc.name)
+		assert.Equal(t, tc.expected, o.pos, tc.name)
+		assert.Equal(t, tc.touching, o.Touching(), tc.name)
+		if tc.touching == 0 {
+			assert.Equal(t, tc.vel, o.vel, tc.name)
+		} else if tc.touching&(TouchWallLeft|TouchWallRight) != 0 {
+			assert.Zero(t, o.vel.X, tc.name)
+		} else {
+			assert.Zero(t, o.vel.Y, tc.name)
+		}
+	}
+}
+
+func TestTouchingReset(t *testing.T) {
+	o := newTestObject(1, 2, 4, 4)
+	o.touching = TouchFloor | TouchWallLeft
+	assert.True(t, o.IsTouching(TouchFloor))
+	assert.False(t, o.IsTouching(TouchCeiling))
+
+	// The flags stay set until the next collision check.
+	o.Update()
+	assert.Equal(t, TouchFloor|TouchWallLeft, o.Touching())
+	assert.Equal(t, Vec2[float32]{X: 1, Y: 2}, o.lastPos)
+
+	tm := newCollisionTestTilemap()
+	assert.False(t, tm.Collide(&o))
+	assert.Equal(t, Touch(0), o.Touching())
+
+	// Collisions with several tilemaps after one update add up.
+	o = newTestObject(10, 21, 4, 4)
+	o.lastPos = Vec2[float32]{X: 10, Y: 16}
+	o.vel = Vec2[float32]{Y: 50}
+	assert.True(t, tm.Collide(&o))
+	far := newCollisionTestTilemap()
+	far.Pos().X = 1000
+	assert.False(t, far.Collide(&o))
+	assert.Equal(t, TouchFloor, o.Touching())
+}
@@ -0,0 +1,921 @@
+package vigor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+var (
+	ErrUnsupportedOrientation = fmt.Errorf("only orthogonal maps are supported")
+	ErrInfiniteMap            = fmt.Errorf("infinite maps are not supported")
+	ErrUnsupportedEncoding    = fmt.Errorf("unsupported tile data encoding")
+	ErrUnsupportedTileset     = fmt.Errorf("image collection tilesets are not supported")
+	ErrInvalidTileset         = fmt.Errorf("invalid tileset")
+	ErrTileDataSize           = fmt.Errorf("tile data does not match the layer size")
+)
+
+// Tile is a global tile id as stored by Tiled. The highest bits are flip flags, zero is an empty cell.
+type Tile uint32
+
+const (
+	TileFlipH Tile = 1 << 31
+	TileFlipV Tile = 1 << 30
+	// TileFlipD swaps the x and y axis. It is applied before the horizontal and vertical flips.
+	TileFlipD Tile = 1 << 29
+	// tileFlags also contains the hexagonal rotation flag, which is ignored.
+	tileFlags = TileFlipH | TileFlipV | TileFlipD | 1<<28
+)
+
+// GID returns the global tile id without flip flags.
+func (t Tile) GID() uint32 {
+	return uint32(t &^ tileFlags)
+}
+
+type TileFrame struct {
+	TileID   int
+	Duration time.Duration
+}
+
+// TileInfo holds the additional data of a single tile in a tileset.
+type TileInfo struct {
+	Properties map[string]any
+	Class      string
+	Animation  []TileFrame
+}
+
+type Tileset struct {
+	Tiles      map[int]*TileInfo
+	Name       string
+	images     []*ebiten.Image
+	FirstGID   uint32
+	TileWidth  int
+	TileHeight int
+}
+
+// image returns the image of a local tile id, animated tiles show the frame at the elapsed time.
+func (ts *Tileset) image(id int, elapsed time.Duration) *ebiten.Image {
+	if info, ok := ts.Tiles[id]; ok && len(info.Animation) > 0 {
+		total := time.Duration(0)
+		for _, f := range info.Animation {
+			total += f.Duration
+		}
+		if total > 0 {
+			elapsed %= total
+		}
+		for _, f := range info.Animation {
+			if elapsed < f.Duration {
+				id = f.TileID
+				break
+			}
+			elapsed -= f.Duration
+		}
+	}
+	if id < 0 || id >= len(ts.images) {
+		return nil
+	}
+	return ts.images[id]
+}
+
+type TileLayer struct {
+	Properties map[string]any
+	Name       string
+	// Tiles are stored row by row.
+	Tiles    []Tile
+	Offset   Vec2[float32]
+	Parallax Vec2[float32]
+	Width    int
+	Height   int
+	Opacity  float32
+	Visible  bool
+}
+
+// TiledObject is an object of an object layer. Game code usually turns them into sprites by class.
+// The position is in pixels, for tile objects it is the bottom left corner.
+type TiledObject struct {
+	Properties map[string]any
+	Name       string
+	Class      string
+	Layer      string
+	Polygon    []Vec2[float32]
+	Polyline   []Vec2[float32]
+	Pos        Vec2[float32]
+	Size       Vec2[float32]
+	Rotation   float32
+	ID         int
+	Tile       Tile
+	Point      bool
+	Ellipse    bool
+	Visible    bool
+}
+
+// TiledMap is an orthogonal map made with the Tiled editor.
+type TiledMap struct {
+	Properties map[string]any
+	Tilesets   []*Tileset
+	Layers     []*TileLayer
+	Objects    []TiledObject
+	Width      int
+	Height     int
+	TileWidth  int
+	TileHeight int
+}
+
+// Layer returns the tile layer with the given name or nil.
+func (m *TiledMap) Layer(name string) *TileLayer {
+	for _, l := range m.Layers {
+		if l.Name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// tileset returns the tileset a tile belongs to and the local id of the tile.
+func (m *TiledMap) tileset(t Tile) (*Tileset, int) {
+	gid := t.GID()
+	for i := len(m.Tilesets) - 1; i >= 0; i-- {
+		if ts := m.Tilesets[i]; ts.FirstGID <= gid {
+			return ts, int(gid - ts.FirstGID)
+		}
+	}
+	return nil, 0
+}
+
+// tileGeoM flips a tile of the given size in place, as described by its flags.
+func tileGeoM(t Tile, w, h int) ebiten.GeoM {
+	g := ebiten.GeoM{}
+	if t&TileFlipD != 0 {
+		g.SetElement(0, 0, 0)
+		g.SetElement(0, 1, 1)
+		g.SetElement(1, 0, 1)
+		g.SetElement(1, 1, 0)
+		w, h = h, w
+	}
+	if t&TileFlipH != 0 {
+		g.Scale(-1, 1)
+		g.Translate(float64(w), 0)
+	}
+	if t&TileFlipV != 0 {
+		g.Scale(1, -1)
+		g.Translate(0, float64(h))
+	}
+	return g
+}
+
+// The tiled* types mirror the JSON format of Tiled, the TMX format is converted into them.
+
+type tiledProperty struct {
+	Value any    `json:"value"`
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+}
+
+type tiledFrameData struct {
+	TileID   int `json:"tileid" xml:"tileid,attr"`
+	Duration int `json:"duration" xml:"duration,attr"`
+}
+
+type tiledTileData struct {
+	Type       string           `json:"type"`
+	Class      string           `json:"class"`
+	Properties []tiledProperty  `json:"properties"`
+	Animation  []tiledFrameData `json:"animation"`
+	ID         int              `json:"id"`
+}
+
+type tiledTilesetData struct {
+	Source     string          `json:"source"`
+	Name       string          `json:"name"`
+	Image      string          `json:"image"`
+	Tiles      []tiledTileData `json:"tiles"`
+	FirstGID   uint32          `json:"firstgid"`
+	TileWidth  int             `json:"tilewidth"`
+	TileHeight int             `json:"tileheight"`
+	Spacing    int             `json:"spacing"`
+	Margin     int             `json:"margin"`
+	Columns    int             `json:"columns"`
+	TileCount  int             `json:"tilecount"`
+}
+
+type tiledPoint struct {
+	X float32 `json:"x"`
+	Y float32 `json:"y"`
+}
+
+type tiledObjectData struct {
+	Name       string          `json:"name"`
+	Type       string          `json:"type"`
+	Class      string          `json:"class"`
+	Properties []tiledProperty `json:"properties"`
+	Polygon    []tiledPoint    `json:"polygon"`
+	Polyline   []tiledPoint    `json:"polyline"`
+	X          float32         `json:"x"`
+	Y          float32         `json:"y"`
+	Width      float32         `json:"width"`
+	Height     float32         `json:"height"`
+	Rotation   float32         `json:"rotation"`
+	ID         int             `json:"id"`
+	GID        Tile            `json:"gid"`
+	Point      bool            `json:"point"`
+	Ellipse    bool            `json:"ellipse"`
+	Visible    bool            `json:"visible"`
+}
+
+type tiledLayerData struct {
+	Type        string            `json:"type"`
+	Name        string            `json:"name"`
+	Encoding    string            `json:"encoding"`
+	Compression string            `json:"compression"`
+	Data        json.RawMessage   `json:"data"`
+	Properties  []tiledProperty   `json:"properties"`
+	Objects     []tiledObjectData `json:"objects"`
+	Layers      []tiledLayerData  `json:"layers"`
+	ParallaxX   *float32          `json:"parallaxx"`
+	ParallaxY   *float32          `json:"parallaxy"`
+	Opacity     *float32          `json:"opacity"`
+	OffsetX     float32           `json:"offsetx"`
+	OffsetY     float32           `json:"offsety"`
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	Visible     bool              `json:"visible"`
+
+	// tiles are decoded from Data.
+	tiles []Tile
+}
+
+type tiledMapData struct {
+	Orientation string             `json:"orientation"`
+	Layers      []tiledLayerData   `json:"layers"`
+	Tilesets    []tiledTilesetData `json:"tilesets"`
+	Properties  []tiledProperty    `json:"properties"`
+	Width       int                `json:"width"`
+	Height      int                `json:"height"`
+	TileWidth   int                `json:"tilewidth"`
+	TileHeight  int                `json:"tileheight"`
+	Infinite    bool               `json:"infinite"`
+}
+
+func parseTiledJSON(raw []byte) (*tiledMapData, error) {
+	data := &tiledMapData{}
+	if err := json.Unmarshal(raw, data); err != nil {
+		return nil, err
+	}
+	if err := decodeJSONLayers(data.Layers); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func decodeJSONLayers(layers []tiledLayerData) error {
+	for i := range layers {
+		l := &layers[i]
+		if err := decodeJSONLayers(l.Layers); err != nil {
+			return err
+		}
+		if l.Type != "tilelayer" {
+			continue
+		}
+		if l.Encoding == "base64" {
+			var payload string
+			if err := json.Unmarshal(l.Data, &payload); err != nil {
+				return err
+			}
+			tiles, err := decodeTiles(l.Encoding, l.Compression, payload)
+			if err != nil {
+				return fmt.Errorf("layer %s: %w", l.Name, err)
+			}
+			l.tiles = tiles
+			continue
+		}
+		if err := json.Unmarshal(l.Data, &l.tiles); err != nil {
+			return fmt.Errorf("layer %s: %w", l.Name, err)
+		}
+	}
+	return nil
+}
+
+// decodeTiles decodes csv or base64 tile data, which may be compressed with zlib or gzip.
+func decodeTiles(encoding, compression, payload string) ([]Tile, error) {
+	tiles := []Tile{}
+	switch encoding {
+	case "csv":
+		for _, field := range strings.Split(payload, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			v, err := strconv.ParseUint(field, 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			tiles = append(tiles, Tile(v))
+		}
+		return tiles, nil
+	case "base64":
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, encoding)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(payload))
+	if err != nil {
+		return nil, err
+	}
+	var r io.Reader = bytes.NewReader(raw)
+	switch compression {
+	case "":
+	case "zlib":
+		if r, err = zlib.NewReader(r); err != nil {
+			return nil, err
+		}
+	case "gzip":
+		if r, err = gzip.NewReader(r); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s compression", ErrUnsupportedEncoding, compression)
+	}
+	raw, err = io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw)%4 != 0 {
+		return nil, ErrTileDataSize
+	}
+	for i := 0; i < len(raw); i += 4 {
+		tiles = append(tiles, Tile(binary.LittleEndian.Uint32(raw[i:])))
+	}
+	return tiles, nil
+}
+
+// The tmx* types mirror the XML format of Tiled.
+
+type tmxProperty struct {
+	Name  string `xml:"name,attr"`
+	Type  string `xml:"type,attr"`
+	Value string `xml:"value,attr"`
+	// Text holds multiline string values.
+	Text string `xml:",chardata"`
+}
+
+type tmxTile struct {
+	Type       string           `xml:"type,attr"`
+	Class      string           `xml:"class,attr"`
+	Properties []tmxProperty    `xml:"properties>property"`
+	Animation  []tiledFrameData `xml:"animation>frame"`
+	ID         int              `xml:"id,attr"`
+}
+
+type tmxTileset struct {
+	Source string `xml:"source,attr"`
+	Name   string `xml:"name,attr"`
+	Image  struct {
+		Source string `xml:"source,attr"`
+	} `xml:"image"`
+	Tiles      []tmxTile `xml:"tile"`
+	FirstGID   uint32    `xml:"firstgid,attr"`
+	TileWidth  int       `xml:"tilewidth,attr"`
+	TileHeight int       `xml:"tileheight,attr"`
+	Spacing    int       `xml:"spacing,attr"`
+	Margin     int       `xml:"margin,attr"`
+	Columns    int       `xml:"columns,attr"`
+	TileCount  int       `xml:"tilecount,attr"`
+}
+
+type tmxPoints struct {
+	Points string `xml:"points,attr"`
+}
+
+type tmxObject struct {
+	Name       string        `xml:"name,attr"`
+	Type       string        `xml:"type,attr"`
+	Class      string        `xml:"class,attr"`
+	Properties []tmxProperty `xml:"properties>property"`
+	Polygon    *tmxPoints    `xml:"polygon"`
+	Polyline   *tmxPoints    `xml:"polyline"`
+	Point      *struct{}     `xml:"point"`
+	Ellipse    *struct{}     `xml:"ellipse"`
+	Visible    *int          `xml:"visible,attr"`
+	X          float32       `xml:"x,attr"`
+	Y          float32       `xml:"y,attr"`
+	Width      float32       `xml:"width,attr"`
+	Height     float32       `xml:"height,attr"`
+	Rotation   float32       `xml:"rotation,attr"`
+	ID         int           `xml:"id,attr"`
+	GID        Tile          `xml:"gid,attr"`
+}
+
+// tmxLayer is any child element of a map or group. Its kind is the element name.
+type tmxLayer struct {
+	XMLName    xml.Name
+	Name       string        `xml:"name,attr"`
+	Properties []tmxProperty `xml:"properties>property"`
+	Data       struct {
+		Encoding    string `xml:"encoding,attr"`
+		Compression string `xml:"compression,attr"`
+		Text        string `xml:",chardata"`
+		Tiles       []struct {
+			GID Tile `xml:"gid,attr"`
+		} `xml:"tile"`
+	} `xml:"data"`
+	Objects   []tmxObject `xml:"object"`
+	Layers    []tmxLayer  `xml:",any"`
+	ParallaxX *float32    `xml:"parallaxx,attr"`
+	ParallaxY *float32    `xml:"parallaxy,attr"`
+	Opacity   *float32    `xml:"opacity,attr"`
+	Visible   *int        `xml:"visible,attr"`
+	OffsetX   float32     `xml:"offsetx,attr"`
+	OffsetY   float32     `xml:"offsety,attr"`
+	Width     int         `xml:"width,attr"`
+	Height    int         `xml:"height,attr"`
+}
+
+type tmxMap struct {
+	Orientation string        `xml:"orientation,attr"`
+	Properties  []tmxProperty `xml:"properties>property"`
+	Tilesets    []tmxTileset  `xml:"tileset"`
+	Layers      []tmxLayer    `xml:",any"`
+	Width       int           `xml:"width,attr"`
+	Height      int           `xml:"height,attr"`
+	TileWidth   int           `xml:"tilewidth,attr"`
+	TileHeight  int           `xml:"tileheight,attr"`
+	Infinite    int           `xml:"infinite,attr"`
+}
+
+// parseTiledMap parses a TMX or JSON map, depending on the extension of its name.
+func parseTiledMap(name string, raw []byte) (*tiledMapData, error) {
+	if path.Ext(name) == ".tmx" {
+		return parseTMX(raw)
+	}
+	return parseTiledJSON(raw)
+}
+
+// parseTiledTileset parses a TSX or JSON tileset, depending on the extension of its name.
+func parseTiledTileset(name string, raw []byte) (*tiledTilesetData, error) {
+	var ts *tiledTilesetData
+	var err error
+	if path.Ext(name) == ".tsx" {
+		ts, err = parseTSX(raw)
+	} else {
+		ts = &tiledTilesetData{}
+		err = json.Unmarshal(raw, ts)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tileset %s: %w", name, err)
+	}
+	return ts, nil
+}
+
+func parseTMX(raw []byte) (*tiledMapData, error) {
+	m := tmxMap{}
+	if err := xml.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+
+	data := &tiledMapData{
+		Orientation: m.Orientation,
+		Properties:  tmxProperties(m.Properties),
+		Width:       m.Width,
+		Height:      m.Height,
+		TileWidth:   m.TileWidth,
+		TileHeight:  m.TileHeight,
+		Infinite:    m.Infinite != 0,
+	}
+	for _, ts := range m.Tilesets {
+		data.Tilesets = append(data.Tilesets, ts.data())
+	}
+	layers, err := tmxLayers(m.Layers)
+	if err != nil {
+		return nil, err
+	}
+	data.Layers = layers
+
+	return data, nil
+}
+
+func parseTSX(raw []byte) (*tiledTilesetData, error) {
+	ts := tmxTileset{}
+	if err := xml.Unmarshal(raw, &ts); err != nil {
+		return nil, err
+	}
+	data := ts.data()
+	return &data, nil
+}
+
+func (ts *tmxTileset) data() tiledTilesetData {
+	data := tiledTilesetData{
+		Source:     ts.Source,
+		Name:       ts.Name,
+		Image:      ts.Image.Source,
+		FirstGID:   ts.FirstGID,
+		TileWidth:  ts.TileWidth,
+		TileHeight: ts.TileHeight,
+		Spacing:    ts.Spacing,
+		Margin:     ts.Margin,
+		Columns:    ts.Columns,
+		TileCount:  ts.TileCount,
+	}
+	for _, t := range ts.Tiles {
+		data.Tiles = append(data.Tiles, tiledTileData{
+			Type:       t.Type,
+			Class:      t.Class,
+			Properties: tmxProperties(t.Properties),
+			Animation:  t.Animation,
+			ID:         t.ID,
+		})
+	}
+	return data
+}
+
+func tmxLayers(elements []tmxLayer) ([]tiledLayerData, error) {
+	layers := []tiledLayerData{}
+	for _, e := range elements {
+		l := tiledLayerData{
+			Name:       e.Name,
+			Properties: tmxProperties(e.Properties),
+			ParallaxX:  e.ParallaxX,
+			ParallaxY:  e.ParallaxY,
+			Opacity:    e.Opacity,
+			OffsetX:    e.OffsetX,
+			OffsetY:    e.OffsetY,
+			Width:      e.Width,
+			Height:     e.Height,
+			Visible:    e.Visible == nil || *e.Visible != 0,
+		}
+
+		switch e.XMLName.Local {
+		case "layer":
+			l.Type = "tilelayer"
+			if e.Data.Encoding == "" {
+				for _, t := range e.Data.Tiles {
+					l.tiles = append(l.tiles, t.GID)
+				}
+				break
+			}
+			tiles, err := decodeTiles(e.Data.Encoding, e.Data.Compression, e.Data.Text)
+			if err != nil {
+				return nil, fmt.Errorf("layer %s: %w", e.Name, err)
+			}
+			l.tiles = tiles
+		case "objectgroup":
+			l.Type = "objectgroup"
+			for _, o := range e.Objects {
+				l.Objects = append(l.Objects, o.data())
+			}
+		case "group":
+			l.Type = "group"
+			children, err := tmxLayers(e.Layers)
+			if err != nil {
+				return nil, err
+			}
+			l.Layers = children
+		default:
+			// Image layers and editor settings are not supported.
+			continue
+		}
+		layers = append(layers, l)
+	}
+	return layers, nil
+}
+
+func (o *tmxObject) data() tiledObjectData {
+	data := tiledObjectData{
+		Name:       o.Name,
+		Type:       o.Type,
+		Class:      o.Class,
+		Properties: tmxProperties(o.Properties),
+		X:          o.X,
+		Y:          o.Y,
+		Width:      o.Width,
+		Height:     o.Height,
+		Rotation:   o.Rotation,
+		ID:         o.ID,
+		GID:        o.GID,
+		Point:      o.Point != nil,
+		Ellipse:    o.Ellipse != nil,
+		Visible:    o.Visible == nil || *o.Visible != 0,
+	}
+	if o.Polygon != nil {
+		data.Polygon = parseTMXPoints(o.Polygon.Points)
+	}
+	if o.Polyline != nil {
+		data.Polyline = parseTMXPoints(o.Polyline.Points)
+	}
+	return data
+}
+
+// parseTMXPoints parses points like "0,0 16,8 0,8".
+func parseTMXPoints(s string) []tiledPoint {
+	points := []tiledPoint{}
+	for _, pair := range strings.Fields(s) {
+		xs, ys, _ := strings.Cut(pair, ",")
+		x, _ := strconv.ParseFloat(xs, 32)
+		y, _ := strconv.ParseFloat(ys, 32)
+		points = append(points, tiledPoint{X: float32(x), Y: float32(y)})
+	}
+	return points
+}
+
+// tmxProperties converts the string values to the types the JSON format uses.
+func tmxProperties(props []tmxProperty) []tiledProperty {
+	converted := []tiledProperty{}
+	for _, p := range props {
+		value := p.Value
+		if value == "" {
+			value = p.Text
+		}
+		cp := tiledProperty{Name: p.Name, Type: p.Type, Value: value}
+		switch p.Type {
+		case "int", "float", "object":
+			cp.Value, _ = strconv.ParseFloat(value, 64)
+		case "bool":
+			cp.Value = value == "true"
+		}
+		converted = append(converted, cp)
+	}
+	return converted
+}
+
+// tiledProperties converts properties to a map. Integers and object references are int,
+// floats are float64, booleans are bool and all others are string.
+func tiledProperties(props []tiledProperty) map[string]any {
+	m := map[string]any{}
+	for _, p := range props {
+		switch p.Type {
+		case "int", "object":
+			f, _ := p.Value.(float64)
+			m[p.Name] = int(f)
+		default:
+			m[p.Name] = p.Value
+		}
+	}
+	return m
+}
+
+// tiledLoader loads maps and their tilesets and images from a file system.
+type tiledLoader struct {
+	fsys fs.FS
+	// root is the path of the file system, which is used to share tileset images with other assets.
+	root     string
+	images   *imageCache
+	tilesets map[string]*tiledTilesetData
+	// decoded holds the maps, tilesets and images that were parsed in the background, it may be nil.
+	decoded *decodedFiles
+}
+
+func newTiledLoader(fsys fs.FS, root string, images *imageCache) *tiledLoader {
+	return &tiledLoader{
+		fsys:     fsys,
+		root:     root,
+		images:   images,
+		tilesets: map[string]*tiledTilesetData{},
+	}
+}
+
+func loadTilemapAssets(ctx *AssetContext, raw json.RawMessage) (map[string]any, error) {
+	cfg := map[string]TilemapConfig{}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, err
+	}
+	root := ctx.Root
+	if root == "" {
+		root = "."
+	}
+	tl := newTiledLoader(ctx.FS, root, ctx.Assets.images)
+	tl.decoded = ctx.Assets.decoded
+	maps := map[string]*TiledMap{}
+	for name, tm := range cfg {
+		m, err := tl.loadMap(path.Clean(tm.Path))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		maps[name] = m
+	}
+	return toAssets(maps), nil
+}
+
+func (l *tiledLoader) loadMap(name string) (*TiledMap, error) {
+	data, ok := l.decoded.tiledMap(path.Join(l.root, name))
+	if !ok {
+		raw, err := fs.ReadFile(l.fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = parseTiledMap(name, raw); err != nil {
+			return nil, err
+		}
+	}
+
+	if data.Orientation != "orthogonal" {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedOrientation, data.Orientation)
+	}
+	if data.Infinite {
+		return nil, ErrInfiniteMap
+	}
+
+	m := &TiledMap{
+		Properties: tiledProperties(data.Properties),
+		Tilesets:   []*Tileset{},
+		Layers:     []*TileLayer{},
+		Objects:    []TiledObject{},
+		Width:      data.Width,
+		Height:     data.Height,
+		TileWidth:  data.TileWidth,
+		TileHeight: data.TileHeight,
+	}
+
+	for _, tsData := range data.Tilesets {
+		dir := path.Dir(name)
+		if tsData.Source != "" {
+			firstGID := tsData.FirstGID
+			source := path.Join(dir, tsData.Source)
+			external, err := l.loadTileset(source)
+			if err != nil {
+				return nil, err
+			}
+			tsData = *external
+			tsData.FirstGID = firstGID
+			dir = path.Dir(source)
+		}
+		ts, err := l.buildTileset(tsData, dir)
+		if err != nil {
+			return nil, err
+		}
+		m.Tilesets = append(m.Tilesets, ts)
+	}
+	sort.Slice(m.Tilesets, func(a, b int) bool { return m.Tilesets[a].FirstGID < m.Tilesets[b].FirstGID })
+
+	if err := m.addLayers(data.Layers, Vec2[float32]{}, Vec2[float32]{X: 1, Y: 1}, 1, true); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// addLayers flattens groups, so their offset, parallax, opacity and visibility apply to their children.
+func (m *TiledMap) addLayers(layers []tiledLayerData, offset, parallax Vec2[float32], opacity float32, visible bool) error {
+	for _, data := range layers {
+		lOffset := Vec2[float32]{X: offset.X + data.OffsetX, Y: offset.Y + data.OffsetY}
+		lParallax := parallax
+		if data.ParallaxX != nil {
+			lParallax.X *= *data.ParallaxX
+		}
+		if data.ParallaxY != nil {
+			lParallax.Y *= *data.ParallaxY
+		}
+		lOpacity := opacity
+		if data.Opacity != nil {
+			lOpacity *= *data.Opacity
+		}
+		lVisible := visible && data.Visible
+
+		switch data.Type {
+		case "tilelayer":
+			if len(data.tiles) != data.Width*data.Height {
+				return fmt.Errorf("%w: %s", ErrTileDataSize, data.Name)
+			}
+			m.Layers = append(m.Layers, &TileLayer{
+				Properties: tiledProperties(data.Properties),
+				Name:       data.Name,
+				Tiles:      data.tiles,
+				Offset:     lOffset,
+				Parallax:   lParallax,
+				Width:      data.Width,
+				Height:     data.Height,
+				Opacity:    lOpacity,
+				Visible:    lVisible,
+			})
+		case "objectgroup":
+			for _, o := range data.Objects {
+				obj := TiledObject{
+					Properties: tiledProperties(o.Properties),
+					Name:       o.Name,
+					Class:      o.Class,
+					Layer:      data.Name,
+					Pos:        Vec2[float32]{X: lOffset.X + o.X, Y: lOffset.Y + o.Y},
+					Size:       Vec2[float32]{X: o.Width, Y: o.Height},
+					Rotation:   o.Rotation,
+					ID:         o.ID,
+					Tile:       o.GID,
+					Point:      o.Point,
+					Ellipse:    o.Ellipse,
+					Visible:    lVisible && o.Visible,
+				}
+				// Tiled before 1.9 called the class type.
+				if obj.Class == "" {
+					obj.Class = o.Type
+				}
+				for _, p := range o.Polygon {
+					obj.Polygon = append(obj.Polygon, Vec2[float32]{X: p.X, Y: p.Y})
+				}
+				for _, p := range o.Polyline {
+					obj.Polyline = append(obj.Polyline, Vec2[float32]{X: p.X, Y: p.Y})
+				}
+				m.Objects = append(m.Objects, obj)
+			}
+		case "group":
+			if err := m.addLayers(data.Layers, lOffset, lParallax, lOpacity, lVisible); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// loadTileset loads an external tileset once, it may be shared by multiple maps.
+func (l *tiledLoader) loadTileset(name string) (*tiledTilesetData, error) {
+	if ts, ok := l.tilesets[name]; ok {
+		return ts, nil
+	}
+	ts, ok := l.decoded.tiledTileset(path.Join(l.root, name))
+	if !ok {
+		raw, err := fs.ReadFile(l.fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		if ts, err = parseTiledTileset(name, raw); err != nil {
+			return nil, err
+		}
+	}
+	l.tilesets[name] = ts
+	return ts, nil
+}
+
+// buildTileset slices the tileset image. Its path is relative to dir.
+// If the number of columns is missing, it is derived from the image width.
+func (l *tiledLoader) buildTileset(data tiledTilesetData, dir string) (*Tileset, error) {
+	if data.Image == "" {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedTileset, data.Name)
+	}
+	if data.TileWidth <= 0 || data.TileHeight <= 0 {
+		return nil, fmt.Errorf("%w: %s: tile size %dx%d", ErrInvalidTileset, data.Name, data.TileWidth, data.TileHeight)
+	}
+	name := path.Join(dir, data.Image)
+	sheet, err := l.images.load(path.Join(l.root, name), func() (image.Image, error) {
+		if img, ok := l.decoded.decodedImage(path.Join(l.root, name)); ok {
+			return img, nil
+		}
+		f, err := l.fsys.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		decoded, _, err := image.Decode(f)
+		return decoded, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ts := &Tileset{
+		Tiles:      map[int]*TileInfo{},
+		Name:       data.Name,
+		images:     []*ebiten.Image{},
+		FirstGID:   data.FirstGID,
+		TileWidth:  data.TileWidth,
+		TileHeight: data.TileHeight,
+	}
+	if data.Columns <= 0 {
+		data.Columns = (sheet.Bounds().Dx() - 2*data.Margin + data.Spacing) / (data.TileWidth + data.Spacing)
+	}
+	if data.Columns <= 0 && data.TileCount > 0 {
+		return nil, fmt.Errorf("%w: %s: image is narrower than a tile", ErrInvalidTileset, data.Name)
+	}
+	for i := 0; i < data.TileCount; i++ {
+		col, row := i%data.Columns, i/data.Columns
+		x := data.Margin + col*(data.TileWidth+data.Spacing)
+		y := data.Margin + row*(data.TileHeight+data.Spacing)
+		ts.images = append(ts.images, sheet.SubImage(image.Rect(x, y, x+data.TileWidth, y+data.TileHeight)).(*ebiten.Image))
+	}
+	for _, t := range data.Tiles {
+		info := &TileInfo{
+			Properties: tiledProperties(t.Properties),
+			Class:      t.Class,
+			Animation:  []TileFrame{},
+		}
+		if info.Class == "" {
+			info.Class = t.Type
+		}
+		for _, f := range t.Animation {
+			info.Animation = append(info.Animation, TileFrame{TileID: f.TileID, Duration: time.Duration(f.Duration) * time.Millisecond})
+		}
+		ts.Tiles[t.ID] = info
+	}
+
+	return ts, nil
+}
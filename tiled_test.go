@@ -0,0 +1,342 @@
+package vigor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+const tiledJSON = `{
+ "orientation": "orthogonal", "infinite": false,
+ "width": 3, "height": 2, "tilewidth": 8, "tileheight": 8,
+ "properties": [{"name": "music", "type": "string", "value": "cave"}],
+ "tilesets": [{"firstgid": 1, "source": "../tilesets/terrain.tsx"}],
+ "layers": [
+  {"type": "tilelayer", "name": "ground", "width": 3, "height": 2, "opacity": 1, "visible": true,
+   "x": 0, "y": 0, "data": [1, 2, 0, 0, 2147483650, 1]},
+  {"type": "group", "name": "fg", "offsetx": 4, "parallaxx": 0.5, "opacity": 1, "visible": true, "layers": [
+   {"type": "tilelayer", "name": "deco", "width": 3, "height": 2, "opacity": 0.5, "visible": true,
+    "data": [0, 0, 0, 0, 0, 0]},
+   {"type": "objectgroup", "name": "spawns", "opacity": 1, "visible": true, "objects": [
+    {"id": 1, "name": "hero", "type": "player", "x": 8, "y": 16, "width": 0, "height": 0, "rotation": 0,
+     "visible": true, "properties": [{"name": "hp", "type": "int", "value": 3}]},
+    {"id": 2, "name": "", "type": "", "x": 0, "y": 0, "width": 0, "height": 0, "rotation": 0,
+     "visible": true, "polygon": [{"x": 0, "y": 0}, {"x": 8, "y": 0}, {"x": 0, "y": 8}]}
+   ]}
+  ]}
+ ]
+}`
+
+const tiledTMX = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" renderorder="right-down" width="3" height="2" tilewidth="8" tileheight="8" infinite="0">
+ <properties>
+  <property name="music" value="cave"/>
+ </properties>
+ <tileset firstgid="1" source="../tilesets/terrain.tsx"/>
+ <layer id="1" name="ground" width="3" height="2">
+  <data encoding="csv">
+1,2,0,
+0,2147483650,1
+</data>
+ </layer>
+ <group id="2" name="fg" offsetx="4" parallaxx="0.5">
+  <layer id="3" name="deco" width="3" height="2" opacity="0.5">
+   <data>
+    <tile/><tile/><tile/><tile/><tile/><tile/>
+   </data>
+  </layer>
+  <objectgroup id="4" name="spawns">
+   <object id="1" name="hero" type="player" x="8" y="16">
+    <properties>
+     <property name="hp" type="int" value="3"/>
+    </properties>
+   </object>
+   <object id="2" x="0" y="0">
+    <polygon points="0,0 8,0 0,8"/>
+   </object>
+  </objectgroup>
+ </group>
+</map>`
+
+const tiledTSX = `<?xml version="1.0" encoding="UTF-8"?>
+<tileset version="1.10" name="terrain" tilewidth="8" tileheight="8" tilecount="2" columns="2">
+ <image source="terrain.png" width="16" height="8"/>
+ <tile id="0">
+  <properties>
+   <property name="solid" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="1" type="water">
+  <animation>
+   <frame tileid="1" duration="100"/>
+   <frame tileid="0" duration="50"/>
+  </animation>
+ </tile>
+</tileset>`
+
+func tiledTestFS(t *testing.T) fstest.MapFS {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, png.Encode(buf, image.NewNRGBA(image.Rect(0, 0, 16, 8))))
+
+	return fstest.MapFS{
+		"maps/level.json":       {Data: []byte(tiledJSON)},
+		"maps/level.tmx":        {Data: []byte(tiledTMX)},
+		"tilesets/terrain.tsx":  {Data: []byte(tiledTSX)},
+		"tilesets/terrain.png":  {Data: buf.Bytes()},
+		"maps/isometric.json":   {Data: []byte(`{"orientation": "isometric"}`)},
+		"maps/infinite.json":    {Data: []byte(`{"orientation": "orthogonal", "infinite": true}`)},
+		"maps/broken_size.json": {Data: []byte(`{"orientation": "orthogonal", "width": 2, "height": 2, "layers": [{"type": "tilelayer", "width": 2, "height": 2, "data": [1]}]}`)},
+		"maps/no_columns.json":  {Data: []byte(`{"orientation": "orthogonal", "tilewidth": 8, "tileheight": 8, "tilesets": [{"firstgid": 1, "name": "terrain", "image": "../tilesets/terrain.png", "tilewidth": 8, "tileheight": 8, "tilecount": 2, "columns": 0}]}`)},
+		"maps/collection.json":  {Data: []byte(`{"orientation": "orthogonal", "tilewidth": 8, "tileheight": 8, "tilesets": [{"firstgid": 1, "name": "props", "tilewidth": 8, "tileheight": 8, "tilecount": 1, "columns": 0, "tiles": [{"id": 0, "image": "../tilesets/terrain.png"}]}]}`)},
+		"maps/wide_tiles.json":  {Data: []byte(`{"orientation": "orthogonal", "tilewidth": 8, "tileheight": 8, "tilesets": [{"firstgid": 1, "name": "big", "image": "../tilesets/terrain.png", "tilewidth": 32, "tileheight": 8, "tilecount": 1, "columns": 0}]}`)},
+	}
+}
+
+func TestLoadTiledMap(t *testing.T) {
+	l := newTiledLoader(tiledTestFS(t), ".", newImageCache())
+
+	for _, name := range []string{"maps/level.json", "maps/level.tmx"} {
+		m, err := l.loadMap(name)
+		assert.NoError(t, err, name)
+
+		assert.Equal(t, 3, m.Width, name)
+		assert.Equal(t, 2, m.Height, name)
+		assert.Equal(t, map[string]any{"music": "cave"}, m.Properties, name)
+
+		assert.Len(t, m.Tilesets, 1, name)
+		ts := m.Tilesets[0]
+		assert.Equal(t, uint32(1), ts.FirstGID, name)
+		assert.Len(t, ts.images, 2, name)
+		assert.Equal(t, map[string]any{"solid": true}, ts.Tiles[0].Properties, name)
+		assert.Equal(t, "water", ts.Tiles[1].Class, name)
+		assert.Equal(t, []TileFrame{{TileID: 1, Duration: 100 * time.Millisecond}, {TileID: 0, Duration: 50 * time.Millisecond}}, ts.Tiles[1].Animation, name)
+
+		assert.Len(t, m.Layers, 2, name)
+		ground := m.Layer("ground")
+		assert.Equal(t, []Tile{1, 2, 0, 0, 2 | TileFlipH, 1}, ground.Tiles, name)
+		assert.Equal(t, Vec2[float32]{X: 1, Y: 1}, ground.Parallax, name)
+		assert.True(t, ground.Visible, name)
+
+		// Groups pass offset, parallax and opacity to their children.
+		deco := m.Layer("deco")
+		assert.Equal(t, make([]Tile, 6), deco.Tiles, name)
+		assert.Equal(t, Vec2[float32]{X: 4}, deco.Offset, name)
+		assert.Equal(t, Vec2[float32]{X: 0.5, Y: 1}, deco.Parallax, name)
+		assert.Equal(t, float32(0.5), deco.Opacity, name)
+
+		assert.Equal(t, []TiledObject{
+			{
+				Properties: map[string]any{"hp": 3},
+				Name:       "hero",
+				Class:      "player",
+				Layer:      "spawns",
+				Pos:        Vec2[float32]{X: 12, Y: 16},
+				ID:         1,
+				Visible:    true,
+			},
+			{
+				Properties: map[string]any{},
+				Layer:      "spawns",
+				Polygon:    []Vec2[float32]{{X: 0, Y: 0}, {X: 8, Y: 0}, {X: 0, Y: 8}},
+				Pos:        Vec2[float32]{X: 4},
+				ID:         2,
+				Visible:    true,
+			},
+		}, m.Objects, name)
+	}
+
+	_, err := l.loadMap("maps/isometric.json")
+	assert.ErrorIs(t, err, ErrUnsupportedOrientation)
+	_, err = l.loadMap("maps/infinite.json")
+	assert.ErrorIs(t, err, ErrInfiniteMap)
+	_, err = l.loadMap("maps/broken_size.json")
+	assert.ErrorIs(t, err, ErrTileDataSize)
+
+	// Missing columns are derived from the image width.
+	m, err := l.loadMap("maps/no_columns.json")
+	assert.NoError(t, err)
+	assert.Len(t, m.Tilesets[0].images, 2)
+	assert.Equal(t, image.Rect(8, 0, 16, 8), m.Tilesets[0].images[1].Bounds())
+	_, err = l.loadMap("maps/collection.json")
+	assert.ErrorIs(t, err, ErrUnsupportedTileset)
+	_, err = l.loadMap("maps/wide_tiles.json")
+	assert.ErrorIs(t, err, ErrInvalidTileset)
+}
+
+func TestDecodeTiles(t *testing.T) {
+	tiles := []Tile{1, 0, 3 | TileFlipV, 42}
+	raw := make([]byte, 4*len(tiles))
+	for i, tile := range tiles {
+		binary.LittleEndian.PutUint32(raw[4*i:], uint32(tile))
+	}
+	compress := func(w io.WriteCloser, buf *bytes.Buffer) string {
+		_, _ = w.Write(raw)
+		w.Close()
+		return base64.StdEncoding.EncodeToString(buf.Bytes())
+	}
+
+	zbuf := &bytes.Buffer{}
+	zlibbed := compress(zlib.NewWriter(zbuf), zbuf)
+	gbuf := &bytes.Buffer{}
+	gzipped := compress(gzip.NewWriter(gbuf), gbuf)
+
+	testcases := []struct {
+		name        string
+		encoding    string
+		compression string
+		payload     string
+		err         error
+	}{
+		{name: "csv", encoding: "csv", payload: "1,0,\n1073741827,42\n"},
+		{name: "base64", encoding: "base64", payload: "\n  " + base64.StdEncoding.EncodeToString(raw) + "\n"},
+		{name: "zlib", encoding: "base64", compression: "zlib", payload: zlibbed},
+		{name: "gzip", encoding: "base64", compression: "gzip", payload: gzipped},
+		{name: "zstd", encoding: "base64", compression: "zstd", payload: "", err: ErrUnsupportedEncoding},
+		{name: "unknown", encoding: "hex", payload: "", err: ErrUnsupportedEncoding},
+	}
+
+	for _, tc := range testcases {
+		decoded, err := decodeTiles(tc.encoding, tc.compression, tc.payload)
+		if tc.err != nil {
+			assert.ErrorIs(t, err, tc.err, tc.name)
+			continue
+		}
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, tiles, decoded, tc.name)
+	}
+}
+
+func TestTileGeoM(t *testing.T) {
+	testcases := []struct {
+		name     string
+		tile     Tile
+		from, to Vec2[float64]
+	}{
+		{name: "none", tile: 1, from: Vec2[float64]{X: 8, Y: 0}, to: Vec2[float64]{X: 8, Y: 0}},
+		{name: "horizontal", tile: 1 | TileFlipH, from: Vec2[float64]{X: 0, Y: 0}, to: Vec2[float64]{X: 8, Y: 0}},
+		{name: "vertical", tile: 1 | TileFlipV, from: Vec2[float64]{X: 0, Y: 0}, to: Vec2[float64]{X: 0, Y: 4}},
+		{name: "diagonal", tile: 1 | TileFlipD, from: Vec2[float64]{X: 8, Y: 0}, to: Vec2[float64]{X: 0, Y: 8}},
+		// Diagonal and horizontal flip rotate by 90 degrees clockwise.
+		{name: "rotate", tile: 1 | TileFlipD | TileFlipH, from: Vec2[float64]{X: 0, Y: 0}, to: Vec2[float64]{X: 4, Y: 0}},
+	}
+
+	for _, tc := range testcases {
+		g := tileGeoM(tc.tile, 8, 4)
+		x, y := g.Apply(tc.from.X, tc.from.Y)
+		assert.InDelta(t, tc.to.X, x, 1e-9, tc.name)
+		assert.InDelta(t, tc.to.Y, y, 1e-9, tc.name)
+	}
+}
+
+func TestTilesetAnimation(t *testing.T) {
+	l := newTiledLoader(tiledTestFS(t), ".", newImageCache())
+	m, err := l.loadMap("maps/level.json")
+	assert.NoError(t, err)
+
+	ts, id := m.tileset(2 | TileFlipH)
+	assert.Equal(t, 1, id)
+	assert.Same(t, ts.images[1], ts.image(id, 0))
+	assert.Same(t, ts.images[0], ts.image(id, 120*time.Millisecond))
+	assert.Same(t, ts.images[1], ts.image(id, 160*time.Millisecond))
+	assert.Same(t, ts.images[0], ts.image(0, 120*time.Millisecond))
+}
+
+func TestVisibleChunks(t *testing.T) {
+	view := image.Rect(0, 0, 320, 240)
+	testcases := []struct {
+		name     string
+		origin   Vec2[float64]
+		margin   image.Point
+		expected image.Rectangle
+	}{
+		{name: "at origin", origin: Vec2[float64]{}, expected: image.Rect(0, 0, 3, 2)},
+		{name: "scrolled", origin: Vec2[float64]{X: -130, Y: -250}, expected: image.Rect(1, 1, 4, 4)},
+		{name: "overflow margin", origin: Vec2[float64]{X: -128, Y: 112}, margin: image.Pt(8, 16), expected: image.Rect(0, 0, 4, 2)},
+		{name: "clamped", origin: Vec2[float64]{X: -1000, Y: -1000}, expected: image.Rect(7, 7, 8, 8)},
+		{name: "right of view", origin: Vec2[float64]{X: 400, Y: 0}, expected: image.Rect(0, 0, 0, 2)},
+	}
+
+	for _, tc := range testcases {
+		chunks := visibleChunks(tc.origin, view, 128, 128, tc.margin, 8, 8)
+		assert.Equal(t, tc.expected, chunks, tc.name)
+	}
+}
+
+func TestLocalView(t *testing.T) {
+	view := image.Rect(0, 0, 320, 240)
+
+	geom := ebiten.GeoM{}
+	local, ok := localView(view, geom)
+	assert.True(t, ok)
+	assert.Equal(t, view, local)
+
+	// A camera zoomed in by 2 and scrolled to the right shows a smaller part of the map.
+	geom.Translate(-100, -50)
+	geom.Scale(2, 2)
+	local, ok = localView(view, geom)
+	assert.True(t, ok)
+	assert.Equal(t, image.Rect(100, 50, 260, 170), local)
+	assert.Equal(t, image.Rect(0, 0, 3, 2), visibleChunks(Vec2[float64]{}, local, 128, 128, image.Point{}, 8, 8))
+
+	// Zoomed out, more chunks are visible than with the plain view.
+	geom.Reset()
+	geom.Scale(0.5, 0.5)
+	local, ok = localView(view, geom)
+	assert.True(t, ok)
+	assert.Equal(t, image.Rect(0, 0, 640, 480), local)
+	assert.Equal(t, image.Rect(0, 0, 5, 4), visibleChunks(Vec2[float64]{}, local, 128, 128, image.Point{}, 8, 8))
+
+	// Rotated views are covered by their bounding box, which rounding errors may widen by a pixel.
+	geom.Reset()
+	geom.Rotate(math.Pi / 2)
+	local, ok = localView(view, geom)
+	assert.True(t, ok)
+	assert.True(t, image.Rect(0, -320, 240, 0).In(local))
+	assert.True(t, local.In(image.Rect(-1, -321, 241, 1)))
+
+	geom.Reset()
+	geom.Scale(0, 1)
+	_, ok = localView(view, geom)
+	assert.False(t, ok)
+}
+
+func TestTilemapEdit(t *testing.T) {
+	l := newTiledLoader(tiledTestFS(t), ".", newImageCache())
+	m, err := l.loadMap("maps/level.json")
+	assert.NoError(t, err)
+
+	tm := newTilemap(m, "ground")
+	assert.Len(t, tm.layers, 1)
+
+	ground := tm.layers[0]
+	tm.renderChunk(ground, 0, 0)
+	assert.False(t, ground.chunks[0].dirty)
+	// The water tile is animated, so it is not cached in the chunk.
+	assert.Equal(t, []int{1, 4}, ground.chunks[0].animated)
+
+	assert.Equal(t, 2|TileFlipH, tm.GetTile("ground", 1, 1))
+	tm.SetTile("ground", 1, 1, 1)
+	assert.Equal(t, Tile(1), tm.GetTile("ground", 1, 1))
+	assert.True(t, ground.chunks[0].dirty)
+
+	// The map in the asset manager is not changed.
+	assert.Equal(t, 2|TileFlipH, m.Layer("ground").Tiles[4])
+
+	// Out of bounds and unknown layers are ignored.
+	tm.SetTile("ground", 3, 0, 1)
+	tm.SetTile("missing", 0, 0, 1)
+	assert.Equal(t, Tile(0), tm.GetTile("ground", -1, 0))
+	assert.Equal(t, Tile(0), tm.GetTile("missing", 0, 0))
+}
@@ -0,0 +1,301 @@
+package vigor
+
+import (
+	"image"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/colorm"
+)
+
+var _ stageable = (*Tilemap)(nil)
+
+// tileChunkSize is the width and height of a cached chunk in tiles.
+const tileChunkSize = 16
+
+// tileChunk caches the static tiles of a chunk. Animated tiles are drawn every frame on top.
+type tileChunk struct {
+	image *ebiten.Image
+	// animated holds the indices of the animated tiles in the layer.
+	animated []int
+	dirty    bool
+}
+
+type tilemapLayer struct {
+	*TileLayer
+	chunks    []tileChunk
+	chunkCols int
+	chunkRows int
+}
+
+// Tilemap is a stageable that draws tile layers of a map from the asset manager.
+// Only the chunks that are visible on the target are drawn.
+type Tilemap struct {
+	tmap   *TiledMap
+	layers []*tilemapLayer
+	camera Vec2[float32]
+	// overflow is how far tiles of tilesets larger than the grid reach out of their cell.
+	overflow image.Point
+	time     time.Duration
+	// collisionLayers is nil if all layers are used for collisions.
+	collisionLayers []*tilemapLayer
+	tileCollisions  map[uint32]TileCollision
+
+	visual
+	Object
+}
+
+// NewTilemap draws the given layers of a map in order. Without layer names all tile layers are drawn.
+// Sprites can be put between layers by creating a Tilemap for the layers below and one for the layers above.
+// The tiles are copied, so changing them does not alter the map in the asset manager.
+func NewTilemap(mapName string, layerNames ...string) *Tilemap {
+	return newTilemap(G.assets.GetTilemapOrPanic(mapName), layerNames...)
+}
+
+func newTilemap(m *TiledMap, layerNames ...string) *Tilemap {
+	t := &Tilemap{
+		Object: NewObject(),
+		visual: newVisual(),
+
+		tmap:           m,
+		layers:         []*tilemapLayer{},
+		tileCollisions: map[uint32]TileCollision{},
+	}
+
+	layers := m.Layers
+	if len(layerNames) > 0 {
+		layers = []*TileLayer{}
+		for _, name := range layerNames {
+			if l := m.Layer(name); l != nil {
+				layers = append(layers, l)
+			}
+		}
+	}
+	for _, l := range layers {
+		c := *l
+		c.Tiles = append([]Tile{}, l.Tiles...)
+		tl := &tilemapLayer{
+			TileLayer: &c,
+			chunkCols: (c.Width + tileChunkSize - 1) / tileChunkSize,
+			chunkRows: (c.Height + tileChunkSize - 1) / tileChunkSize,
+		}
+		tl.chunks = make([]tileChunk, tl.chunkCols*tl.chunkRows)
+		for i := range tl.chunks {
+			tl.chunks[i].dirty = true
+		}
+		t.layers = append(t.layers, tl)
+	}
+
+	for _, ts := range m.Tilesets {
+		t.overflow.X = max(t.overflow.X, ts.TileWidth-m.TileWidth)
+		t.overflow.Y = max(t.overflow.Y, ts.TileHeight-m.TileHeight)
+	}
+	t.SetDim(uint32(m.Width*m.TileWidth), uint32(m.Height*m.TileHeight))
+
+	return t
+}
+
+// Map returns the map with all tilesets and objects.
+func (t *Tilemap) Map() *TiledMap {
+	return t.tmap
+}
+
+// SetCamera sets the top left corner of the view in pixels. Layers are moved by the camera position
+// multiplied with their parallax factor, so a factor of 0.5 scrolls with half the speed.
+func (t *Tilemap) SetCamera(x, y float32) {
+	t.camera = Vec2[float32]{X: x, Y: y}
+}
+
+func (t *Tilemap) Camera() Vec2[float32] {
+	return t.camera
+}
+
+func (t *Tilemap) layer(name string) *tilemapLayer {
+	for _, l := range t.layers {
+		if l.Name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// GetTile returns the tile at the grid coordinate of a layer. It is zero outside of the layer.
+func (t *Tilemap) GetTile(layer string, x, y int) Tile {
+	l := t.layer(layer)
+	if l == nil || x < 0 || y < 0 || x >= l.Width || y >= l.Height {
+		return 0
+	}
+	return l.Tiles[y*l.Width+x]
+}
+
+// SetTile changes the tile at the grid coordinate of a layer and redraws only its chunk.
+// Coordinates outside of the layer are ignored.
+func (t *Tilemap) SetTile(layer string, x, y int, tile Tile) {
+	l := t.layer(layer)
+	if l == nil || x < 0 || y < 0 || x >= l.Width || y >= l.Height {
+		return
+	}
+	l.Tiles[y*l.Width+x] = tile
+	l.chunks[(y/tileChunkSize)*l.chunkCols+x/tileChunkSize].dirty = true
+}
+
+func (t *Tilemap) Update() {
+	t.Object.Update()
+	t.time += time.Duration(float64(G.Dt()) * float64(time.Second))
+}
+
+// visibleChunks returns the range [min, max) of chunks that intersect the view.
+// The origin is the position of the layer within the view and margin extends the chunks.
+func visibleChunks(origin Vec2[float64], view image.Rectangle, chunkW, chunkH int, margin image.Point, cols, rows int) image.Rectangle {
+	x0 := int(math.Floor((float64(view.Min.X) - origin.X - float64(margin.X)) / float64(chunkW)))
+	y0 := int(math.Floor((float64(view.Min.Y) - origin.Y) / float64(chunkH)))
+	x1 := int(math.Ceil((float64(view.Max.X) - origin.X) / float64(chunkW)))
+	y1 := int(math.Ceil((float64(view.Max.Y) - origin.Y + float64(margin.Y)) / float64(chunkH)))
+	r := image.Rectangle{
+		Min: image.Pt(max(0, x0), max(0, y0)),
+		Max: image.Pt(min(cols, x1), min(rows, y1)),
+	}
+	// Layers outside of the view have no visible chunks.
+	r.Max.X = max(r.Min.X, r.Max.X)
+	r.Max.Y = max(r.Min.Y, r.Max.Y)
+	return r
+}
+
+// localView returns the bounds of the view in the coordinates before geom is applied, so chunks are culled
+// correctly when the tilemap is drawn scaled, rotated or translated. It is false if geom cannot be inverted.
+func localView(view image.Rectangle, geom ebiten.GeoM) (image.Rectangle, bool) {
+	if !geom.IsInvertible() {
+		return image.Rectangle{}, false
+	}
+	geom.Invert()
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range []image.Point{view.Min, {view.Max.X, view.Min.Y}, {view.Min.X, view.Max.Y}, view.Max} {
+		x, y := geom.Apply(float64(p.X), float64(p.Y))
+		minX, minY = min(minX, x), min(minY, y)
+		maxX, maxY = max(maxX, x), max(maxY, y)
+	}
+	return image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY))), true
+}
+
+// tileImage returns the current image of a tile and the position of its top left corner within its chunk.
+func (t *Tilemap) tileImage(l *tilemapLayer, i int) (*ebiten.Image, *Tileset, image.Point) {
+	tile := l.Tiles[i]
+	if tile.GID() == 0 {
+		return nil, nil, image.Point{}
+	}
+	ts, id := t.tmap.tileset(tile)
+	if ts == nil {
+		return nil, nil, image.Point{}
+	}
+	img := ts.image(id, t.time)
+	if img == nil {
+		return nil, nil, image.Point{}
+	}
+
+	// Tiles larger than the grid are aligned to the bottom left corner of their cell.
+	col, row := i%l.Width%tileChunkSize, i/l.Width%tileChunkSize
+	pos := image.Pt(col*t.tmap.TileWidth, t.overflow.Y+(row+1)*t.tmap.TileHeight-ts.TileHeight)
+	return img, ts, pos
+}
+
+func (t *Tilemap) isAnimated(tile Tile) bool {
+	ts, id := t.tmap.tileset(tile)
+	if ts == nil {
+		return false
+	}
+	info, ok := ts.Tiles[id]
+	return ok && len(info.Animation) > 0
+}
+
+// chunkTiles calls f with the index of every tile of a chunk in the layer.
+func chunkTiles(l *tilemapLayer, cx, cy int, f func(i int)) {
+	for y := cy * tileChunkSize; y < min(l.Height, (cy+1)*tileChunkSize); y++ {
+		for x := cx * tileChunkSize; x < min(l.Width, (cx+1)*tileChunkSize); x++ {
+			f(y*l.Width + x)
+		}
+	}
+}
+
+func (t *Tilemap) renderChunk(l *tilemapLayer, cx, cy int) {
+	c := &l.chunks[cy*l.chunkCols+cx]
+	c.dirty = false
+	c.animated = c.animated[:0]
+
+	if c.image == nil {
+		c.image = ebiten.NewImage(tileChunkSize*t.tmap.TileWidth+t.overflow.X, tileChunkSize*t.tmap.TileHeight+t.overflow.Y)
+	} else {
+		c.image.Clear()
+	}
+
+	chunkTiles(l, cx, cy, func(i int) {
+		if t.isAnimated(l.Tiles[i]) {
+			c.animated = append(c.animated, i)
+			return
+		}
+		img, ts, pos := t.tileImage(l, i)
+		if img == nil {
+			return
+		}
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM = tileGeoM(l.Tiles[i], ts.TileWidth, ts.TileHeight)
+		op.GeoM.Translate(float64(pos.X), float64(pos.Y))
+		c.image.DrawImage(img, op)
+	})
+}
+
+func (t *Tilemap) draw(target *ebiten.Image, op colorm.DrawImageOptions) {
+	m := t.tmap
+	chunkW := tileChunkSize * m.TileWidth
+	chunkH := tileChunkSize * m.TileHeight
+	view, ok := localView(target.Bounds(), op.GeoM)
+	if !ok {
+		return
+	}
+
+	for _, l := range t.layers {
+		if !l.Visible {
+			continue
+		}
+		cm := colorm.ColorM{}
+		cm.Scale(1, 1, 1, float64(l.Opacity))
+
+		origin := Vec2[float64]{
+			X: float64(t.pos.X+l.Offset.X) - float64(t.camera.X*l.Parallax.X),
+			Y: float64(t.pos.Y+l.Offset.Y) - float64(t.camera.Y*l.Parallax.Y),
+		}
+		origin.X, origin.Y = math.Floor(origin.X), math.Floor(origin.Y)
+
+		chunks := visibleChunks(origin, view, chunkW, chunkH, t.overflow, l.chunkCols, l.chunkRows)
+		for cy := chunks.Min.Y; cy < chunks.Max.Y; cy++ {
+			for cx := chunks.Min.X; cx < chunks.Max.X; cx++ {
+				c := &l.chunks[cy*l.chunkCols+cx]
+				if c.dirty {
+					t.renderChunk(l, cx, cy)
+				}
+
+				chunkOrigin := Vec2[float64]{
+					X: origin.X + float64(cx*chunkW),
+					Y: origin.Y + float64(cy*chunkH-t.overflow.Y),
+				}
+				chunkOp := colorm.DrawImageOptions{}
+				chunkOp.GeoM.Translate(chunkOrigin.X, chunkOrigin.Y)
+				chunkOp.GeoM.Concat(op.GeoM)
+				colorm.DrawImage(target, c.image, cm, &chunkOp)
+
+				for _, i := range c.animated {
+					img, ts, pos := t.tileImage(l, i)
+					if img == nil {
+						continue
+					}
+					tileOp := colorm.DrawImageOptions{}
+					tileOp.GeoM = tileGeoM(l.Tiles[i], ts.TileWidth, ts.TileHeight)
+					tileOp.GeoM.Translate(chunkOrigin.X+float64(pos.X), chunkOrigin.Y+float64(pos.Y))
+					tileOp.GeoM.Concat(op.GeoM)
+					colorm.DrawImage(target, img, cm, &tileOp)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package vigor
+
+import (
+	"github.com/dbriemann/vigor/config"
+)
+
+var (
+	ErrUnknownSection = config.ErrUnknownSection
+	ErrDuplicateName  = config.ErrDuplicateName
+	ErrNotPositive    = config.ErrNotPositive
+	ErrNegative       = config.ErrNegative
+	ErrInvalidImage   = config.ErrInvalidImage
+)
+
+// ConfigError is a problem in a config, located by the JSON path of the offending field.
+type ConfigError = config.Error
+
+// ConfigErrors are all problems found in a config.
+type ConfigErrors = config.Errors
+
+// ValidateConfig checks a config file without loading its assets. Unlike loading, it does not stop
+// at the first problem but returns ConfigErrors with all of them. Tools that run without a display
+// should use package config directly, since importing vigor initializes Ebitengine.
+func ValidateConfig(fname string) error {
+	return config.ValidateFile(fname)
+}